@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"github.com/maxsnegir/zones_service/internal/config"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/logger"
+	"github.com/maxsnegir/zones_service/internal/repository/psql"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+// importer loads zones from NDJSON, one FeatureCollection per line, and prints
+// the created ids, or the rejected lines, as JSON.
+func main() {
+	var databaseDsn, filePath, crs string
+	var strict bool
+
+	flag.StringVar(&databaseDsn, "database-dsn", "", "database dsn")
+	flag.StringVar(&filePath, "file", "", "path to NDJSON file, stdin by default")
+	flag.BoolVar(&strict, "strict", false, "parse lines as strict RFC 7946 GeoJSON")
+	flag.StringVar(&crs, "crs", "", "crs of the lines, e.g. EPSG:3857, the crs member or EPSG:4326 by default")
+	flag.Parse()
+
+	if databaseDsn == "" {
+		databaseDsn = os.Getenv("DATABASE_DSN")
+	}
+	if databaseDsn == "" {
+		log.Fatalf("database-dsn is required")
+	}
+
+	var srid int
+	if crs != "" {
+		var err error
+		if srid, err = dto.ParseCRS(crs); err != nil {
+			log.Fatalf("%s", err)
+		}
+	}
+
+	var input io.Reader = os.Stdin
+	if filePath != "" {
+		file, err := os.Open(filePath)
+		if err != nil {
+			log.Fatalf("failed to open file: %s", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	ctx := context.Background()
+	appLog := logger.New(config.EnvProd)
+	appLog.Out = os.Stderr
+
+	storage, err := psql.New(ctx, appLog, databaseDsn)
+	if err != nil {
+		log.Fatalf("failed to connect to database: %s", err)
+	}
+	defer storage.ShutDown()
+
+	mode := dto.GeoJSONLenient
+	if strict {
+		mode = dto.GeoJSONStrict
+	}
+
+	zoneService := zone.New(appLog, storage, storage, storage)
+	result, err := zoneService.ImportZones(ctx, input, mode, srid)
+	if err != nil {
+		log.Fatalf("failed to import zones: %s", err)
+	}
+	if err = json.NewEncoder(os.Stdout).Encode(result); err != nil {
+		log.Fatalf("failed to write result: %s", err)
+	}
+	if len(result.Errors) > 0 {
+		storage.ShutDown()
+		os.Exit(1)
+	}
+}
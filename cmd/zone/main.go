@@ -2,17 +2,27 @@ package main
 
 import (
 	"context"
+	"fmt"
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
 	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
 
 	"github.com/maxsnegir/zones_service/internal/app/pprof_server"
 	"github.com/maxsnegir/zones_service/internal/config"
+	"github.com/maxsnegir/zones_service/internal/dto"
 	"github.com/maxsnegir/zones_service/internal/logger"
+	"github.com/maxsnegir/zones_service/internal/repository/indexed"
+	"github.com/maxsnegir/zones_service/internal/repository/memory"
 	"github.com/maxsnegir/zones_service/internal/repository/psql"
+	"github.com/maxsnegir/zones_service/internal/service/changes"
+	"github.com/maxsnegir/zones_service/internal/service/geofence"
+	"github.com/maxsnegir/zones_service/internal/service/layer"
+	"github.com/maxsnegir/zones_service/internal/service/webhook"
 	"github.com/maxsnegir/zones_service/internal/service/zone"
 
 	httpserver "github.com/maxsnegir/zones_service/internal/app/http"
@@ -24,17 +34,52 @@ func main() {
 	log := logger.New(cfg.Env)
 	log.Debugf("config: %+v", cfg)
 
-	storage, err := psql.New(ctx, log, cfg.Storage.DSN)
+	storage, err := newStorage(ctx, log, cfg.Storage)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	zoneService := zone.New(log, storage, storage, storage)
 	appRouter := httpserver.NewRouter(mux.NewRouter(), zoneService, log)
+	changesService := changes.New(log, storage, cfg.Changes)
+	appRouter.ChangesService = changesService
+	webhookService := webhook.New(log, storage, webhook.NewClient(), cfg.Webhook)
+	appRouter.WebhookService = webhookService
+	appRouter.LayerService = layer.New(log, storage)
+	appRouter.GeofenceService = geofence.New(log, storage, memory.NewDeviceStateStore(), webhookService, cfg.Geofence.DwellTime)
 	appRouter.ConfigureRouter()
 	app := httpserver.New(appRouter, cfg.Server.Host, cfg.Server.Port, log)
 
+	// The workers use storage, it is shut down after they return.
+	var workers sync.WaitGroup
+	runWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(ctx)
+		}()
+	}
+
+	if pgStorage, ok := postgresOf(storage); ok {
+		// Other instances write zones too, their changes arrive through LISTEN/NOTIFY.
+		listener := psql.NewListener(log, pgStorage)
+		listener.Subscribe(func(dto.ZoneChange) { changesService.Notify() })
+		indexedStorage, isIndexed := storage.(*indexed.Storage)
+		if isIndexed {
+			listener.Subscribe(indexedStorage.Track)
+		}
+		runWorker(listener.Run)
+		if isIndexed {
+			// Zones are loaded once the listener is ready, so no change is missed in between.
+			<-listener.Ready()
+			if err = indexedStorage.Load(ctx); err != nil {
+				log.Fatal(err)
+			}
+			runWorker(indexedStorage.Run)
+		}
+	}
 	go app.MustRun()
+	runWorker(webhookService.Run)
 	pprof_server.ServePprof(ctx, log)
 
 	// Graceful shutdown
@@ -42,8 +87,46 @@ func main() {
 	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
 
 	<-stop
-	defer cancel()
 	app.Stop()
+	cancel()
+	workers.Wait()
 	storage.ShutDown()
 	log.Info("Gracefully stopped")
 }
+
+type storage interface {
+	zone.Saver
+	zone.Provider
+	zone.Deleter
+	webhook.Store
+	changes.Store
+	layer.Store
+	ShutDown()
+}
+
+func newStorage(ctx context.Context, log *logrus.Logger, cfg config.StorageConfig) (storage, error) {
+	switch cfg.Type {
+	case config.StorageTypePostgres:
+		return psql.New(ctx, log, cfg.DSN)
+	case config.StorageTypeIndexed:
+		pgStorage, err := psql.New(ctx, log, cfg.DSN)
+		if err != nil {
+			return nil, err
+		}
+		// The zones are loaded by main, once their changes are listened to.
+		return indexed.New(log, pgStorage), nil
+	default:
+		return nil, fmt.Errorf("unknown storage type: %s", cfg.Type)
+	}
+}
+
+// postgresOf returns the Postgres storage the zones are kept in, if any.
+func postgresOf(s storage) (*psql.Storage, bool) {
+	switch s := s.(type) {
+	case *psql.Storage:
+		return s, true
+	case *indexed.Storage:
+		return s.Storage, true
+	}
+	return nil, false
+}
@@ -0,0 +1,273 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	storageMock "github.com/maxsnegir/zones_service/internal/repository/mocks"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+func TestBatchContainsPoint_Ok(t *testing.T) {
+	ctx := context.Background()
+
+	polygonZoneId, err := createZoneFixture(ctx, polygonGeoJson)
+	require.NoError(t, err)
+	multiPolygonZoneId, err := createZoneFixture(ctx, multiPolygonGeoJson)
+	require.NoError(t, err)
+
+	defer storage.CleanDB(ctx)
+
+	request := dto.BatchZoneContainsPointInCollection{
+		{Key: "first", ZoneIds: []int{polygonZoneId}, Point: dto.Point{Lon: 0.6336, Lat: 0.5439}},
+		{Key: "second", ZoneIds: []int{multiPolygonZoneId}, Point: dto.Point{Lon: 2.4728, Lat: 1.6995}},
+		{Key: "third", ZoneIds: []int{polygonZoneId, multiPolygonZoneId}, Point: dto.Point{Lon: 2.5448, Lat: 2.6211}},
+		{Key: "not existing", ZoneIds: []int{100, 1001}, Point: dto.Point{Lon: 0.6336, Lat: 0.5439}},
+		{Key: "no ids", ZoneIds: []int{}, Point: dto.Point{Lon: 0.6336, Lat: 0.5439}},
+		{
+			Key:             "covers edge",
+			ZoneIds:         []int{polygonZoneId},
+			Point:           dto.Point{Lon: 1, Lat: 0.5},
+			ContainsOptions: dto.ContainsOptions{Predicate: dto.PredicateCovers},
+		},
+		{
+			Key:             "wrong predicate",
+			ZoneIds:         []int{polygonZoneId},
+			Point:           dto.Point{Lon: 1, Lat: 0.5},
+			ContainsOptions: dto.ContainsOptions{Predicate: "within"},
+		},
+		{Key: "wrong lat", ZoneIds: []int{polygonZoneId}, Point: dto.Point{Lon: 0.6336, Lat: 91}},
+		{Key: "wrong ids", ZoneIds: []int{-1}, Point: dto.Point{Lon: 0.6336, Lat: 0.5439}},
+	}
+	expected := []dto.BatchZoneContainsPointOut{
+		{Key: "first", Contains: true, Position: dto.PositionInside},
+		{Key: "second", Contains: false, Position: dto.PositionOutside},
+		{Key: "third", Contains: true, Position: dto.PositionInside},
+		{Key: "not existing", Contains: false, Position: dto.PositionOutside},
+		{Key: "no ids", Contains: false, Position: dto.PositionOutside},
+		{Key: "covers edge", Contains: true, Position: dto.PositionOnEdge},
+		{Key: "wrong predicate", Error: dto.ErrInvalidPredicate.Error()},
+		{Key: "wrong lat", Error: dto.InvalidLatitudeError.Error()},
+		{Key: "wrong ids", Error: dto.ErrInvalidId.Error()},
+	}
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+
+	rawRequest, err := json.Marshal(request)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, batchAnyZonesContainsPoint, bytes.NewBuffer(rawRequest))
+	r.BatchAnyOfZonesContainsPint()(w, req)
+
+	response := w.Result()
+	defer func() { require.NoError(t, response.Body.Close()) }()
+
+	require.Equal(t, response.Header.Get("Content-Type"), "application/json")
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var actual []dto.BatchZoneContainsPointOut
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+	require.Equal(t, expected, actual)
+}
+
+func TestBatchContainsPoint_Err(t *testing.T) {
+	type errResponse struct {
+		Error string `json:"error"`
+	}
+
+	items := make([]string, 0, dto.MaxBatchSize+1)
+	for i := 0; i < cap(items); i++ {
+		items = append(items, fmt.Sprintf(`{"key": "%d", "ids": [1], "point": {"lon": 0, "lat": 0}}`, i))
+	}
+	tooLargeBatch := "[" + strings.Join(items, ",") + "]"
+
+	tests := []struct {
+		name               string
+		requestData        string
+		dbErr              bool
+		expectedResponse   errResponse
+		expectedStatusCode int
+	}{
+		{
+			name:               "wrong body",
+			requestData:        `{"key": "a"}`,
+			expectedResponse:   errResponse{Error: geojson.SerializationErr.Error()},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "empty batch",
+			requestData:        `[]`,
+			expectedResponse:   errResponse{Error: dto.ErrEmptyData.Error()},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "duplicate keys",
+			requestData:        `[{"key": "a", "ids": [1]}, {"key": "a", "ids": [2]}]`,
+			expectedResponse:   errResponse{Error: dto.ErrDuplicateKey.Error()},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "too large batch",
+			requestData:        tooLargeBatch,
+			expectedResponse:   errResponse{Error: dto.ErrBatchTooLarge.Error()},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "db error",
+			requestData:        `[{"key": "a", "ids": [1], "point": {"lon": 0, "lat": 0}}]`,
+			dbErr:              true,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: "db error is not retried by item",
+			requestData: `[
+				{"key": "a", "ids": [1], "point": {"lon": 0, "lat": 0}},
+				{"key": "b", "ids": [1], "point": {"lon": 1, "lat": 1}},
+				{"key": "c", "ids": [1], "point": {"lon": 2, "lat": 2}}
+			]`,
+			dbErr:              true,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockSaver := storageMock.NewMockSaver(ctrl)
+			mockProvider := storageMock.NewMockProvider(ctrl)
+			mockDeleter := storageMock.NewMockDeleter(ctrl)
+			if tt.dbErr {
+				mockProvider.EXPECT().
+					ButchAnyZoneContainsPoint(gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("DB DOWN")).
+					Times(1)
+			}
+
+			zoneService := zone.New(log, mockSaver, mockProvider, mockDeleter)
+			r := NewRouter(mux.NewRouter(), zoneService, log)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, batchAnyZonesContainsPoint, bytes.NewBuffer([]byte(tt.requestData)))
+			r.BatchAnyOfZonesContainsPint()(w, req)
+
+			response := w.Result()
+			defer func() { require.NoError(t, response.Body.Close()) }()
+
+			require.Equal(t, response.Header.Get("Content-Type"), "application/json")
+			require.Equal(t, tt.expectedStatusCode, response.StatusCode)
+
+			if !tt.dbErr {
+				var actual errResponse
+				require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+				require.Equal(t, tt.expectedResponse, actual)
+			}
+		})
+	}
+}
+
+func TestBatchContainsPoint_PartialFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSaver := storageMock.NewMockSaver(ctrl)
+	mockProvider := storageMock.NewMockProvider(ctrl)
+	mockDeleter := storageMock.NewMockDeleter(ctrl)
+
+	valid := dto.BatchZoneContainsPointInCollection{
+		{Key: "ok", ZoneIds: []int{1}, Point: dto.Point{Lon: 1, Lat: 1}},
+	}
+	mockProvider.EXPECT().
+		ButchAnyZoneContainsPoint(gomock.Any(), valid).
+		Return([]dto.BatchZoneContainsPointOut{{Key: "ok", Contains: true}}, nil).
+		Times(1)
+
+	zoneService := zone.New(log, mockSaver, mockProvider, mockDeleter)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+
+	requestData := `[
+		{"key": "bad lon", "ids": [1], "point": {"lon": 181, "lat": 1}},
+		{"key": "ok", "ids": [1], "point": {"lon": 1, "lat": 1}},
+		{"key": "bad id", "ids": [0], "point": {"lon": 1, "lat": 1}}
+	]`
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, batchAnyZonesContainsPoint, bytes.NewBufferString(requestData))
+	r.BatchAnyOfZonesContainsPint()(w, req)
+
+	response := w.Result()
+	defer func() { require.NoError(t, response.Body.Close()) }()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var actual []dto.BatchZoneContainsPointOut
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+	require.Equal(t, []dto.BatchZoneContainsPointOut{
+		{Key: "bad lon", Error: dto.InvalidLongitudeError.Error()},
+		{Key: "ok", Contains: true},
+		{Key: "bad id", Error: dto.ErrInvalidId.Error()},
+	}, actual)
+}
+
+func TestBatchContainsPoint_ProviderItemFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSaver := storageMock.NewMockSaver(ctrl)
+	mockProvider := storageMock.NewMockProvider(ctrl)
+	mockDeleter := storageMock.NewMockDeleter(ctrl)
+
+	// The provider rejects every batch holding "boom", the other items are checked in
+	// the halves without it.
+	mockProvider.EXPECT().
+		ButchAnyZoneContainsPoint(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, in dto.BatchZoneContainsPointInCollection) ([]dto.BatchZoneContainsPointOut, error) {
+			out := make([]dto.BatchZoneContainsPointOut, 0, len(in))
+			for _, item := range in {
+				if item.Key == "boom" {
+					return nil, fmt.Errorf("storage: %w", dto.ErrBatchItemRejected)
+				}
+				out = append(out, dto.BatchZoneContainsPointOut{Key: item.Key, Contains: true})
+			}
+			return out, nil
+		}).
+		AnyTimes()
+
+	zoneService := zone.New(log, mockSaver, mockProvider, mockDeleter)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+
+	requestData := `[
+		{"key": "a", "ids": [1], "point": {"lon": 1, "lat": 1}},
+		{"key": "boom", "ids": [1], "point": {"lon": 1, "lat": 1}},
+		{"key": "b", "ids": [1], "point": {"lon": 1, "lat": 1}}
+	]`
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, batchAnyZonesContainsPoint, bytes.NewBufferString(requestData))
+	r.BatchAnyOfZonesContainsPint()(w, req)
+
+	response := w.Result()
+	defer func() { require.NoError(t, response.Body.Close()) }()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var actual []dto.BatchZoneContainsPointOut
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+	require.Equal(t, []dto.BatchZoneContainsPointOut{
+		{Key: "a", Contains: true},
+		{Key: "boom", Error: dto.ErrBatchItemRejected.Error()},
+		{Key: "b", Contains: true},
+	}, actual)
+}
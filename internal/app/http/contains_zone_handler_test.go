@@ -39,7 +39,7 @@ func TestContainsPoint_Ok(t *testing.T) {
 				ZoneIds: []int{polygonZoneId},
 				Point:   dto.Point{Lon: 0.6336, Lat: 0.5439},
 			},
-			expected: []dto.ZoneContainsPointOut{{ZoneId: polygonZoneId, Contains: true}},
+			expected: []dto.ZoneContainsPointOut{{ZoneId: polygonZoneId, Contains: true, Position: dto.PositionInside}},
 		},
 		{
 			name: "polygon: second feature contains point",
@@ -47,7 +47,7 @@ func TestContainsPoint_Ok(t *testing.T) {
 				ZoneIds: []int{polygonZoneId},
 				Point:   dto.Point{Lon: 2.5448, Lat: 2.6211},
 			},
-			expected: []dto.ZoneContainsPointOut{{ZoneId: polygonZoneId, Contains: true}},
+			expected: []dto.ZoneContainsPointOut{{ZoneId: polygonZoneId, Contains: true, Position: dto.PositionInside}},
 		},
 		{
 			name: "polygon: dont contains point",
@@ -55,7 +55,7 @@ func TestContainsPoint_Ok(t *testing.T) {
 				ZoneIds: []int{polygonZoneId},
 				Point:   dto.Point{Lon: 2.4728, Lat: 1.6995},
 			},
-			expected: []dto.ZoneContainsPointOut{{ZoneId: polygonZoneId, Contains: false}},
+			expected: []dto.ZoneContainsPointOut{{ZoneId: polygonZoneId, Contains: false, Position: dto.PositionOutside}},
 		},
 		{
 			name: "multipolygon: first polygon contains point",
@@ -63,7 +63,7 @@ func TestContainsPoint_Ok(t *testing.T) {
 				ZoneIds: []int{multiPolygonZoneId},
 				Point:   dto.Point{Lon: 0.6336, Lat: 0.5439},
 			},
-			expected: []dto.ZoneContainsPointOut{{ZoneId: multiPolygonZoneId, Contains: true}},
+			expected: []dto.ZoneContainsPointOut{{ZoneId: multiPolygonZoneId, Contains: true, Position: dto.PositionInside}},
 		},
 		{
 			name: "multipolygon: first polygon contains point",
@@ -71,7 +71,7 @@ func TestContainsPoint_Ok(t *testing.T) {
 				ZoneIds: []int{multiPolygonZoneId},
 				Point:   dto.Point{Lon: 2.5448, Lat: 2.6211},
 			},
-			expected: []dto.ZoneContainsPointOut{{ZoneId: multiPolygonZoneId, Contains: true}},
+			expected: []dto.ZoneContainsPointOut{{ZoneId: multiPolygonZoneId, Contains: true, Position: dto.PositionInside}},
 		},
 		{
 			name: "multipolygon: dont contains point",
@@ -79,7 +79,7 @@ func TestContainsPoint_Ok(t *testing.T) {
 				ZoneIds: []int{multiPolygonZoneId},
 				Point:   dto.Point{Lon: 2.4728, Lat: 1.6995},
 			},
-			expected: []dto.ZoneContainsPointOut{{ZoneId: multiPolygonZoneId, Contains: false}},
+			expected: []dto.ZoneContainsPointOut{{ZoneId: multiPolygonZoneId, Contains: false, Position: dto.PositionOutside}},
 		},
 		{
 			name: "polygon and multipolygon contains point",
@@ -88,8 +88,8 @@ func TestContainsPoint_Ok(t *testing.T) {
 				Point:   dto.Point{Lon: 0.6336, Lat: 0.5439},
 			},
 			expected: []dto.ZoneContainsPointOut{
-				{ZoneId: polygonZoneId, Contains: true},
-				{ZoneId: multiPolygonZoneId, Contains: true},
+				{ZoneId: polygonZoneId, Contains: true, Position: dto.PositionInside},
+				{ZoneId: multiPolygonZoneId, Contains: true, Position: dto.PositionInside},
 			},
 		},
 		{
@@ -99,10 +99,45 @@ func TestContainsPoint_Ok(t *testing.T) {
 				Point:   dto.Point{Lon: 2.4728, Lat: 1.6995},
 			},
 			expected: []dto.ZoneContainsPointOut{
-				{ZoneId: polygonZoneId, Contains: false},
-				{ZoneId: multiPolygonZoneId, Contains: false},
+				{ZoneId: polygonZoneId, Contains: false, Position: dto.PositionOutside},
+				{ZoneId: multiPolygonZoneId, Contains: false, Position: dto.PositionOutside},
 			},
 		},
+		{
+			name: "polygon: point on boundary",
+			request: dto.ZoneContainsPointIn{
+				ZoneIds: []int{polygonZoneId},
+				Point:   dto.Point{Lon: 1, Lat: 0.5},
+			},
+			expected: []dto.ZoneContainsPointOut{{ZoneId: polygonZoneId, Contains: false, Position: dto.PositionOnEdge}},
+		},
+		{
+			name: "polygon: covers point on boundary",
+			request: dto.ZoneContainsPointIn{
+				ZoneIds:         []int{polygonZoneId},
+				Point:           dto.Point{Lon: 1, Lat: 0.5},
+				ContainsOptions: dto.ContainsOptions{Predicate: dto.PredicateCovers},
+			},
+			expected: []dto.ZoneContainsPointOut{{ZoneId: polygonZoneId, Contains: true, Position: dto.PositionOnEdge}},
+		},
+		{
+			name: "polygon: point outside within tolerance",
+			request: dto.ZoneContainsPointIn{
+				ZoneIds:         []int{polygonZoneId},
+				Point:           dto.Point{Lon: 1.0005, Lat: 0.5},
+				ContainsOptions: dto.ContainsOptions{Tolerance: 100},
+			},
+			expected: []dto.ZoneContainsPointOut{{ZoneId: polygonZoneId, Contains: true, Position: dto.PositionOnEdge}},
+		},
+		{
+			name: "polygon: point outside beyond tolerance",
+			request: dto.ZoneContainsPointIn{
+				ZoneIds:         []int{polygonZoneId},
+				Point:           dto.Point{Lon: 1.0005, Lat: 0.5},
+				ContainsOptions: dto.ContainsOptions{Tolerance: 10},
+			},
+			expected: []dto.ZoneContainsPointOut{{ZoneId: polygonZoneId, Contains: false, Position: dto.PositionOutside}},
+		},
 		{
 			name: "not existing ids",
 			request: dto.ZoneContainsPointIn{
@@ -186,6 +221,18 @@ func TestContainsPoint_Err(t *testing.T) {
 			expectedResponse:   errResponse{Error: dto.InvalidLongitudeError.Error()},
 			expectedStatusCode: http.StatusBadRequest,
 		},
+		{
+			name:               "wrong predicate",
+			requestData:        `{"ids": [1, 2], "point": {"lon": 0, "lat": 0}, "predicate": "within"}`,
+			expectedResponse:   errResponse{Error: dto.ErrInvalidPredicate.Error()},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "wrong tolerance",
+			requestData:        `{"ids": [1, 2], "point": {"lon": 0, "lat": 0}, "tolerance": -5}`,
+			expectedResponse:   errResponse{Error: dto.ErrInvalidTolerance.Error()},
+			expectedStatusCode: http.StatusBadRequest,
+		},
 		{
 			name:               "db error",
 			requestData:        `{"ids": [1, 2], "point": {"lon": 0, "lat": 0}}`,
@@ -205,7 +252,7 @@ func TestContainsPoint_Err(t *testing.T) {
 
 			if tt.dbErr == true {
 				mockProvider.EXPECT().
-					ContainsPoint(gomock.Any(), gomock.Any(), gomock.Any()).
+					ContainsPoint(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 					Return([]dto.ZoneContainsPointOut{}, errors.New("DB DOWN")).
 					Times(1)
 			}
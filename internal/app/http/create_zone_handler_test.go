@@ -17,16 +17,22 @@ import (
 	"github.com/maxsnegir/zones_service/internal/domain/geojson"
 	"github.com/maxsnegir/zones_service/internal/dto"
 	storageMock "github.com/maxsnegir/zones_service/internal/repository/mocks"
-	"github.com/maxsnegir/zones_service/internal/repository/psql"
 	"github.com/maxsnegir/zones_service/internal/service/zone"
 )
 
 type expectedResponse struct {
-	ZoneId int    `json:"id"`
-	Error  string `json:"error,omitempty"`
+	ZoneId         int                 `json:"id"`
+	Error          string              `json:"error,omitempty"`
+	GeometryErrors []dto.GeometryError `json:"geometry_errors,omitempty"`
 }
 
 func TestCreateZoneHandlerErr(t *testing.T) {
+	tooFewPointsErr := dto.GeometryError{
+		Reason:   dto.GeometryErrorTooFewPoints,
+		Message:  geojson.TooFewPointsMessage,
+		Location: &dto.Point{Lon: 1, Lat: 2},
+		Path:     "/features/0/geometry/coordinates/0",
+	}
 	tests := []struct {
 		name               string
 		data               string
@@ -96,11 +102,26 @@ func TestCreateZoneHandlerErr(t *testing.T) {
 				Error: geojson.NotValidMultiPolygonCoordinatesErr.Error(),
 			},
 		},
+		{
+			name: "point without radius",
+			data: `{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {}, "geometry": {"type": "Point", "coordinates": [1, 2]}}]}`,
+			expectedData: expectedResponse{
+				Error: geojson.RadiusIsRequiredErr.Error(),
+			},
+		},
+		{
+			name: "wrong linestring coordinates",
+			data: `{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {}, "radius": 10, "geometry": {"type": "LineString", "coordinates": [[1, 2]]}}]}`,
+			expectedData: expectedResponse{
+				Error: geojson.NotValidLineStringCoordinatesErr.Error(),
+			},
+		},
 		{
 			name: "wrong polygon coordinates",
 			data: `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[1, 2]]]}}]}`,
 			expectedData: expectedResponse{
-				Error: psql.PostgisValidationErr{Message: "Polygon must have at least four points in each ring"}.Error(),
+				Error:          dto.GeometryValidationErr{Errors: []dto.GeometryError{tooFewPointsErr}}.Error(),
+				GeometryErrors: []dto.GeometryError{tooFewPointsErr},
 			},
 		},
 	}
@@ -176,7 +197,7 @@ func TestCreateZoneHandler_Ok(t *testing.T) {
 			require.Equal(t, data.ZoneId, tt.expectedId)
 			require.Equal(t, data.Error, "")
 
-			zones, err := storage.GetZonesByIds(ctx, []int{tt.expectedId})
+			zones, err := storage.GetZonesByIds(ctx, []int{tt.expectedId}, nil, dto.WGS84)
 			assert.NoError(t, err)
 
 			require.Equal(t, len(zones), 1)
@@ -186,7 +207,14 @@ func TestCreateZoneHandler_Ok(t *testing.T) {
 			if err = json.Unmarshal([]byte(tt.geoJson), &geoJsonFromDb); err != nil {
 				t.Fatal(err)
 			}
-			require.EqualValues(t, geoJsonFromDb, zones[0].GeoJSON)
+			actual := zones[0].GeoJSON
+			require.Equal(t, []float64{0, 0, 3, 3}, actual.BBox)
+			actual.BBox = nil
+			for i := range actual.Features {
+				require.Len(t, actual.Features[i].BBox, 4)
+				actual.Features[i].BBox = nil
+			}
+			require.EqualValues(t, geoJsonFromDb, actual)
 
 			count, err := storage.GetZonesCount(ctx)
 			assert.NoError(t, err)
@@ -195,6 +223,53 @@ func TestCreateZoneHandler_Ok(t *testing.T) {
 	}
 }
 
+func TestCreateZoneHandler_Buffered(t *testing.T) {
+	const data = `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {}, "radius": 500, "geometry": {"type": "Point", "coordinates": [10, 10]}},
+		{"type": "Feature", "properties": {}, "radius": 100, "geometry": {"type": "GeometryCollection", "geometries": [
+			{"type": "LineString", "coordinates": [[20, 20], [20.1, 20]]},
+			{"type": "Polygon", "coordinates": [[[30, 30], [31, 30], [31, 31], [30, 30]]]}
+		]}}
+	]}`
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+
+	wr := httptest.NewRecorder()
+	r.CreateZone()(wr, httptest.NewRequest(http.MethodPost, createZoneRoute, bytes.NewBufferString(data)))
+	require.Equal(t, http.StatusCreated, wr.Code)
+
+	var created expectedResponse
+	require.NoError(t, json.NewDecoder(wr.Body).Decode(&created))
+
+	zones, err := storage.GetZonesByIds(ctx, []int{created.ZoneId}, nil, dto.WGS84)
+	require.NoError(t, err)
+	require.Len(t, zones, 1)
+	features := zones[0].GeoJSON.Features
+	require.Equal(t, "MultiPolygon", features[0].Geometry.Type)
+	require.Equal(t, "Point", features[0].SourceGeometry.Type)
+	require.Equal(t, "MultiPolygon", features[1].Geometry.Type)
+	require.Equal(t, "GeometryCollection", features[1].SourceGeometry.Type)
+
+	tests := []struct {
+		point    dto.Point
+		contains bool
+	}{
+		{point: dto.Point{Lon: 10.003, Lat: 10}, contains: true},
+		{point: dto.Point{Lon: 10.006, Lat: 10}, contains: false},
+		{point: dto.Point{Lon: 20.05, Lat: 20.0005}, contains: true},
+		{point: dto.Point{Lon: 20.05, Lat: 20.002}, contains: false},
+		{point: dto.Point{Lon: 30.9, Lat: 30.1}, contains: true},
+	}
+	for _, tt := range tests {
+		actual, err := storage.AnyContainsPoint(ctx, []int{created.ZoneId}, tt.point, dto.ContainsOptions{})
+		require.NoError(t, err)
+		require.Equal(t, tt.contains, actual.Contains, tt.point)
+	}
+}
+
 func TestCreateZoneHandler_DbErr(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	mockSaver := storageMock.NewMockSaver(ctrl)
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+// webMercatorGeoJson is the lon/lat square [0, 10] x [0, 10] in EPSG:3857.
+const webMercatorGeoJson = `{"type": "FeatureCollection", "features": [
+	{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[
+		[0, 0], [1113194.9079327357, 0], [1113194.9079327357, 1118889.9748579594], [0, 1118889.9748579594], [0, 0]
+	]]}}
+]}`
+
+func TestCRS(t *testing.T) {
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.ConfigureRouter()
+
+	t.Run("invalid crs", func(t *testing.T) {
+		code, actual := saveZone(t, r, http.MethodPost, createZoneRoute+"?crs=mercator", polygonGeoJson)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, dto.ErrInvalidCRS.Error(), actual.Error)
+	})
+
+	t.Run("conflicting crs member", func(t *testing.T) {
+		body := `{"type": "FeatureCollection", "crs": {"type": "name", "properties": {"name": "EPSG:3857"}}, "features": [
+			{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}}
+		]}`
+		code, actual := saveZone(t, r, http.MethodPost, createZoneRoute+"?crs=EPSG:32633", body)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, dto.ErrConflictingCRS.Error(), actual.Error)
+	})
+
+	t.Run("crs84", func(t *testing.T) {
+		code, created := saveZone(t, r, http.MethodPost, createZoneRoute+"?crs=urn:ogc:def:crs:OGC:1.3:CRS84", polygonGeoJson)
+		require.Equal(t, http.StatusCreated, code)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("%s?ids=%d&crs=EPSG:4326", getZonesRoute, created.ZoneId), nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("%s?ids=%d&crs=3857", getZonesRoute, created.ZoneId), nil))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestCRS_Reprojected(t *testing.T) {
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.ConfigureRouter()
+
+	getZone := func(t *testing.T, target string) dto.ZoneGeoJSON {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var zones []dto.ZoneGeoJSON
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&zones))
+		require.Len(t, zones, 1)
+		return zones[0]
+	}
+
+	code, created := saveZone(t, r, http.MethodPost, createZoneRoute+"?crs=EPSG:3857", webMercatorGeoJson)
+	require.Equal(t, http.StatusCreated, code)
+
+	stored := getZone(t, fmt.Sprintf("%s?ids=%d", getZonesRoute, created.ZoneId))
+	require.InDeltaSlice(t, []float64{0, 0, 10, 10}, stored.GeoJSON.BBox, 1e-6)
+	require.NotContains(t, stored.GeoJSON.ForeignMembers, dto.CRSMemberName)
+
+	contains, err := storage.AnyContainsPoint(ctx, []int{created.ZoneId}, dto.Point{Lon: 9.9, Lat: 9.9}, dto.ContainsOptions{})
+	require.NoError(t, err)
+	require.True(t, contains.Contains)
+
+	projected := getZone(t, fmt.Sprintf("%s?ids=%d&crs=EPSG:3857", getZonesRoute, created.ZoneId))
+	require.InDeltaSlice(t, []float64{0, 0, 1113194.9079327357, 1118889.9748579594}, projected.GeoJSON.BBox, 1e-3)
+	require.JSONEq(t, `{"type": "name", "properties": {"name": "urn:ogc:def:crs:EPSG::3857"}}`, string(projected.GeoJSON.ForeignMembers[dto.CRSMemberName]))
+
+	code, _ = saveZone(t, r, http.MethodPost, createZoneRoute+"?crs=EPSG:999999", webMercatorGeoJson)
+	require.Equal(t, http.StatusBadRequest, code)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("%s?ids=%d&crs=EPSG:999999", getZonesRoute, created.ZoneId), bytes.NewReader(nil)))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,206 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+// exportZonesFixture creates three zones, the second one is tagged and in the pricing layer.
+func exportZonesFixture(t *testing.T, zoneService *zone.Service) {
+	t.Helper()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		featureCollection, err := decodeFeatureCollection(io.NopCloser(bytes.NewBufferString(polygonGeoJson)), dto.GeoJSONLenient, 0)
+		require.NoError(t, err)
+		if i == 1 {
+			featureCollection.Metadata = &dto.ZoneMetadata{Name: "second", Tags: []string{"night"}, Layer: "pricing"}
+		}
+		_, err = zoneService.SaveZoneFromFeatureCollection(ctx, featureCollection)
+		require.NoError(t, err)
+	}
+}
+
+func TestExportZones(t *testing.T) {
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+	_, err := storage.CreateLayer(ctx, "pricing")
+	require.NoError(t, err)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.ConfigureRouter()
+	exportZonesFixture(t, zoneService)
+
+	get := func(t *testing.T, target string) *http.Response {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w.Result()
+	}
+
+	t.Run("ndjson", func(t *testing.T) {
+		response := get(t, "/export")
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, ndjsonContentType, response.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+
+		ids := make([]int, 0)
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			var line dto.ExportZoneJSON
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+			require.Equal(t, "FeatureCollection", line.Type)
+			require.Len(t, line.Features, 2)
+			ids = append(ids, line.ZoneId)
+		}
+		require.Equal(t, []int{1, 2, 3}, ids)
+
+		// The export is imported as it is, the copies are deleted for the next cases.
+		imported, err := zoneService.ImportZones(ctx, bytes.NewReader(body), dto.GeoJSONLenient, 0)
+		require.NoError(t, err)
+		require.Empty(t, imported.Errors)
+		require.Len(t, imported.Created, 3)
+		zones, err := storage.GetZonesByIds(ctx, imported.Created[1:2], nil, dto.WGS84)
+		require.NoError(t, err)
+		require.Equal(t, "second", zones[0].Name)
+		require.Equal(t, "pricing", zones[0].Layer)
+		for _, id := range imported.Created {
+			require.NoError(t, storage.DeleteZoneById(ctx, id))
+		}
+	})
+
+	t.Run("geojson", func(t *testing.T) {
+		response := get(t, "/export?format=geojson&after=1")
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, geojsonContentType, response.Header.Get("Content-Type"))
+
+		var actual struct {
+			Type     string                  `json:"type"`
+			Features []dto.ExportFeatureJSON `json:"features"`
+			LastId   int                     `json:"last_id"`
+		}
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		require.Equal(t, "FeatureCollection", actual.Type)
+		require.Len(t, actual.Features, 4)
+		require.Equal(t, 2, actual.Features[0].ZoneId)
+		require.Equal(t, []string{"night"}, actual.Features[0].Metadata.Tags)
+		require.Equal(t, 3, actual.LastId)
+	})
+
+	filterTests := []struct {
+		name     string
+		target   string
+		expected []int
+	}{
+		{"id range", "/export?from_id=2&to_id=3", []int{2, 3}},
+		{"layer", "/export?layer=default", []int{1, 3}},
+		{"tag", "/export?tag=night", []int{2}},
+		{"cursor", "/export?after=3", []int{}},
+	}
+	for _, tt := range filterTests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := get(t, tt.target)
+			defer func() { require.NoError(t, response.Body.Close()) }()
+			require.Equal(t, http.StatusOK, response.StatusCode)
+
+			ids := make([]int, 0)
+			decoder := json.NewDecoder(response.Body)
+			for decoder.More() {
+				var line dto.ExportZoneJSON
+				require.NoError(t, decoder.Decode(&line))
+				ids = append(ids, line.ZoneId)
+			}
+			require.Equal(t, tt.expected, ids)
+		})
+	}
+
+	t.Run("empty geojson", func(t *testing.T) {
+		response := get(t, "/export?format=geojson&from_id=10")
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"type": "FeatureCollection", "features": [], "last_id": 0}`, string(body))
+	})
+
+	t.Run("count", func(t *testing.T) {
+		response := get(t, zonesCountRoute)
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"count": 3}`, string(body))
+	})
+
+	errTests := []struct {
+		name        string
+		target      string
+		expectedErr error
+	}{
+		{"unknown format", "/export?format=csv", dto.ErrInvalidExportFormat},
+		{"invalid from id", "/export?from_id=a", dto.ErrInvalidIdRange},
+		{"reversed range", "/export?from_id=3&to_id=2", dto.ErrInvalidIdRange},
+		{"invalid cursor", "/export?after=-1", dto.ErrInvalidCursor},
+		{"invalid layer", "/export?layer=a%20b", dto.ErrInvalidLayerName},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := get(t, tt.target)
+			defer func() { require.NoError(t, response.Body.Close()) }()
+			require.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+			var actual struct {
+				Error string `json:"error"`
+			}
+			require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+			require.Equal(t, tt.expectedErr.Error(), actual.Error)
+		})
+	}
+}
+
+func TestExportZones_Postgres(t *testing.T) {
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+	_, err := storage.CreateLayer(ctx, "pricing")
+	require.NoError(t, err)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	exportZonesFixture(t, zoneService)
+
+	tests := []struct {
+		name     string
+		filter   dto.ZonesFilter
+		expected []string
+	}{
+		{name: "all", expected: []string{"", "second", ""}},
+		{name: "layer and tag", filter: dto.ZonesFilter{Layer: "pricing", Tag: "night"}, expected: []string{"second"}},
+		{name: "tag of another layer", filter: dto.ZonesFilter{Layer: dto.DefaultLayer, Tag: "night"}, expected: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := make([]string, 0)
+			err := zoneService.ExportZones(ctx, dto.ExportIn{Format: dto.ExportFormatNDJSON, ZonesFilter: tt.filter}, func(z dto.ZoneGeoJSON) error {
+				require.Len(t, z.GeoJSON.Features, 2)
+				names = append(names, z.Name)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, names)
+		})
+	}
+}
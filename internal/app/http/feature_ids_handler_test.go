@@ -0,0 +1,76 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+const featureIdsGeoJson = `{"type": "FeatureCollection", "features": [
+	{"type": "Feature", "id": "north", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 1], [0, 0]]]}},
+	{"type": "Feature", "id": 2, "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[2, 2], [3, 2], [3, 3], [2, 3], [2, 2]]]}}
+]}`
+
+func TestFeatureIds(t *testing.T) {
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.ConfigureRouter()
+
+	t.Run("round trip", func(t *testing.T) {
+		code, created := saveZone(t, r, http.MethodPost, createZoneRoute+"?strict=true", featureIdsGeoJson)
+		require.Equal(t, http.StatusCreated, code)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("%s?ids=%d", getZonesRoute, created.ZoneId), nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var zones []dto.ZoneGeoJSON
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&zones))
+		require.Len(t, zones, 1)
+		require.Equal(t, []float64{0, 0, 3, 3}, zones[0].GeoJSON.BBox)
+		require.Equal(t, json.RawMessage(`"north"`), zones[0].GeoJSON.Features[0].Id)
+		require.Equal(t, []float64{0, 0, 1, 1}, zones[0].GeoJSON.Features[0].BBox)
+		require.Equal(t, json.RawMessage(`2`), zones[0].GeoJSON.Features[1].Id)
+	})
+
+	t.Run("strict rejects duplicate ids", func(t *testing.T) {
+		body := `{"type": "FeatureCollection", "features": [
+			{"type": "Feature", "id": 1, "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}},
+			{"type": "Feature", "id": 1, "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[2, 2], [3, 2], [3, 3], [2, 2]]]}}
+		]}`
+		code, actual := saveZone(t, r, http.MethodPost, createZoneRoute+"?strict=true", body)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, geojson.DuplicateFeatureIdErr{Id: "1"}.Error(), actual.Error)
+
+		code, _ = saveZone(t, r, http.MethodPost, createZoneRoute, body)
+		require.Equal(t, http.StatusCreated, code)
+	})
+
+	t.Run("strict rejects missing feature type", func(t *testing.T) {
+		body := `{"type": "FeatureCollection", "features": [
+			{"properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}}
+		]}`
+		code, actual := saveZone(t, r, http.MethodPost, createZoneRoute+"?strict=true", body)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, geojson.NotValidFeatureType{T: ""}.Error(), actual.Error)
+	})
+
+	t.Run("invalid strict", func(t *testing.T) {
+		code, actual := saveZone(t, r, http.MethodPost, createZoneRoute+"?strict=yes", featureIdsGeoJson)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, ErrInvalidStrict.Error(), actual.Error)
+	})
+}
@@ -0,0 +1,173 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	storageMock "github.com/maxsnegir/zones_service/internal/repository/mocks"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+func TestFindZonesByPoint_Ok(t *testing.T) {
+	ctx := context.Background()
+
+	polygonZoneId, err := createZoneFixture(ctx, polygonGeoJson)
+	require.NoError(t, err)
+	multiPolygonZoneId, err := createZoneFixture(ctx, multiPolygonGeoJson)
+	require.NoError(t, err)
+
+	defer storage.CleanDB(ctx)
+
+	tests := []struct {
+		name       string
+		point      dto.Point
+		expected   []int
+		properties []map[string]interface{}
+	}{
+		{
+			name:     "both zones contain point",
+			point:    dto.Point{Lon: 0.6336, Lat: 0.5439},
+			expected: []int{polygonZoneId, multiPolygonZoneId},
+			properties: []map[string]interface{}{
+				{"color": "#ff0000"},
+				{"color": "#ff0000"},
+			},
+		},
+		{
+			name:     "second features contain point",
+			point:    dto.Point{Lon: 2.5448, Lat: 2.6211},
+			expected: []int{polygonZoneId, multiPolygonZoneId},
+			properties: []map[string]interface{}{
+				{"color": "#00ff00", "title": "Second Polygon"},
+				{"color": "#00ff00"},
+			},
+		},
+		{
+			name:     "no zone contains point",
+			point:    dto.Point{Lon: 2.4728, Lat: 1.6995},
+			expected: []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zoneService := zone.New(log, storage, storage, storage)
+			r := NewRouter(mux.NewRouter(), zoneService, log)
+
+			rawRequest, err := json.Marshal(dto.PointIn{Point: tt.point})
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, findZonesByPoint, bytes.NewBuffer(rawRequest))
+
+			r.FindZonesByPoint()(w, req)
+
+			response := w.Result()
+			defer func() { require.NoError(t, response.Body.Close()) }()
+
+			require.Equal(t, response.Header.Get("Content-Type"), "application/json")
+			require.Equal(t, http.StatusOK, response.StatusCode)
+
+			var actual []dto.ZoneGeoJSON
+			err = json.NewDecoder(response.Body).Decode(&actual)
+			require.NoError(t, err)
+			require.Equal(t, len(tt.expected), len(actual))
+
+			for i, zoneGeoJson := range actual {
+				require.Equal(t, tt.expected[i], zoneGeoJson.ZoneId)
+				require.Len(t, zoneGeoJson.GeoJSON.Features, 1)
+				require.Equal(t, tt.properties[i], zoneGeoJson.GeoJSON.Features[0].Properties)
+			}
+		})
+	}
+}
+
+func TestFindZonesByPoint_Err(t *testing.T) {
+
+	type errResponse struct {
+		Error string `json:"error"`
+	}
+
+	tests := []struct {
+		name               string
+		requestData        string
+		dbErr              bool
+		expectedResponse   errResponse
+		expectedStatusCode int
+	}{
+		{
+			name:               "wrong body",
+			requestData:        `{"point": [1, 2]}`,
+			expectedResponse:   errResponse{Error: geojson.SerializationErr.Error()},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "wrong point lat",
+			requestData:        `{"point": {"lon": 0, "lat": 91}}`,
+			expectedResponse:   errResponse{Error: dto.InvalidLatitudeError.Error()},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "wrong point lon",
+			requestData:        `{"point": {"lon": -181, "lat": 90}}`,
+			expectedResponse:   errResponse{Error: dto.InvalidLongitudeError.Error()},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "db error",
+			requestData:        `{"point": {"lon": 0, "lat": 0}}`,
+			dbErr:              true,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockSaver := storageMock.NewMockSaver(ctrl)
+			mockProvider := storageMock.NewMockProvider(ctrl)
+			mockDeleter := storageMock.NewMockDeleter(ctrl)
+
+			if tt.dbErr {
+				mockProvider.EXPECT().
+					FindZonesContainingPoint(gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("DB DOWN")).
+					Times(1)
+			}
+
+			zoneService := zone.New(log, mockSaver, mockProvider, mockDeleter)
+			r := NewRouter(mux.NewRouter(), zoneService, log)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, findZonesByPoint, bytes.NewBuffer([]byte(tt.requestData)))
+
+			r.FindZonesByPoint()(w, req)
+
+			response := w.Result()
+			defer func() { require.NoError(t, response.Body.Close()) }()
+
+			require.Equal(t, response.Header.Get("Content-Type"), "application/json")
+			require.Equal(t, tt.expectedStatusCode, response.StatusCode)
+
+			if !tt.dbErr {
+				var actual errResponse
+				err := json.NewDecoder(response.Body).Decode(&actual)
+				require.NoError(t, err)
+				require.Equal(t, tt.expectedResponse, actual)
+			}
+		})
+	}
+}
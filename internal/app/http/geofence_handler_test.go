@@ -0,0 +1,143 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/repository/memory"
+	storageMock "github.com/maxsnegir/zones_service/internal/repository/mocks"
+	"github.com/maxsnegir/zones_service/internal/service/geofence"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+func TestGeofence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSaver := storageMock.NewMockSaver(ctrl)
+	mockProvider := storageMock.NewMockProvider(ctrl)
+	mockDeleter := storageMock.NewMockDeleter(ctrl)
+
+	zoneService := zone.New(log, mockSaver, mockProvider, mockDeleter)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.GeofenceService = geofence.New(log, mockProvider, memory.NewDeviceStateStore(), nil, time.Minute)
+	r.ConfigureRouter()
+
+	type errResponse struct {
+		Error string `json:"error"`
+	}
+
+	post := func(t *testing.T, body string) *http.Response {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, geofencePositionsRoute, bytes.NewBufferString(body))
+		r.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	t.Run("enter zone", func(t *testing.T) {
+		mockProvider.EXPECT().
+			ContainsPoint(gomock.Any(), []int{1}, dto.Point{Lon: 0.5, Lat: 0.5}, gomock.Any()).
+			Return([]dto.ZoneContainsPointOut{{ZoneId: 1, Contains: true}}, nil).
+			Times(1)
+
+		response := post(t, `{"device_id": "courier", "ids": [1], "point": {"lon": 0.5, "lat": 0.5}, "time": "2026-03-01T10:00:00Z"}`)
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		var actual dto.DevicePositionOut
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		require.Len(t, actual.Events, 1)
+		require.Equal(t, dto.GeofenceEventEnter, actual.Events[0].Type)
+		require.Equal(t, 1, actual.Events[0].ZoneId)
+		require.Len(t, actual.Zones, 1)
+	})
+
+	t.Run("device state", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/geofence/devices/courier", nil)
+		r.ServeHTTP(w, req)
+
+		response := w.Result()
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		var actual dto.DeviceState
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		require.Equal(t, "courier", actual.DeviceId)
+		require.Equal(t, []dto.ZoneStay{{ZoneId: 1, EnteredAt: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)}}, actual.Zones)
+	})
+
+	t.Run("stale position", func(t *testing.T) {
+		mockProvider.EXPECT().
+			ContainsPoint(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return([]dto.ZoneContainsPointOut{{ZoneId: 1, Contains: false}}, nil).
+			Times(1)
+
+		response := post(t, `{"device_id": "courier", "ids": [1], "point": {"lon": 5, "lat": 5}, "time": "2026-03-01T09:00:00Z"}`)
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusConflict, response.StatusCode)
+
+		var actual errResponse
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		require.Equal(t, errResponse{Error: dto.ErrStalePosition.Error()}, actual)
+	})
+
+	t.Run("unknown device", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/geofence/devices/unknown", nil)
+		r.ServeHTTP(w, req)
+
+		response := w.Result()
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+
+	validationTests := []struct {
+		name             string
+		requestData      string
+		expectedResponse errResponse
+	}{
+		{
+			name:             "wrong body",
+			requestData:      `{"device_id": 1}`,
+			expectedResponse: errResponse{Error: geojson.SerializationErr.Error()},
+		},
+		{
+			name:             "empty device id",
+			requestData:      `{"ids": [1], "point": {"lon": 0, "lat": 0}, "time": "2026-03-01T10:00:00Z"}`,
+			expectedResponse: errResponse{Error: dto.ErrInvalidDeviceId.Error()},
+		},
+		{
+			name:             "empty ids",
+			requestData:      `{"device_id": "courier", "point": {"lon": 0, "lat": 0}, "time": "2026-03-01T10:00:00Z"}`,
+			expectedResponse: errResponse{Error: dto.EmptyIdsErr.Error()},
+		},
+		{
+			name:             "empty time",
+			requestData:      `{"device_id": "courier", "ids": [1], "point": {"lon": 0, "lat": 0}}`,
+			expectedResponse: errResponse{Error: dto.ErrInvalidTime.Error()},
+		},
+	}
+
+	for _, tt := range validationTests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := post(t, tt.requestData)
+			defer func() { require.NoError(t, response.Body.Close()) }()
+			require.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+			var actual errResponse
+			require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+			require.Equal(t, tt.expectedResponse, actual)
+		})
+	}
+}
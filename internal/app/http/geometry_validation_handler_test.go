@@ -0,0 +1,181 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+// bowtieGeoJson has a valid first feature and a self-intersecting second one crossing at (1, 1).
+const bowtieGeoJson = `{"type": "FeatureCollection", "features": [
+	{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[5, 5], [5, 6], [6, 6], [6, 5], [5, 5]]]}},
+	{"type": "Feature", "properties": {"color": "#ff0000"}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [2, 2], [2, 0], [0, 2], [0, 0]]]}}
+]}`
+
+type geometryValidationResponse struct {
+	ZoneId           int                  `json:"id"`
+	Error            string               `json:"error"`
+	GeometryErrors   []dto.GeometryError  `json:"geometry_errors"`
+	Repairs          []dto.GeometryRepair `json:"repairs"`
+	GeometryWarnings []dto.GeometryError  `json:"geometry_warnings"`
+}
+
+func saveZone(t *testing.T, r *Router, method, target, body string) (int, geometryValidationResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, target, bytes.NewBufferString(body)))
+
+	var actual geometryValidationResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&actual))
+	return w.Code, actual
+}
+
+func TestGeometryValidation(t *testing.T) {
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.ConfigureRouter()
+
+	selfIntersection := dto.GeometryError{
+		Feature:  1,
+		Reason:   dto.GeometryErrorSelfIntersection,
+		Message:  "Self-intersection",
+		Location: &dto.Point{Lon: 1, Lat: 1},
+		Path:     "/features/1/geometry",
+	}
+
+	t.Run("create", func(t *testing.T) {
+		code, actual := saveZone(t, r, http.MethodPost, createZoneRoute, bowtieGeoJson)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, dto.GeometryValidationErr{Errors: []dto.GeometryError{selfIntersection}}.Error(), actual.Error)
+		require.Equal(t, []dto.GeometryError{selfIntersection}, actual.GeometryErrors)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		code, created := saveZone(t, r, http.MethodPost, createZoneRoute, polygonGeoJson)
+		require.Equal(t, http.StatusCreated, code)
+
+		code, actual := saveZone(t, r, http.MethodPut, fmt.Sprintf("/zones/%d", created.ZoneId), bowtieGeoJson)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, []dto.GeometryError{selfIntersection}, actual.GeometryErrors)
+	})
+
+	t.Run("ring errors cannot be repaired", func(t *testing.T) {
+		body := `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [0, 1], [1, 1], [1, 0]]]}}]}`
+		code, actual := saveZone(t, r, http.MethodPost, createZoneRoute+"?repair=true", body)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, []dto.GeometryError{{
+			Reason:   dto.GeometryErrorRingNotClosed,
+			Message:  geojson.NotClosedMessage,
+			Location: &dto.Point{Lon: 0, Lat: 0},
+			Path:     "/features/0/geometry/coordinates/0",
+		}}, actual.GeometryErrors)
+	})
+
+	t.Run("coordinates out of range", func(t *testing.T) {
+		body := `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "MultiPolygon", "coordinates": [[[[0, 0], [1, 0], [1, 1], [0, 0]]], [[[179, 0], [181, 0], [179, 1], [179, 0]]]]}}]}`
+		code, actual := saveZone(t, r, http.MethodPost, createZoneRoute+"?repair=true", body)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, []dto.GeometryError{{
+			Reason:   dto.GeometryErrorCoordinateOutOfRange,
+			Message:  geojson.OutOfRangeMessage,
+			Location: &dto.Point{Lon: 181, Lat: 0},
+			Path:     "/features/0/geometry/coordinates/1/0/1",
+		}}, actual.GeometryErrors)
+	})
+
+	t.Run("warnings", func(t *testing.T) {
+		code, actual := saveZone(t, r, http.MethodPost, createZoneRoute, polygonGeoJson)
+		require.Equal(t, http.StatusCreated, code)
+		require.Len(t, actual.GeometryWarnings, 2)
+		require.Equal(t, dto.GeometryError{
+			Reason:   dto.GeometryErrorWindingOrder,
+			Message:  geojson.ShellWindingOrderMessage,
+			Location: &dto.Point{Lon: 0, Lat: 0},
+			Path:     "/features/0/geometry/coordinates/0",
+		}, actual.GeometryWarnings[0])
+
+		body := `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}}]}`
+		code, actual = saveZone(t, r, http.MethodPut, fmt.Sprintf("/zones/%d", actual.ZoneId), body)
+		require.Equal(t, http.StatusOK, code)
+		require.Empty(t, actual.GeometryWarnings)
+	})
+
+	t.Run("valid zone with repair", func(t *testing.T) {
+		code, actual := saveZone(t, r, http.MethodPost, createZoneRoute+"?repair=true", polygonGeoJson)
+		require.Equal(t, http.StatusCreated, code)
+		require.NotZero(t, actual.ZoneId)
+		require.Empty(t, actual.Repairs)
+	})
+
+	t.Run("invalid repair", func(t *testing.T) {
+		code, actual := saveZone(t, r, http.MethodPost, createZoneRoute+"?repair=maybe", polygonGeoJson)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, ErrInvalidRepair.Error(), actual.Error)
+	})
+}
+
+func TestGeometryValidation_Postgres(t *testing.T) {
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.ConfigureRouter()
+
+	t.Run("create", func(t *testing.T) {
+		code, actual := saveZone(t, r, http.MethodPost, createZoneRoute, bowtieGeoJson)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Len(t, actual.GeometryErrors, 1)
+		require.Equal(t, 1, actual.GeometryErrors[0].Feature)
+		require.Equal(t, dto.GeometryErrorSelfIntersection, actual.GeometryErrors[0].Reason)
+		require.Equal(t, &dto.Point{Lon: 1, Lat: 1}, actual.GeometryErrors[0].Location)
+		require.Equal(t, "/features/1/geometry", actual.GeometryErrors[0].Path)
+
+		count, err := storage.GetZonesCount(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, count)
+	})
+
+	t.Run("repair", func(t *testing.T) {
+		code, actual := saveZone(t, r, http.MethodPost, createZoneRoute+"?repair=true", bowtieGeoJson)
+		require.Equal(t, http.StatusCreated, code)
+		require.Len(t, actual.Repairs, 1)
+		require.Equal(t, 1, actual.Repairs[0].Feature)
+		require.Equal(t, dto.GeometryErrorSelfIntersection, actual.Repairs[0].Reason)
+		require.Equal(t, "Polygon", actual.Repairs[0].FromType)
+		require.Equal(t, "MultiPolygon", actual.Repairs[0].ToType)
+
+		zone, err := zoneService.GetZoneById(ctx, actual.ZoneId, nil)
+		require.NoError(t, err)
+		require.Len(t, zone.GeoJSON.Features, 2)
+		require.Equal(t, "Polygon", zone.GeoJSON.Features[0].Geometry.Type)
+		require.Equal(t, "MultiPolygon", zone.GeoJSON.Features[1].Geometry.Type)
+		require.Equal(t, "#ff0000", zone.GeoJSON.Features[1].Properties["color"])
+	})
+
+	t.Run("import", func(t *testing.T) {
+		var line bytes.Buffer
+		require.NoError(t, json.Compact(&line, []byte(bowtieGeoJson)))
+
+		result, err := zoneService.ImportZones(ctx, bytes.NewReader(line.Bytes()), dto.GeoJSONLenient, 0)
+		require.NoError(t, err)
+		require.Equal(t, []dto.ImportLineError{{
+			Line:  1,
+			Error: "invalid geometry of feature 1: Self-intersection",
+		}}, result.Errors)
+	})
+}
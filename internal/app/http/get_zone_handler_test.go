@@ -215,7 +215,7 @@ func TestGetZonesHandler_DbErr(t *testing.T) {
 
 	zoneService := zone.New(log, mockSaver, mockProvider, mockDeleter)
 	r := NewRouter(mux.NewRouter(), zoneService, log)
-	mockProvider.EXPECT().GetZonesByIds(gomock.Any(), gomock.Any()).Return(nil, errors.New("DB DOWN")).Times(1)
+	mockProvider.EXPECT().GetZonesByIds(gomock.Any(), gomock.Any(), nil, dto.WGS84).Return(nil, errors.New("DB DOWN")).Times(1)
 
 	wr := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, getZonesRoute, nil)
@@ -4,59 +4,157 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
 	"github.com/maxsnegir/zones_service/internal/domain/geojson"
 	"github.com/maxsnegir/zones_service/internal/dto"
-	"github.com/maxsnegir/zones_service/internal/repository/psql"
+	"github.com/maxsnegir/zones_service/internal/service/layer"
 )
 
+// CreateZone creates a zone, invalid geometries are reported one by one. With repair=true
+// invalid geometries are repaired before the zone is created and the repairs are reported.
+// Geometries saved with duplicate vertices or a wrong winding order are reported as warnings.
+// Points and lines are buffered by the radius member of the feature, 501 is returned by storages that cannot.
+// Coordinates are in the crs query parameter or crs member, EPSG:4326 by default, and are
+// reprojected to EPSG:4326 on save.
 func (r *Router) CreateZone() http.HandlerFunc {
 	const op = "handlers.CreateZone"
 
 	type ResponseData struct {
-		ZoneId int    `json:"id,omitempty"`
-		Error  string `json:"error,omitempty"`
+		ZoneId           int                  `json:"id,omitempty"`
+		Error            string               `json:"error,omitempty"`
+		GeometryErrors   []dto.GeometryError  `json:"geometry_errors,omitempty"`
+		Repairs          []dto.GeometryRepair `json:"repairs,omitempty"`
+		GeometryWarnings []dto.GeometryError  `json:"geometry_warnings,omitempty"`
 	}
 
 	return func(w http.ResponseWriter, req *http.Request) {
 		var responseData ResponseData
 
-		featureCollectionJSON, err := dto.NewFeatureCollectionJSON(req.Body)
+		repair, err := parseRepair(req.URL.Query().Get("repair"))
 		if err != nil {
-			responseData.Error = geojson.SerializationErr.Error()
+			responseData.Error = err.Error()
+			r.JsonResponse(w, http.StatusBadRequest, responseData)
+			return
+		}
+
+		mode, err := parseGeoJSONMode(req.URL.Query().Get("strict"))
+		if err != nil {
+			responseData.Error = err.Error()
+			r.JsonResponse(w, http.StatusBadRequest, responseData)
+			return
+		}
+		srid, err := parseCRS(req.URL.Query().Get("crs"), 0)
+		if err != nil {
+			responseData.Error = err.Error()
 			r.JsonResponse(w, http.StatusBadRequest, responseData)
 			return
 		}
 
-		var featureCollection geojson.FeatureCollection
-		if err := featureCollection.FromFeatureCollectionJSON(*featureCollectionJSON); err != nil {
+		featureCollection, err := decodeFeatureCollection(req.Body, mode, srid)
+		if err != nil {
 			responseData.Error = err.Error()
 			r.JsonResponse(w, http.StatusBadRequest, responseData)
 			return
 		}
 
+		if repair {
+			featureCollection, responseData.Repairs, err = r.ZoneService.RepairGeometries(req.Context(), featureCollection)
+			if err != nil {
+				r.repairErrorResponse(w, op, err)
+				return
+			}
+		}
+
 		zoneId, err := r.ZoneService.SaveZoneFromFeatureCollection(req.Context(), featureCollection)
 		if err != nil {
-			var e psql.PostgisValidationErr
-			if errors.As(err, &e) {
-				responseData.Error = e.Message
+			if errors.Is(err, dto.ErrExternalKeyExists) {
+				responseData.Error = err.Error()
+				r.JsonResponse(w, http.StatusConflict, responseData)
+				return
+			}
+			if errors.Is(err, dto.ErrLayerNotFound) || errors.Is(err, dto.ErrUnknownCRS) {
+				responseData.Error = err.Error()
+				r.JsonResponse(w, http.StatusBadRequest, responseData)
+				return
+			}
+			if message, ok := geometryValidationMessage(err); ok {
+				responseData.Error = message
+				responseData.GeometryErrors = geometryErrors(err)
 				r.JsonResponse(w, http.StatusBadRequest, responseData)
 				return
 			}
+			if errors.Is(err, dto.ErrNotSupported) {
+				responseData.Error = err.Error()
+				r.JsonResponse(w, http.StatusNotImplemented, responseData)
+				return
+			}
 
 			r.log.Error(fmt.Sprintf("%s: %v", op, err))
 			r.JsonResponse(w, http.StatusInternalServerError, nil)
 			return
 		}
 		responseData.ZoneId = zoneId
+		responseData.GeometryWarnings = featureCollection.Warnings()
 		r.JsonResponse(w, http.StatusCreated, responseData)
 	}
 }
 
+// ImportZones creates zones from an NDJSON body, one FeatureCollection per line.
+// An import with invalid lines is rejected as a whole with the line errors.
+func (r *Router) ImportZones() http.HandlerFunc {
+	const op = "handlers.ImportZones"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		mode, err := parseGeoJSONMode(req.URL.Query().Get("strict"))
+		if err != nil {
+			r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
+			return
+		}
+		srid, err := parseCRS(req.URL.Query().Get("crs"), 0)
+		if err != nil {
+			r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
+			return
+		}
+
+		result, err := r.ZoneService.ImportZones(req.Context(), req.Body, mode, srid)
+		if err != nil {
+			if errors.Is(err, dto.ErrEmptyData) {
+				r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
+				return
+			}
+			if errors.Is(err, dto.ErrExternalKeyExists) {
+				r.JsonResponse(w, http.StatusConflict, ErrResponseData{Error: err.Error()})
+				return
+			}
+			if message, ok := geometryValidationMessage(err); ok {
+				r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: message})
+				return
+			}
+
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+		if len(result.Errors) > 0 {
+			r.JsonResponse(w, http.StatusBadRequest, result)
+			return
+		}
+		r.JsonResponse(w, http.StatusCreated, result)
+	}
+}
+
+// GetZones returns the zones with geometries in the crs query parameter, EPSG:4326 by default.
 func (r *Router) GetZones() http.HandlerFunc {
 	const op = "handlers.GetZones"
 
@@ -72,9 +170,27 @@ func (r *Router) GetZones() http.HandlerFunc {
 			r.JsonResponse(w, http.StatusBadRequest, responseData)
 			return
 		}
+		at, err := parseAt(req.URL.Query().Get("at"))
+		if err != nil {
+			r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
+			return
+		}
+		srid, err := parseCRS(req.URL.Query().Get("crs"), dto.WGS84)
+		if err != nil {
+			r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
+			return
+		}
 
-		zones, err := r.ZoneService.GetZonesByIds(req.Context(), zoneIds)
+		zones, err := r.ZoneService.GetZonesByIds(req.Context(), zoneIds, at, srid)
 		if err != nil {
+			if errors.Is(err, dto.ErrUnknownCRS) {
+				r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
+				return
+			}
+			if errors.Is(err, dto.ErrNotSupported) {
+				r.JsonResponse(w, http.StatusNotImplemented, ErrResponseData{Error: err.Error()})
+				return
+			}
 			r.log.Error(fmt.Sprintf("%s: %v", op, err))
 			r.JsonResponse(w, http.StatusInternalServerError, nil)
 			return
@@ -84,129 +200,1218 @@ func (r *Router) GetZones() http.HandlerFunc {
 	}
 }
 
-func (r *Router) ZonesContainsPoint() http.HandlerFunc {
-	const op = "handlers.ZonesContainsPoint"
+// ListZones returns a page of zones passing the filter, the next page is requested
+// with the returned cursor and the same filter and sort.
+func (r *Router) ListZones() http.HandlerFunc {
+	const op = "handlers.ListZones"
 
 	type ErrResponseData struct {
 		Error string `json:"error,omitempty"`
 	}
 
 	return func(w http.ResponseWriter, req *http.Request) {
-		var requestData dto.ZoneContainsPointIn
-
-		if err := json.NewDecoder(req.Body).Decode(&requestData); err != nil {
-			response := ErrResponseData{Error: geojson.SerializationErr.Error()}
-			r.JsonResponse(w, http.StatusBadRequest, response)
+		in, err := parseZonesListIn(req.URL.Query())
+		if err != nil {
+			r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
 			return
 		}
-		if err := requestData.Validate(); err != nil {
-			response := ErrResponseData{Error: err.Error()}
-			r.JsonResponse(w, http.StatusBadRequest, response)
+
+		page, err := r.ZoneService.ListZones(req.Context(), in)
+		if err != nil {
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
 			return
 		}
 
-		result, err := r.ZoneService.ContainsPoint(req.Context(), requestData)
+		r.JsonResponse(w, http.StatusOK, page)
+	}
+}
+
+func (r *Router) ZonesCount() http.HandlerFunc {
+	const op = "handlers.ZonesCount"
+
+	type ResponseData struct {
+		Count int `json:"count"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		count, err := r.ZoneService.GetZonesCount(req.Context())
 		if err != nil {
 			r.log.Error(fmt.Sprintf("%s: %v", op, err))
 			r.JsonResponse(w, http.StatusInternalServerError, nil)
 			return
 		}
 
-		r.JsonResponse(w, http.StatusOK, result)
+		r.JsonResponse(w, http.StatusOK, ResponseData{Count: count})
 	}
 }
 
-func (r *Router) AnyOfZonesContainsPint() http.HandlerFunc {
-	const op = "handlers.AnyOfZonesContainsPint"
+// ExportZones streams the zones passing the filter in id order, as NDJSON in the import
+// format or as a single FeatureCollection ending with the last exported id. Zones are
+// written as they are read, an export failing midway is aborted, so a truncated export
+// is not mistaken for a complete one. Exports are resumed with after set to the last id.
+func (r *Router) ExportZones() http.HandlerFunc {
+	const op = "handlers.ExportZones"
+	const flushEvery = 100
 
-	type ResponseData struct {
-		Contains bool   `json:"contains"`
-		Error    string `json:"error,omitempty"`
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
 	}
 
 	return func(w http.ResponseWriter, req *http.Request) {
-		var requestData dto.ZoneContainsPointIn
-		var responseData ResponseData
+		in, err := parseExportIn(req.URL.Query())
+		if err != nil {
+			r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
+			return
+		}
 
-		if err := json.NewDecoder(req.Body).Decode(&requestData); err != nil {
-			responseData.Error = geojson.SerializationErr.Error()
-			r.JsonResponse(w, http.StatusBadRequest, responseData)
+		// An export may take longer than the server write timeout.
+		controller := http.NewResponseController(w)
+		_ = controller.SetWriteDeadline(time.Time{})
+
+		started := false
+		exported, features, lastId := 0, 0, 0
+		start := func() error {
+			started = true
+			if in.Format == dto.ExportFormatNDJSON {
+				w.Header().Set("Content-Type", ndjsonContentType)
+				w.WriteHeader(http.StatusOK)
+				return nil
+			}
+			w.Header().Set("Content-Type", geojsonContentType)
+			w.WriteHeader(http.StatusOK)
+			_, err := io.WriteString(w, `{"type":"FeatureCollection","features":[`)
+			return err
+		}
+
+		encoder := json.NewEncoder(w)
+		err = r.ZoneService.ExportZones(req.Context(), in, func(zone dto.ZoneGeoJSON) error {
+			if !started {
+				if err := start(); err != nil {
+					return err
+				}
+			}
+			written, err := writeExportZone(w, encoder, in.Format, zone, features)
+			if err != nil {
+				return err
+			}
+			features += written
+			exported++
+			lastId = zone.ZoneId
+			if exported%flushEvery == 0 {
+				return controller.Flush()
+			}
+			return nil
+		})
+		if err == nil && !started {
+			err = start()
+		}
+		if err == nil && in.Format == dto.ExportFormatGeoJSON {
+			_, err = fmt.Fprintf(w, `],"last_id":%d}`, lastId)
+		}
+		if err != nil {
+			if req.Context().Err() != nil {
+				return
+			}
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			if !started {
+				r.JsonResponse(w, http.StatusInternalServerError, nil)
+				return
+			}
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+func (r *Router) GetZone() http.HandlerFunc {
+	const op = "handlers.GetZone"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		id, err := parseZoneId(mux.Vars(req)["id"])
+		if err != nil {
+			r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
 			return
 		}
-		if err := requestData.Validate(); err != nil {
-			responseData.Error = err.Error()
-			r.JsonResponse(w, http.StatusBadRequest, responseData)
+
+		at, err := parseAt(req.URL.Query().Get("at"))
+		if err != nil {
+			r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
 			return
 		}
 
-		contains, err := r.ZoneService.AnyZoneContainsPoint(req.Context(), requestData)
+		zoneGeoJson, err := r.ZoneService.GetZoneById(req.Context(), id, at)
 		if err != nil {
+			if errors.Is(err, dto.ErrZoneNotFound) {
+				r.JsonResponse(w, http.StatusNotFound, ErrResponseData{Error: err.Error()})
+				return
+			}
 			r.log.Error(fmt.Sprintf("%s: %v", op, err))
 			r.JsonResponse(w, http.StatusInternalServerError, nil)
 			return
 		}
-		responseData.Contains = contains
-		r.JsonResponse(w, http.StatusOK, responseData)
+
+		r.JsonResponse(w, http.StatusOK, zoneGeoJson)
 	}
 }
 
-func (r *Router) BatchAnyOfZonesContainsPint() http.HandlerFunc {
-	const op = "handlers.GetZone"
+func (r *Router) GetZoneVersions() http.HandlerFunc {
+	const op = "handlers.GetZoneVersions"
 
 	type ErrResponseData struct {
 		Error string `json:"error,omitempty"`
 	}
 
 	return func(w http.ResponseWriter, req *http.Request) {
-		var errResponse ErrResponseData
-		var requestData dto.BatchZoneContainsPointInCollection
-
-		if err := json.NewDecoder(req.Body).Decode(&requestData); err != nil {
-			errResponse.Error = geojson.SerializationErr.Error()
-			r.JsonResponse(w, http.StatusBadRequest, errResponse)
+		id, err := parseZoneId(mux.Vars(req)["id"])
+		if err != nil {
+			r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
 			return
 		}
-		if err := requestData.Validate(); err != nil {
-			errResponse.Error = err.Error()
-			r.JsonResponse(w, http.StatusBadRequest, errResponse)
+
+		versions, err := r.ZoneService.GetZoneVersions(req.Context(), id)
+		if err != nil {
+			if errors.Is(err, dto.ErrZoneNotFound) {
+				r.JsonResponse(w, http.StatusNotFound, ErrResponseData{Error: err.Error()})
+				return
+			}
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
 			return
 		}
 
-		result, err := r.ZoneService.ButchAnyZoneContainsPoint(req.Context(), requestData)
+		r.JsonResponse(w, http.StatusOK, versions)
+	}
+}
+
+func (r *Router) GetZoneByExternalKey() http.HandlerFunc {
+	const op = "handlers.GetZoneByExternalKey"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		zoneGeoJson, err := r.ZoneService.GetZoneByExternalKey(req.Context(), mux.Vars(req)["key"])
 		if err != nil {
+			if errors.Is(err, dto.ErrZoneNotFound) {
+				r.JsonResponse(w, http.StatusNotFound, ErrResponseData{Error: err.Error()})
+				return
+			}
 			r.log.Error(fmt.Sprintf("%s: %v", op, err))
 			r.JsonResponse(w, http.StatusInternalServerError, nil)
 			return
 		}
 
-		r.JsonResponse(w, http.StatusOK, result)
+		r.JsonResponse(w, http.StatusOK, zoneGeoJson)
 	}
 }
 
-func (r *Router) DeleteZone() http.HandlerFunc {
-	const op = "handlers.DeleteZone"
+// UpdateZone replaces a zone, repair=true and warnings work as for CreateZone.
+func (r *Router) UpdateZone() http.HandlerFunc {
+	const op = "handlers.UpdateZone"
 
-	type errResponseData struct {
-		Error string `json:"error,omitempty"`
+	type ResponseData struct {
+		ZoneId           int                  `json:"id,omitempty"`
+		Error            string               `json:"error,omitempty"`
+		GeometryErrors   []dto.GeometryError  `json:"geometry_errors,omitempty"`
+		Repairs          []dto.GeometryRepair `json:"repairs,omitempty"`
+		GeometryWarnings []dto.GeometryError  `json:"geometry_warnings,omitempty"`
 	}
 
 	return func(w http.ResponseWriter, req *http.Request) {
-		idStr := mux.Vars(req)["id"]
+		var responseData ResponseData
 
-		id, err := strconv.Atoi(idStr)
-		if err != nil || id < 1 {
-			response := errResponseData{Error: "invalid zone id"}
-			r.JsonResponse(w, http.StatusBadRequest, response)
+		id, err := parseZoneId(mux.Vars(req)["id"])
+		if err != nil {
+			responseData.Error = err.Error()
+			r.JsonResponse(w, http.StatusBadRequest, responseData)
 			return
 		}
 
-		err = r.ZoneService.DeleteZone(req.Context(), id)
+		repair, err := parseRepair(req.URL.Query().Get("repair"))
+		if err != nil {
+			responseData.Error = err.Error()
+			r.JsonResponse(w, http.StatusBadRequest, responseData)
+			return
+		}
+
+		mode, err := parseGeoJSONMode(req.URL.Query().Get("strict"))
+		if err != nil {
+			responseData.Error = err.Error()
+			r.JsonResponse(w, http.StatusBadRequest, responseData)
+			return
+		}
+		srid, err := parseCRS(req.URL.Query().Get("crs"), 0)
+		if err != nil {
+			responseData.Error = err.Error()
+			r.JsonResponse(w, http.StatusBadRequest, responseData)
+			return
+		}
+
+		featureCollection, err := decodeFeatureCollection(req.Body, mode, srid)
+		if err != nil {
+			responseData.Error = err.Error()
+			r.JsonResponse(w, http.StatusBadRequest, responseData)
+			return
+		}
+
+		if repair {
+			featureCollection, responseData.Repairs, err = r.ZoneService.RepairGeometries(req.Context(), featureCollection)
+			if err != nil {
+				r.repairErrorResponse(w, op, err)
+				return
+			}
+		}
+
+		err = r.ZoneService.UpdateZoneFromFeatureCollection(req.Context(), id, featureCollection)
 		if err != nil {
+			if errors.Is(err, dto.ErrZoneNotFound) {
+				responseData.Error = err.Error()
+				r.JsonResponse(w, http.StatusNotFound, responseData)
+				return
+			}
+			if errors.Is(err, dto.ErrExternalKeyExists) {
+				responseData.Error = err.Error()
+				r.JsonResponse(w, http.StatusConflict, responseData)
+				return
+			}
+			if errors.Is(err, dto.ErrLayerNotFound) || errors.Is(err, dto.ErrUnknownCRS) {
+				responseData.Error = err.Error()
+				r.JsonResponse(w, http.StatusBadRequest, responseData)
+				return
+			}
+			if message, ok := geometryValidationMessage(err); ok {
+				responseData.Error = message
+				responseData.GeometryErrors = geometryErrors(err)
+				r.JsonResponse(w, http.StatusBadRequest, responseData)
+				return
+			}
+			if errors.Is(err, dto.ErrNotSupported) {
+				responseData.Error = err.Error()
+				r.JsonResponse(w, http.StatusNotImplemented, responseData)
+				return
+			}
+
 			r.log.Error(fmt.Sprintf("%s: %v", op, err))
 			r.JsonResponse(w, http.StatusInternalServerError, nil)
 			return
 		}
+		responseData.ZoneId = id
+		responseData.GeometryWarnings = featureCollection.Warnings()
+		r.JsonResponse(w, http.StatusOK, responseData)
+	}
+}
+
+func (r *Router) PatchZone() http.HandlerFunc {
+	const op = "handlers.PatchZone"
+
+	type ResponseData struct {
+		ZoneId int    `json:"id,omitempty"`
+		Error  string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		var responseData ResponseData
+		var requestData dto.ZonePropertiesPatchIn
+
+		id, err := parseZoneId(mux.Vars(req)["id"])
+		if err != nil {
+			responseData.Error = err.Error()
+			r.JsonResponse(w, http.StatusBadRequest, responseData)
+			return
+		}
 
-		r.JsonResponse(w, http.StatusNoContent, nil)
+		if err := json.NewDecoder(req.Body).Decode(&requestData); err != nil {
+			responseData.Error = geojson.SerializationErr.Error()
+			r.JsonResponse(w, http.StatusBadRequest, responseData)
+			return
+		}
+		if err := requestData.Validate(); err != nil {
+			responseData.Error = err.Error()
+			r.JsonResponse(w, http.StatusBadRequest, responseData)
+			return
+		}
+
+		err = r.ZoneService.UpdateZoneProperties(req.Context(), id, requestData)
+		if err != nil {
+			switch {
+			case errors.Is(err, dto.ErrZoneNotFound):
+				responseData.Error = err.Error()
+				r.JsonResponse(w, http.StatusNotFound, responseData)
+			case errors.Is(err, dto.ErrPropertiesCount):
+				responseData.Error = err.Error()
+				r.JsonResponse(w, http.StatusBadRequest, responseData)
+			default:
+				r.log.Error(fmt.Sprintf("%s: %v", op, err))
+				r.JsonResponse(w, http.StatusInternalServerError, nil)
+			}
+			return
+		}
+		responseData.ZoneId = id
+		r.JsonResponse(w, http.StatusOK, responseData)
+	}
+}
+
+func (r *Router) GetZonesTile() http.HandlerFunc {
+	const op = "handlers.GetZonesTile"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		tile, err := parseTile(vars["z"], vars["x"], vars["y"])
+		if err != nil {
+			r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
+			return
+		}
+		zoneIds, err := parseZoneIds(req.URL.Query().Get("ids"), false)
+		if err != nil {
+			r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
+			return
+		}
+		tile.ZoneIds = zoneIds
+		tile.Tag = req.URL.Query().Get("tag")
+		if err := tile.Validate(); err != nil {
+			r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
+			return
+		}
+
+		mvt, err := r.ZoneService.GetZonesTile(req.Context(), tile)
+		if err != nil {
+			if errors.Is(err, dto.ErrNotSupported) {
+				r.JsonResponse(w, http.StatusNotImplemented, ErrResponseData{Error: err.Error()})
+				return
+			}
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		w.Header().Set("Content-Type", mvtContentType)
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(mvt); err != nil {
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+		}
+	}
+}
+
+func (r *Router) ZonesContainsPoint() http.HandlerFunc {
+	const op = "handlers.ZonesContainsPoint"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		var requestData dto.ZoneContainsPointIn
+
+		if err := json.NewDecoder(req.Body).Decode(&requestData); err != nil {
+			response := ErrResponseData{Error: geojson.SerializationErr.Error()}
+			r.JsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+		if err := requestData.Validate(); err != nil {
+			response := ErrResponseData{Error: err.Error()}
+			r.JsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+
+		result, err := r.ZoneService.ContainsPoint(req.Context(), requestData)
+		if err != nil {
+			if errors.Is(err, dto.ErrLayerNotFound) {
+				r.JsonResponse(w, http.StatusNotFound, ErrResponseData{Error: err.Error()})
+				return
+			}
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, result)
+	}
+}
+
+func (r *Router) AnyOfZonesContainsPint() http.HandlerFunc {
+	const op = "handlers.AnyOfZonesContainsPint"
+
+	type ResponseData struct {
+		Contains bool         `json:"contains"`
+		Position dto.Position `json:"position,omitempty"`
+		Error    string       `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		var requestData dto.ZoneContainsPointIn
+		var responseData ResponseData
+
+		if err := json.NewDecoder(req.Body).Decode(&requestData); err != nil {
+			responseData.Error = geojson.SerializationErr.Error()
+			r.JsonResponse(w, http.StatusBadRequest, responseData)
+			return
+		}
+		if err := requestData.Validate(); err != nil {
+			responseData.Error = err.Error()
+			r.JsonResponse(w, http.StatusBadRequest, responseData)
+			return
+		}
+
+		result, err := r.ZoneService.AnyZoneContainsPoint(req.Context(), requestData)
+		if err != nil {
+			if errors.Is(err, dto.ErrLayerNotFound) {
+				responseData.Error = err.Error()
+				r.JsonResponse(w, http.StatusNotFound, responseData)
+				return
+			}
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+		responseData.Contains = result.Contains
+		responseData.Position = result.Position
+		r.JsonResponse(w, http.StatusOK, responseData)
+	}
+}
+
+func (r *Router) BatchAnyOfZonesContainsPint() http.HandlerFunc {
+	const op = "handlers.GetZone"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		var errResponse ErrResponseData
+		var requestData dto.BatchZoneContainsPointInCollection
+
+		if err := json.NewDecoder(req.Body).Decode(&requestData); err != nil {
+			errResponse.Error = geojson.SerializationErr.Error()
+			r.JsonResponse(w, http.StatusBadRequest, errResponse)
+			return
+		}
+		if err := requestData.Validate(); err != nil {
+			errResponse.Error = err.Error()
+			r.JsonResponse(w, http.StatusBadRequest, errResponse)
+			return
+		}
+
+		result, err := r.ZoneService.ButchAnyZoneContainsPoint(req.Context(), requestData)
+		if err != nil {
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, result)
+	}
+}
+
+func (r *Router) FindZonesByPoint() http.HandlerFunc {
+	const op = "handlers.FindZonesByPoint"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		var requestData dto.PointIn
+
+		if err := json.NewDecoder(req.Body).Decode(&requestData); err != nil {
+			response := ErrResponseData{Error: geojson.SerializationErr.Error()}
+			r.JsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+		if err := requestData.Validate(); err != nil {
+			response := ErrResponseData{Error: err.Error()}
+			r.JsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+
+		zones, err := r.ZoneService.FindZonesContainingPoint(req.Context(), requestData)
+		if err != nil {
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, zones)
+	}
+}
+
+func (r *Router) NearestZones() http.HandlerFunc {
+	const op = "handlers.NearestZones"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		var requestData dto.NearestZonesIn
+
+		if err := json.NewDecoder(req.Body).Decode(&requestData); err != nil {
+			response := ErrResponseData{Error: geojson.SerializationErr.Error()}
+			r.JsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+		if err := requestData.Validate(); err != nil {
+			response := ErrResponseData{Error: err.Error()}
+			r.JsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+
+		zones, err := r.ZoneService.NearestZones(req.Context(), requestData)
+		if err != nil {
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, zones)
+	}
+}
+
+func (r *Router) RouteCrossings() http.HandlerFunc {
+	const op = "handlers.RouteCrossings"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		var requestData dto.RouteCrossingIn
+
+		if err := json.NewDecoder(req.Body).Decode(&requestData); err != nil {
+			response := ErrResponseData{Error: geojson.SerializationErr.Error()}
+			r.JsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+		if err := requestData.Validate(); err != nil {
+			response := ErrResponseData{Error: err.Error()}
+			r.JsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+
+		crossings, err := r.ZoneService.RouteCrossings(req.Context(), requestData)
+		if err != nil {
+			if errors.Is(err, dto.ErrNotSupported) {
+				r.JsonResponse(w, http.StatusNotImplemented, ErrResponseData{Error: err.Error()})
+				return
+			}
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, crossings)
+	}
+}
+
+func (r *Router) TrackDevicePosition() http.HandlerFunc {
+	const op = "handlers.TrackDevicePosition"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		var requestData dto.DevicePositionIn
+
+		if err := json.NewDecoder(req.Body).Decode(&requestData); err != nil {
+			response := ErrResponseData{Error: geojson.SerializationErr.Error()}
+			r.JsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+		if err := requestData.Validate(); err != nil {
+			response := ErrResponseData{Error: err.Error()}
+			r.JsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+
+		result, err := r.GeofenceService.TrackPosition(req.Context(), requestData)
+		if err != nil {
+			if errors.Is(err, dto.ErrStalePosition) {
+				r.JsonResponse(w, http.StatusConflict, ErrResponseData{Error: err.Error()})
+				return
+			}
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, result)
+	}
+}
+
+func (r *Router) GetDeviceState() http.HandlerFunc {
+	const op = "handlers.GetDeviceState"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		state, err := r.GeofenceService.GetDeviceState(req.Context(), mux.Vars(req)["id"])
+		if err != nil {
+			if errors.Is(err, dto.ErrDeviceNotFound) {
+				r.JsonResponse(w, http.StatusNotFound, ErrResponseData{Error: err.Error()})
+				return
+			}
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, state)
+	}
+}
+
+func (r *Router) DeleteZone() http.HandlerFunc {
+	const op = "handlers.DeleteZone"
+
+	type errResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		id, err := parseZoneId(mux.Vars(req)["id"])
+		if err != nil {
+			response := errResponseData{Error: err.Error()}
+			r.JsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+
+		err = r.ZoneService.DeleteZone(req.Context(), id)
+		if err != nil {
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusNoContent, nil)
+	}
+}
+
+func (r *Router) CreateWebhookSubscription() http.HandlerFunc {
+	const op = "handlers.CreateWebhookSubscription"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		var requestData dto.WebhookSubscriptionIn
+
+		if err := json.NewDecoder(req.Body).Decode(&requestData); err != nil {
+			response := ErrResponseData{Error: geojson.SerializationErr.Error()}
+			r.JsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+		if err := requestData.Validate(); err != nil {
+			response := ErrResponseData{Error: err.Error()}
+			r.JsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+
+		subscription, err := r.WebhookService.CreateSubscription(req.Context(), requestData)
+		if err != nil {
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusCreated, subscription)
+	}
+}
+
+func (r *Router) GetWebhookSubscriptions() http.HandlerFunc {
+	const op = "handlers.GetWebhookSubscriptions"
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		subscriptions, err := r.WebhookService.GetSubscriptions(req.Context())
+		if err != nil {
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, subscriptions)
+	}
+}
+
+func (r *Router) DeleteWebhookSubscription() http.HandlerFunc {
+	const op = "handlers.DeleteWebhookSubscription"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(req)["id"])
+		if err != nil {
+			r.JsonResponse(w, http.StatusNotFound, ErrResponseData{Error: dto.ErrSubscriptionNotFound.Error()})
+			return
+		}
+
+		if err = r.WebhookService.DeleteSubscription(req.Context(), id); err != nil {
+			if errors.Is(err, dto.ErrSubscriptionNotFound) {
+				r.JsonResponse(w, http.StatusNotFound, ErrResponseData{Error: err.Error()})
+				return
+			}
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusNoContent, nil)
+	}
+}
+
+func (r *Router) GetWebhookDeliveries() http.HandlerFunc {
+	const op = "handlers.GetWebhookDeliveries"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(req)["id"])
+		if err != nil {
+			r.JsonResponse(w, http.StatusNotFound, ErrResponseData{Error: dto.ErrSubscriptionNotFound.Error()})
+			return
+		}
+		limit, err := parseLimit(req.URL.Query().Get("limit"), dto.DefaultWebhookDeliveriesLimit, dto.MaxWebhookDeliveriesLimit)
+		if err != nil {
+			r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
+			return
+		}
+
+		deliveries, err := r.WebhookService.GetDeliveries(req.Context(), id, limit)
+		if err != nil {
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, deliveries)
+	}
+}
+
+func (r *Router) GetWebhookDeadLetters() http.HandlerFunc {
+	const op = "handlers.GetWebhookDeadLetters"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		limit, err := parseLimit(req.URL.Query().Get("limit"), dto.DefaultWebhookDeliveriesLimit, dto.MaxWebhookDeliveriesLimit)
+		if err != nil {
+			r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
+			return
+		}
+
+		deliveries, err := r.WebhookService.GetDeadLetters(req.Context(), limit)
+		if err != nil {
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, deliveries)
+	}
+}
+
+func (r *Router) RetryWebhookDeadLetter() http.HandlerFunc {
+	const op = "handlers.RetryWebhookDeadLetter"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		id, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+		if err != nil {
+			r.JsonResponse(w, http.StatusNotFound, ErrResponseData{Error: dto.ErrWebhookDeliveryNotFound.Error()})
+			return
+		}
+
+		if err = r.WebhookService.RetryDeadLetter(req.Context(), id); err != nil {
+			if errors.Is(err, dto.ErrWebhookDeliveryNotFound) {
+				r.JsonResponse(w, http.StatusNotFound, ErrResponseData{Error: err.Error()})
+				return
+			}
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusAccepted, nil)
+	}
+}
+
+func (r *Router) CreateLayer() http.HandlerFunc {
+	const op = "handlers.CreateLayer"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		var requestData dto.LayerIn
+
+		if err := json.NewDecoder(req.Body).Decode(&requestData); err != nil {
+			response := ErrResponseData{Error: geojson.SerializationErr.Error()}
+			r.JsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+		if err := requestData.Validate(); err != nil {
+			response := ErrResponseData{Error: err.Error()}
+			r.JsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+
+		created, err := r.LayerService.CreateLayer(req.Context(), requestData)
+		if err != nil {
+			if errors.Is(err, dto.ErrLayerExists) {
+				r.JsonResponse(w, http.StatusConflict, ErrResponseData{Error: err.Error()})
+				return
+			}
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusCreated, created)
+	}
+}
+
+func (r *Router) GetLayers() http.HandlerFunc {
+	const op = "handlers.GetLayers"
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		layers, err := r.LayerService.GetLayers(req.Context())
+		if err != nil {
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, layers)
+	}
+}
+
+func (r *Router) GetLayer() http.HandlerFunc {
+	const op = "handlers.GetLayer"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		l, err := r.LayerService.GetLayer(req.Context(), mux.Vars(req)["name"])
+		if err != nil {
+			if errors.Is(err, dto.ErrLayerNotFound) {
+				r.JsonResponse(w, http.StatusNotFound, ErrResponseData{Error: err.Error()})
+				return
+			}
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, l)
+	}
+}
+
+// CreateLayerDraft uploads a complete zone set of the layer as the next version.
+func (r *Router) CreateLayerDraft() http.HandlerFunc {
+	const op = "handlers.CreateLayerDraft"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		var requestData dto.LayerDraftIn
+
+		if err := json.NewDecoder(req.Body).Decode(&requestData); err != nil {
+			response := ErrResponseData{Error: geojson.SerializationErr.Error()}
+			r.JsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+
+		version, err := r.LayerService.CreateDraft(req.Context(), mux.Vars(req)["name"], requestData)
+		if err != nil {
+			var draftErr layer.DraftError
+			switch {
+			case errors.As(err, &draftErr):
+				r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
+			case errors.Is(err, dto.ErrLayerNotFound):
+				r.JsonResponse(w, http.StatusNotFound, ErrResponseData{Error: err.Error()})
+			default:
+				r.log.Error(fmt.Sprintf("%s: %v", op, err))
+				r.JsonResponse(w, http.StatusInternalServerError, nil)
+			}
+			return
+		}
+
+		r.JsonResponse(w, http.StatusCreated, version)
+	}
+}
+
+func (r *Router) GetLayerVersions() http.HandlerFunc {
+	const op = "handlers.GetLayerVersions"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		versions, err := r.LayerService.GetVersions(req.Context(), mux.Vars(req)["name"])
+		if err != nil {
+			if errors.Is(err, dto.ErrLayerNotFound) {
+				r.JsonResponse(w, http.StatusNotFound, ErrResponseData{Error: err.Error()})
+				return
+			}
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, versions)
+	}
+}
+
+func (r *Router) ValidateLayerVersion() http.HandlerFunc {
+	const op = "handlers.ValidateLayerVersion"
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		version, err := strconv.Atoi(vars["version"])
+		if err != nil {
+			r.layerErrorResponse(w, op, dto.ErrLayerVersionNotFound)
+			return
+		}
+
+		validated, err := r.LayerService.Validate(req.Context(), vars["name"], version)
+		if err != nil {
+			r.layerErrorResponse(w, op, err)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, validated)
+	}
+}
+
+// PublishLayerVersion swaps the zones of the layer for a validated version at once.
+func (r *Router) PublishLayerVersion() http.HandlerFunc {
+	const op = "handlers.PublishLayerVersion"
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		version, err := strconv.Atoi(vars["version"])
+		if err != nil {
+			r.layerErrorResponse(w, op, dto.ErrLayerVersionNotFound)
+			return
+		}
+
+		published, err := r.LayerService.Publish(req.Context(), vars["name"], version)
+		if err != nil {
+			r.layerErrorResponse(w, op, err)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, published)
+	}
+}
+
+// RollbackLayer publishes the previously published version of the layer again.
+func (r *Router) RollbackLayer() http.HandlerFunc {
+	const op = "handlers.RollbackLayer"
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		published, err := r.LayerService.Rollback(req.Context(), mux.Vars(req)["name"])
+		if err != nil {
+			r.layerErrorResponse(w, op, err)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, published)
+	}
+}
+
+// repairErrorResponse maps errors of geometry repairs to response statuses,
+// geometries that cannot be repaired are reported one by one.
+func (r *Router) repairErrorResponse(w http.ResponseWriter, op string, err error) {
+	type ErrResponseData struct {
+		Error          string              `json:"error,omitempty"`
+		GeometryErrors []dto.GeometryError `json:"geometry_errors,omitempty"`
+	}
+
+	if errors.Is(err, dto.ErrNotSupported) {
+		r.JsonResponse(w, http.StatusNotImplemented, ErrResponseData{Error: err.Error()})
+		return
+	}
+	if message, ok := geometryValidationMessage(err); ok {
+		r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: message, GeometryErrors: geometryErrors(err)})
+		return
+	}
+	r.log.Error(fmt.Sprintf("%s: %v", op, err))
+	r.JsonResponse(w, http.StatusInternalServerError, nil)
+}
+
+// layerErrorResponse maps errors of layer version transitions to response statuses.
+func (r *Router) layerErrorResponse(w http.ResponseWriter, op string, err error) {
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	switch {
+	case errors.Is(err, dto.ErrLayerNotFound), errors.Is(err, dto.ErrLayerVersionNotFound):
+		r.JsonResponse(w, http.StatusNotFound, ErrResponseData{Error: err.Error()})
+	case errors.Is(err, dto.ErrLayerVersionNotDraft),
+		errors.Is(err, dto.ErrLayerVersionNotValidated),
+		errors.Is(err, dto.ErrNoPreviousLayerVersion),
+		errors.Is(err, dto.ErrLayerVersionNotValid),
+		errors.Is(err, dto.ErrDuplicateLayerExternalKey),
+		errors.Is(err, dto.ErrExternalKeyExists):
+		r.JsonResponse(w, http.StatusConflict, ErrResponseData{Error: err.Error()})
+	default:
+		if message, ok := geometryValidationMessage(err); ok {
+			r.JsonResponse(w, http.StatusConflict, ErrResponseData{Error: message})
+			return
+		}
+		r.log.Error(fmt.Sprintf("%s: %v", op, err))
+		r.JsonResponse(w, http.StatusInternalServerError, nil)
+	}
+}
+
+// ZoneChanges streams the change log as server-sent events when the client accepts
+// text/event-stream, otherwise it long-polls for up to timeout seconds.
+func (r *Router) ZoneChanges() http.HandlerFunc {
+	const op = "handlers.ZoneChanges"
+
+	type ErrResponseData struct {
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		afterSeq, err := parseSequence(query.Get("after"), req.Header.Get("Last-Event-ID"))
+		if err != nil {
+			r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
+			return
+		}
+		limit, err := parseLimit(query.Get("limit"), dto.DefaultZoneChangesLimit, dto.MaxZoneChangesLimit)
+		if err != nil {
+			r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: err.Error()})
+			return
+		}
+
+		if strings.Contains(req.Header.Get("Accept"), sseContentType) {
+			r.streamZoneChanges(w, req, afterSeq, limit)
+			return
+		}
+
+		// Without timeout the request waits up to the configured maximum, timeout=0 returns at once.
+		var timeout time.Duration
+		wait := true
+		if timeoutStr := query.Get("timeout"); timeoutStr != "" {
+			seconds, err := strconv.Atoi(timeoutStr)
+			if err != nil || seconds < 0 {
+				r.JsonResponse(w, http.StatusBadRequest, ErrResponseData{Error: ErrInvalidTimeout.Error()})
+				return
+			}
+			timeout = time.Duration(seconds) * time.Second
+			wait = seconds > 0
+		}
+
+		var out dto.ZoneChangesOut
+		if wait {
+			// The wait may be longer than the server write timeout.
+			_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+			out, err = r.ChangesService.WaitChanges(req.Context(), afterSeq, limit, timeout)
+		} else {
+			out, err = r.ChangesService.GetChanges(req.Context(), afterSeq, limit)
+		}
+		if err != nil {
+			if req.Context().Err() != nil {
+				return
+			}
+			r.log.Error(fmt.Sprintf("%s: %v", op, err))
+			r.JsonResponse(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		r.JsonResponse(w, http.StatusOK, out)
+	}
+}
+
+// writeExportZone writes the zone as an NDJSON line, or as features of the FeatureCollection
+// after the written ones, and returns the number of features written.
+func writeExportZone(w io.Writer, encoder *json.Encoder, format dto.ExportFormat, zone dto.ZoneGeoJSON, written int) (int, error) {
+	if format == dto.ExportFormatNDJSON {
+		return len(zone.GeoJSON.Features), encoder.Encode(dto.NewExportZoneJSON(zone))
+	}
+	metadata := zone.ZoneMetadata
+	for i, feature := range zone.GeoJSON.Features {
+		if written+i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return i, err
+			}
+		}
+		data, err := json.Marshal(dto.ExportFeatureJSON{ZoneId: zone.ZoneId, FeatureJSON: feature, Metadata: &metadata})
+		if err != nil {
+			return i, err
+		}
+		if _, err = w.Write(data); err != nil {
+			return i, err
+		}
+	}
+	return len(zone.GeoJSON.Features), nil
+}
+
+func (r *Router) streamZoneChanges(w http.ResponseWriter, req *http.Request, afterSeq int64, limit int) {
+	const op = "handlers.streamZoneChanges"
+
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", sseContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		r.log.Error(fmt.Sprintf("%s: streaming is not supported: %v", op, err))
+		return
+	}
+
+	send := func(changes []dto.ZoneChange) error {
+		for _, change := range changes {
+			data, err := json.Marshal(change)
+			if err != nil {
+				return err
+			}
+			if _, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.Seq, change.Type, data); err != nil {
+				return err
+			}
+		}
+		return rc.Flush()
+	}
+	keepAlive := func() error {
+		if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	err := r.ChangesService.Stream(req.Context(), afterSeq, limit, send, keepAlive)
+	if err != nil && req.Context().Err() == nil {
+		r.log.Error(fmt.Sprintf("%s: %v", op, err))
 	}
 }
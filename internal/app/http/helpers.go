@@ -1,14 +1,27 @@
 package http
 
 import (
+	"encoding/json"
 	"errors"
+	"io"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/repository/psql"
 )
 
 var (
-	ErrInvalidZoneId = errors.New("invalid zone id")
-	ErrEmptyZoneIds  = errors.New("ids is required")
+	ErrInvalidZoneId        = errors.New("invalid zone id")
+	ErrEmptyZoneIds         = errors.New("ids is required")
+	ErrInvalidTimeout       = errors.New("invalid timeout")
+	ErrInvalidAt            = errors.New("invalid at, RFC 3339 time expected")
+	ErrInvalidGeometryParam = errors.New("invalid geometry, true or false expected")
+	ErrInvalidRepair        = errors.New("invalid repair, true or false expected")
+	ErrInvalidStrict        = errors.New("invalid strict, true or false expected")
 )
 
 func parseZoneIds(ids string, isRequired bool) ([]int, error) {
@@ -16,9 +29,11 @@ func parseZoneIds(ids string, isRequired bool) ([]int, error) {
 	zoneIds := make([]int, 0, len(zoneIdsStr))
 	cache := make(map[int]struct{}, len(zoneIdsStr))
 
-	if len(zoneIdsStr) == 1 && zoneIdsStr[0] == "" && isRequired {
-		return nil, ErrEmptyZoneIds
-
+	if len(zoneIdsStr) == 1 && zoneIdsStr[0] == "" {
+		if isRequired {
+			return nil, ErrEmptyZoneIds
+		}
+		return zoneIds, nil
 	}
 
 	for _, zoneIdStr := range zoneIdsStr {
@@ -37,3 +52,250 @@ func parseZoneIds(ids string, isRequired bool) ([]int, error) {
 	}
 	return zoneIds, nil
 }
+
+func parseZoneId(idStr string) (int, error) {
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id < 1 {
+		return 0, ErrInvalidZoneId
+	}
+	return id, nil
+}
+
+// parseLimit returns def for an empty limit.
+func parseLimit(limitStr string, def, max int) (int, error) {
+	if limitStr == "" {
+		return def, nil
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > max {
+		return 0, dto.ErrInvalidLimit
+	}
+	return limit, nil
+}
+
+// parseSequence reads the change feed position from the query or, for reconnecting
+// event sources, from the Last-Event-ID header.
+func parseSequence(after, lastEventId string) (int64, error) {
+	if after == "" {
+		after = lastEventId
+	}
+	if after == "" {
+		return 0, nil
+	}
+	seq, err := strconv.ParseInt(after, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, dto.ErrInvalidSequence
+	}
+	return seq, nil
+}
+
+// parseAt parses the optional point in time of zone history queries.
+func parseAt(atStr string) (*time.Time, error) {
+	if atStr == "" {
+		return nil, nil
+	}
+	at, err := time.Parse(time.RFC3339, atStr)
+	if err != nil {
+		return nil, ErrInvalidAt
+	}
+	return &at, nil
+}
+
+// parseExportIn reads the export format and the zones filter of an export.
+func parseExportIn(query url.Values) (dto.ExportIn, error) {
+	in := dto.ExportIn{Format: dto.ExportFormat(query.Get("format"))}
+	if in.Format == "" {
+		in.Format = dto.ExportFormatNDJSON
+	}
+	filter, err := parseZonesFilter(query)
+	if err != nil {
+		return in, err
+	}
+	in.ZonesFilter = filter
+	return in, in.Validate()
+}
+
+// parseZonesFilter reads from_id, to_id, layer, tag and the after cursor.
+func parseZonesFilter(query url.Values) (dto.ZonesFilter, error) {
+	filter := dto.ZonesFilter{Layer: query.Get("layer"), Tag: query.Get("tag")}
+	var err error
+
+	if filter.FromId, err = parseOptionalInt(query.Get("from_id")); err != nil {
+		return filter, dto.ErrInvalidIdRange
+	}
+	if filter.ToId, err = parseOptionalInt(query.Get("to_id")); err != nil {
+		return filter, dto.ErrInvalidIdRange
+	}
+	if filter.After, err = parseOptionalInt(query.Get("after")); err != nil {
+		return filter, dto.ErrInvalidCursor
+	}
+	return filter, nil
+}
+
+// parseZonesListIn reads the zones filter, repeated property=key:value filters, a bbox
+// of min_lon,min_lat,max_lon,max_lat, sort, limit, cursor and geometry=false.
+func parseZonesListIn(query url.Values) (dto.ZonesListIn, error) {
+	in := dto.ZonesListIn{Sort: dto.ZonesSort(query.Get("sort"))}
+	var err error
+
+	if in.ZonesFilter, err = parseZonesFilter(query); err != nil {
+		return in, err
+	}
+	for _, filter := range query["property"] {
+		key, value, ok := strings.Cut(filter, ":")
+		if !ok {
+			return in, dto.ErrInvalidPropertyFilter
+		}
+		if in.Properties == nil {
+			in.Properties = make(map[string]interface{})
+		}
+		in.Properties[key] = parsePropertyValue(value)
+	}
+	if bbox := query.Get("bbox"); bbox != "" {
+		if in.BBox, err = parseBBox(bbox); err != nil {
+			return in, err
+		}
+	}
+	if in.Limit, err = parseOptionalInt(query.Get("limit")); err != nil {
+		return in, dto.ErrInvalidLimit
+	}
+	if token := query.Get("cursor"); token != "" {
+		cursor, err := dto.ParseZonesCursor(token)
+		if err != nil {
+			return in, err
+		}
+		in.Cursor = &cursor
+	}
+	if geometry := query.Get("geometry"); geometry != "" {
+		withGeometry, err := strconv.ParseBool(geometry)
+		if err != nil {
+			return in, ErrInvalidGeometryParam
+		}
+		in.WithoutGeometry = !withGeometry
+	}
+	return in, in.Validate()
+}
+
+// parsePropertyValue reads a JSON scalar, a value that is not JSON is a string,
+// so color:#ff0000 and size:10 both work and quoting is needed for "10" only.
+func parsePropertyValue(value string) interface{} {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+		return value
+	}
+	return parsed
+}
+
+func parseBBox(value string) (*dto.BBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return nil, dto.ErrInvalidBBox
+	}
+	coordinates := make([]float64, len(parts))
+	for i, part := range parts {
+		coordinate, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, dto.ErrInvalidBBox
+		}
+		coordinates[i] = coordinate
+	}
+	return &dto.BBox{MinLon: coordinates[0], MinLat: coordinates[1], MaxLon: coordinates[2], MaxLat: coordinates[3]}, nil
+}
+
+func parseOptionalInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func parseTile(z, x, y string) (dto.TileIn, error) {
+	var tile dto.TileIn
+	var err error
+
+	if tile.Z, err = strconv.Atoi(z); err != nil {
+		return tile, dto.ErrInvalidTile
+	}
+	if tile.X, err = strconv.Atoi(x); err != nil {
+		return tile, dto.ErrInvalidTile
+	}
+	if tile.Y, err = strconv.Atoi(y); err != nil {
+		return tile, dto.ErrInvalidTile
+	}
+	return tile, nil
+}
+
+// decodeFeatureCollection parses the body as the mode requires, a srid other than 0 declares its crs.
+func decodeFeatureCollection(body io.ReadCloser, mode dto.GeoJSONMode, srid int) (geojson.FeatureCollection, error) {
+	var featureCollection geojson.FeatureCollection
+
+	featureCollectionJSON, err := dto.NewFeatureCollectionJSON(body)
+	if err != nil {
+		return featureCollection, geojson.SerializationErr
+	}
+	if err := featureCollection.ParseFeatureCollectionJSON(*featureCollectionJSON, mode); err != nil {
+		return featureCollection, err
+	}
+	if srid != 0 {
+		if err := featureCollection.DeclareCRS(srid); err != nil {
+			return featureCollection, err
+		}
+	}
+	return featureCollection, nil
+}
+
+// geometryValidationMessage reports whether err is a geometry rejected by the storage.
+func geometryValidationMessage(err error) (string, bool) {
+	var validationErr dto.GeometryValidationErr
+	if errors.As(err, &validationErr) {
+		return validationErr.Error(), true
+	}
+	var postgisErr psql.PostgisValidationErr
+	if errors.As(err, &postgisErr) {
+		return postgisErr.Message, true
+	}
+	return "", false
+}
+
+// geometryErrors returns the invalid geometries err describes, if any.
+func geometryErrors(err error) []dto.GeometryError {
+	var validationErr dto.GeometryValidationErr
+	if errors.As(err, &validationErr) {
+		return validationErr.Errors
+	}
+	return nil
+}
+
+func parseRepair(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	repair, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, ErrInvalidRepair
+	}
+	return repair, nil
+}
+
+// parseGeoJSONMode reads strict=true as strict RFC 7946 parsing, GeoJSON is parsed leniently by default.
+func parseGeoJSONMode(value string) (dto.GeoJSONMode, error) {
+	if value == "" {
+		return dto.GeoJSONLenient, nil
+	}
+	strict, err := strconv.ParseBool(value)
+	if err != nil {
+		return "", ErrInvalidStrict
+	}
+	if strict {
+		return dto.GeoJSONStrict, nil
+	}
+	return dto.GeoJSONLenient, nil
+}
+
+// parseCRS reads the crs query parameter, def when it is empty.
+func parseCRS(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	return dto.ParseCRS(value)
+}
@@ -66,3 +66,13 @@ func Test_parseZoneIds(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseZoneIds_NotRequired(t *testing.T) {
+	gotIds, err := parseZoneIds("", false)
+	require.NoError(t, err)
+	require.Empty(t, gotIds)
+
+	gotIds, err = parseZoneIds("3,1", false)
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 1}, gotIds)
+}
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+func TestImportZones(t *testing.T) {
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.ConfigureRouter()
+
+	zoneLine := func(key string) string {
+		return `{"type": "FeatureCollection", "metadata": {"external_key": "` + key + `"}, "features": [` +
+			`{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [0, 1], [1, 1], [1, 0], [0, 0]]]}}]}`
+	}
+
+	do := func(t *testing.T, body string) (*http.Response, dto.ZoneImportOut) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, zonesImportRoute, bytes.NewBufferString(body))
+		r.ServeHTTP(w, req)
+
+		response := w.Result()
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		var actual dto.ZoneImportOut
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		return response, actual
+	}
+
+	t.Run("import", func(t *testing.T) {
+		body := strings.Join([]string{zoneLine("a"), "", zoneLine("b"), ""}, "\n")
+		response, actual := do(t, body)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		require.Len(t, actual.Created, 2)
+
+		zone, err := zoneService.GetZoneByExternalKey(ctx, "b")
+		require.NoError(t, err)
+		require.Equal(t, actual.Created[1], zone.ZoneId)
+	})
+
+	t.Run("invalid lines", func(t *testing.T) {
+		body := strings.Join([]string{
+			zoneLine("c"),
+			`{"type": "FeatureCollection"`,
+			zoneLine("c"),
+			`{"type": "Feature", "features": []}`,
+		}, "\n")
+		response, actual := do(t, body)
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+		require.Empty(t, actual.Created)
+		require.Equal(t, []dto.ImportLineError{
+			{Line: 2, Error: geojson.SerializationErr.Error()},
+			{Line: 3, Error: dto.ErrDuplicateImportExternalKey.Error()},
+			{Line: 4, Error: geojson.NotValidFeatureCollectionType{T: "Feature"}.Error()},
+		}, actual.Errors)
+	})
+
+	t.Run("stored external key", func(t *testing.T) {
+		response, actual := do(t, zoneLine("d")+"\n"+zoneLine("a"))
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+		require.Equal(t, []dto.ImportLineError{{Line: 2, Error: dto.ErrExternalKeyExists.Error()}}, actual.Errors)
+
+		_, err := zoneService.GetZoneByExternalKey(ctx, "d")
+		require.ErrorIs(t, err, dto.ErrZoneNotFound)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, zonesImportRoute, bytes.NewBufferString("\n"))
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestImportZones_Postgres(t *testing.T) {
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.ConfigureRouter()
+
+	compact := func(data string) string {
+		var buf bytes.Buffer
+		require.NoError(t, json.Compact(&buf, []byte(data)))
+		return buf.String()
+	}
+	body := compact(polygonGeoJson) + "\n" + compact(multiPolygonGeoJson) + "\n"
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, zonesImportRoute, bytes.NewBufferString(body)))
+	response := w.Result()
+	defer func() { require.NoError(t, response.Body.Close()) }()
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+
+	var actual dto.ZoneImportOut
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+	require.Len(t, actual.Created, 2)
+
+	zones, err := zoneService.GetZonesByIds(ctx, actual.Created, nil, dto.WGS84)
+	require.NoError(t, err)
+	require.Len(t, zones, 2)
+	for _, z := range zones {
+		require.Equal(t, dto.DefaultLayer, z.Layer)
+		require.Len(t, z.GeoJSON.Features, 2)
+		require.Equal(t, "#ff0000", z.GeoJSON.Features[0].Properties["color"])
+	}
+
+	versions, err := zoneService.GetZoneVersions(ctx, actual.Created[1])
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+
+	changes, err := storage.GetZoneChanges(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, actual.Created[0], changes[0].ZoneId)
+	require.Equal(t, dto.ZoneChangeCreated, changes[1].Type)
+}
@@ -0,0 +1,153 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/service/layer"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+func TestLayers(t *testing.T) {
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.LayerService = layer.New(log, storage)
+	r.ConfigureRouter()
+
+	type errResponse struct {
+		Error string `json:"error"`
+	}
+
+	do := func(t *testing.T, method, url, body string) *http.Response {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+		r.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	const draft = `{"zones": [{
+		"type": "FeatureCollection",
+		"metadata": {"external_key": "center"},
+		"features": [{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [0, 1], [1, 1], [1, 0], [0, 0]]]}}]
+	}]}`
+
+	t.Run("create layer", func(t *testing.T) {
+		response := do(t, http.MethodPost, layersRoute, `{"name": "delivery"}`)
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+
+		var actual dto.Layer
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		require.Equal(t, "delivery", actual.Name)
+		require.Nil(t, actual.PublishedVersion)
+	})
+
+	t.Run("publish draft", func(t *testing.T) {
+		response := do(t, http.MethodPost, "/layers/delivery/versions", draft)
+		require.NoError(t, response.Body.Close())
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+
+		response = do(t, http.MethodPost, "/layers/delivery/versions/1/publish", "")
+		require.NoError(t, response.Body.Close())
+		require.Equal(t, http.StatusConflict, response.StatusCode)
+
+		response = do(t, http.MethodPost, "/layers/delivery/versions/1/validate", "")
+		require.NoError(t, response.Body.Close())
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		response = do(t, http.MethodPost, "/layers/delivery/versions/1/publish", "")
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		var actual dto.LayerVersion
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		require.Equal(t, dto.LayerVersionPublished, actual.Status)
+		require.NotNil(t, actual.PublishedAt)
+	})
+
+	t.Run("contains by layer", func(t *testing.T) {
+		response := do(t, http.MethodPost, anyZonesContainsPoint, `{"layer": "delivery", "point": {"lon": 0.5, "lat": 0.5}}`)
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		var actual dto.AnyContainsPointOut
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		require.True(t, actual.Contains)
+	})
+
+	t.Run("list versions", func(t *testing.T) {
+		response := do(t, http.MethodGet, "/layers/delivery/versions", "")
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		var actual []dto.LayerVersion
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		require.Len(t, actual, 1)
+		require.Equal(t, 1, actual[0].ZonesCount)
+	})
+
+	errTests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		expectedStatus int
+		expectedErr    error
+	}{
+		{"invalid layer name", http.MethodPost, layersRoute, `{"name": "a b"}`, http.StatusBadRequest, dto.ErrInvalidLayerName},
+		{"layer exists", http.MethodPost, layersRoute, `{"name": "delivery"}`, http.StatusConflict, dto.ErrLayerExists},
+		{"unknown layer", http.MethodGet, "/layers/unknown", "", http.StatusNotFound, dto.ErrLayerNotFound},
+		{"draft for unknown layer", http.MethodPost, "/layers/unknown/versions", draft, http.StatusNotFound, dto.ErrLayerNotFound},
+		{
+			"zone of another layer",
+			http.MethodPost,
+			"/layers/delivery/versions",
+			strings.Replace(draft, `"external_key": "center"`, `"layer": "pricing"`, 1),
+			http.StatusBadRequest,
+			dto.ErrLayerZoneMismatch,
+		},
+		{"unknown version", http.MethodPost, "/layers/delivery/versions/7/validate", "", http.StatusNotFound, dto.ErrLayerVersionNotFound},
+		{"validate published", http.MethodPost, "/layers/delivery/versions/1/validate", "", http.StatusConflict, dto.ErrLayerVersionNotDraft},
+		{"nothing to roll back to", http.MethodPost, "/layers/delivery/rollback", "", http.StatusConflict, dto.ErrNoPreviousLayerVersion},
+		{
+			"contains by unknown layer",
+			http.MethodPost,
+			zonesContainsPoint,
+			`{"layer": "unknown", "point": {"lon": 0.5, "lat": 0.5}}`,
+			http.StatusNotFound,
+			dto.ErrLayerNotFound,
+		},
+		{
+			"contains by ids and layer",
+			http.MethodPost,
+			zonesContainsPoint,
+			`{"ids": [1], "layer": "delivery", "point": {"lon": 0.5, "lat": 0.5}}`,
+			http.StatusBadRequest,
+			dto.ErrIdsWithLayer,
+		},
+	}
+
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := do(t, tt.method, tt.url, tt.body)
+			defer func() { require.NoError(t, response.Body.Close()) }()
+			require.Equal(t, tt.expectedStatus, response.StatusCode)
+
+			var actual errResponse
+			require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+			require.Contains(t, actual.Error, tt.expectedErr.Error())
+		})
+	}
+}
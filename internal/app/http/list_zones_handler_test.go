@@ -0,0 +1,190 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+func listZones(t *testing.T, r *Router, query string) (*http.Response, dto.ZonesPageOut) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, zonesRoute+"?"+query, nil))
+
+	response := w.Result()
+	defer func() { require.NoError(t, response.Body.Close()) }()
+	var page dto.ZonesPageOut
+	if response.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&page))
+	}
+	return response, page
+}
+
+func pageIds(page dto.ZonesPageOut) []int {
+	ids := make([]int, 0, len(page.Zones))
+	for _, z := range page.Zones {
+		ids = append(ids, z.ZoneId)
+	}
+	return ids
+}
+
+func TestListZones(t *testing.T) {
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+	_, err := storage.CreateLayer(ctx, "pricing")
+	require.NoError(t, err)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.ConfigureRouter()
+	exportZonesFixture(t, zoneService)
+
+	t.Run("pages", func(t *testing.T) {
+		response, page := listZones(t, r, "limit=2")
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, []int{1, 2}, pageIds(page))
+		require.NotNil(t, page.Zones[0].GeoJSON)
+		require.Len(t, page.Zones[0].GeoJSON.Features, 2)
+		require.NotEmpty(t, page.NextCursor)
+
+		response, page = listZones(t, r, "limit=2&cursor="+page.NextCursor)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, []int{3}, pageIds(page))
+		require.Empty(t, page.NextCursor)
+	})
+
+	t.Run("created at", func(t *testing.T) {
+		_, page := listZones(t, r, "sort=created_at&limit=1")
+		require.Equal(t, []int{1}, pageIds(page))
+
+		_, page = listZones(t, r, "sort=created_at&limit=5&cursor="+page.NextCursor)
+		require.Equal(t, []int{2, 3}, pageIds(page))
+	})
+
+	filterTests := []struct {
+		name     string
+		query    url.Values
+		expected []int
+	}{
+		{"id range", url.Values{"from_id": {"2"}, "to_id": {"3"}}, []int{2, 3}},
+		{"layer and tag", url.Values{"layer": {"pricing"}, "tag": {"night"}}, []int{2}},
+		{"property", url.Values{"property": {"title:Second Polygon"}}, []int{1, 2, 3}},
+		{"properties of a single feature", url.Values{"property": {"title:Second Polygon", "color:#ff0000"}}, []int{}},
+		{"bbox", url.Values{"bbox": {"2.5,2.5,4,4"}}, []int{1, 2, 3}},
+		{"bbox outside", url.Values{"bbox": {"1.2,1.2,1.8,1.8"}}, []int{}},
+	}
+	for _, tt := range filterTests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, page := listZones(t, r, tt.query.Encode())
+			require.Equal(t, http.StatusOK, response.StatusCode)
+			require.Equal(t, tt.expected, pageIds(page))
+		})
+	}
+
+	t.Run("without geometry", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, zonesRoute+"?geometry=false&from_id=2&to_id=2", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NotContains(t, w.Body.String(), "geojson")
+
+		var page dto.ZonesPageOut
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&page))
+		require.Len(t, page.Zones, 1)
+		require.Equal(t, "second", page.Zones[0].Name)
+		require.Equal(t, []string{"night"}, page.Zones[0].Tags)
+	})
+
+	errTests := []struct {
+		name        string
+		query       string
+		expectedErr error
+	}{
+		{"unknown sort", "sort=name", dto.ErrInvalidZonesSort},
+		{"invalid limit", "limit=1001", dto.ErrInvalidLimit},
+		{"invalid cursor", "cursor=abc", dto.ErrInvalidCursor},
+		{"cursor of another sort", "sort=created_at&cursor=" + dto.ZonesCursor{Sort: dto.ZonesSortId, ZoneId: 1}.String(), dto.ErrInvalidCursor},
+		{"invalid bbox", "bbox=0,0,1", dto.ErrInvalidBBox},
+		{"invalid property", "property=color", dto.ErrInvalidPropertyFilter},
+		{"invalid geometry", "geometry=no", ErrInvalidGeometryParam},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, zonesRoute+"?"+tt.query, nil))
+			require.Equal(t, http.StatusBadRequest, w.Code)
+
+			var actual struct {
+				Error string `json:"error"`
+			}
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&actual))
+			require.Equal(t, tt.expectedErr.Error(), actual.Error)
+		})
+	}
+}
+
+func TestListZones_Postgres(t *testing.T) {
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+	_, err := storage.CreateLayer(ctx, "pricing")
+	require.NoError(t, err)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.ConfigureRouter()
+	exportZonesFixture(t, zoneService)
+
+	tests := []struct {
+		name     string
+		query    url.Values
+		expected []string
+	}{
+		{"all", url.Values{}, []string{"", "second", ""}},
+		{"created at", url.Values{"sort": {"created_at"}}, []string{"", "second", ""}},
+		{"layer", url.Values{"layer": {"pricing"}}, []string{"second"}},
+		{"property", url.Values{"property": {"color:#00ff00"}, "tag": {"night"}}, []string{"second"}},
+		{"missing property", url.Values{"property": {"color:#0000ff"}}, []string{}},
+		{"bbox", url.Values{"bbox": {"2.5,2.5,4,4"}}, []string{"", "second", ""}},
+		{"bbox outside", url.Values{"bbox": {"1.2,1.2,1.8,1.8"}}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, page := listZones(t, r, tt.query.Encode())
+			require.Equal(t, http.StatusOK, response.StatusCode)
+
+			names := make([]string, 0)
+			for _, z := range page.Zones {
+				require.NotNil(t, z.GeoJSON)
+				require.Len(t, z.GeoJSON.Features, 2)
+				names = append(names, z.Name)
+			}
+			require.Equal(t, tt.expected, names)
+		})
+	}
+
+	t.Run("pages without geometry", func(t *testing.T) {
+		ids := make([]int, 0)
+		query := url.Values{"limit": {"2"}, "geometry": {"false"}, "sort": {"created_at"}}
+		for {
+			response, page := listZones(t, r, query.Encode())
+			require.Equal(t, http.StatusOK, response.StatusCode)
+			for _, z := range page.Zones {
+				require.Nil(t, z.GeoJSON)
+			}
+			ids = append(ids, pageIds(page)...)
+			if page.NextCursor == "" {
+				break
+			}
+			query.Set("cursor", page.NextCursor)
+		}
+		require.Len(t, ids, 3)
+	})
+}
@@ -0,0 +1,222 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	storageMock "github.com/maxsnegir/zones_service/internal/repository/mocks"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+func TestNearestZones_Ok(t *testing.T) {
+	ctx := context.Background()
+
+	polygonZoneId, err := createZoneFixture(ctx, polygonGeoJson)
+	require.NoError(t, err)
+	multiPolygonZoneId, err := createZoneFixture(ctx, multiPolygonGeoJson)
+	require.NoError(t, err)
+
+	defer storage.CleanDB(ctx)
+
+	tests := []struct {
+		name     string
+		request  dto.NearestZonesIn
+		expected []int
+		inside   []bool
+		boundary []dto.Point
+	}{
+		{
+			name:     "point outside every zone",
+			request:  dto.NearestZonesIn{Point: dto.Point{Lon: 1.5, Lat: 0.5}},
+			expected: []int{polygonZoneId, multiPolygonZoneId},
+			inside:   []bool{false, false},
+			boundary: []dto.Point{{Lon: 1, Lat: 0.5}, {Lon: 1, Lat: 0.5}},
+		},
+		{
+			name:     "point inside zone",
+			request:  dto.NearestZonesIn{ZoneIds: []int{multiPolygonZoneId}, Point: dto.Point{Lon: 2.5, Lat: 2.9}},
+			expected: []int{multiPolygonZoneId},
+			inside:   []bool{true},
+			boundary: []dto.Point{{Lon: 2.5, Lat: 3}},
+		},
+		{
+			name:     "limit",
+			request:  dto.NearestZonesIn{Point: dto.Point{Lon: 1.5, Lat: 0.5}, Limit: 1},
+			expected: []int{polygonZoneId},
+			inside:   []bool{false},
+			boundary: []dto.Point{{Lon: 1, Lat: 0.5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zoneService := zone.New(log, storage, storage, storage)
+			r := NewRouter(mux.NewRouter(), zoneService, log)
+
+			rawRequest, err := json.Marshal(tt.request)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, nearestZones, bytes.NewBuffer(rawRequest))
+
+			r.NearestZones()(w, req)
+
+			response := w.Result()
+			defer func() { require.NoError(t, response.Body.Close()) }()
+
+			require.Equal(t, response.Header.Get("Content-Type"), "application/json")
+			require.Equal(t, http.StatusOK, response.StatusCode)
+
+			var actual []dto.NearestZoneOut
+			err = json.NewDecoder(response.Body).Decode(&actual)
+			require.NoError(t, err)
+			require.Equal(t, len(tt.expected), len(actual))
+
+			for i, nearest := range actual {
+				require.Equal(t, tt.expected[i], nearest.ZoneId)
+				require.Equal(t, tt.inside[i], nearest.Inside)
+				require.Equal(t, tt.inside[i], nearest.Distance < 0)
+				require.InDelta(t, tt.boundary[i].Lon, nearest.BoundaryPoint.Lon, 1e-9)
+				require.InDelta(t, tt.boundary[i].Lat, nearest.BoundaryPoint.Lat, 1e-9)
+			}
+		})
+	}
+}
+
+// A zone with more features near the point than the KNN candidates must not hide the
+// zones behind it.
+func TestNearestZones_ManyFeatures(t *testing.T) {
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+
+	features := make([]string, 0, 30)
+	for i := 0; i < cap(features); i++ {
+		lon := 0.01 * float64(i)
+		features = append(features, fmt.Sprintf(
+			`{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[%[1]g, 0], [%[1]g, 0.005], [%[2]g, 0.005], [%[2]g, 0], [%[1]g, 0]]]}}`,
+			lon, lon+0.005,
+		))
+	}
+	manyFeaturesZoneId, err := createZoneFixture(ctx, `{"type": "FeatureCollection", "features": [`+strings.Join(features, ",")+`]}`)
+	require.NoError(t, err)
+	farZoneId, err := createZoneFixture(ctx, `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[0, 1], [0, 2], [1, 2], [1, 1], [0, 1]]]}}
+	]}`)
+	require.NoError(t, err)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+
+	rawRequest, err := json.Marshal(dto.NearestZonesIn{Point: dto.Point{Lon: 0.1, Lat: 0.01}, Limit: 2})
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	r.NearestZones()(w, httptest.NewRequest(http.MethodPost, nearestZones, bytes.NewBuffer(rawRequest)))
+
+	response := w.Result()
+	defer func() { require.NoError(t, response.Body.Close()) }()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var actual []dto.NearestZoneOut
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+	require.Len(t, actual, 2)
+	require.Equal(t, manyFeaturesZoneId, actual[0].ZoneId)
+	require.Equal(t, farZoneId, actual[1].ZoneId)
+}
+
+func TestNearestZones_Err(t *testing.T) {
+
+	type errResponse struct {
+		Error string `json:"error"`
+	}
+
+	tests := []struct {
+		name               string
+		requestData        string
+		dbErr              bool
+		expectedResponse   errResponse
+		expectedStatusCode int
+	}{
+		{
+			name:               "wrong body",
+			requestData:        `{"point": [1, 2]}`,
+			expectedResponse:   errResponse{Error: geojson.SerializationErr.Error()},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "wrong point lat",
+			requestData:        `{"point": {"lon": 0, "lat": 91}}`,
+			expectedResponse:   errResponse{Error: dto.InvalidLatitudeError.Error()},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "wrong ids",
+			requestData:        `{"ids": [-1], "point": {"lon": 0, "lat": 0}}`,
+			expectedResponse:   errResponse{Error: dto.ErrInvalidId.Error()},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "wrong limit",
+			requestData:        `{"point": {"lon": 0, "lat": 0}, "limit": 1000}`,
+			expectedResponse:   errResponse{Error: dto.ErrInvalidLimit.Error()},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "db error",
+			requestData:        `{"point": {"lon": 0, "lat": 0}}`,
+			dbErr:              true,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockSaver := storageMock.NewMockSaver(ctrl)
+			mockProvider := storageMock.NewMockProvider(ctrl)
+			mockDeleter := storageMock.NewMockDeleter(ctrl)
+
+			if tt.dbErr {
+				mockProvider.EXPECT().
+					NearestZones(gomock.Any(), gomock.Any(), gomock.Any(), dto.DefaultNearestLimit).
+					Return(nil, errors.New("DB DOWN")).
+					Times(1)
+			}
+
+			zoneService := zone.New(log, mockSaver, mockProvider, mockDeleter)
+			r := NewRouter(mux.NewRouter(), zoneService, log)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, nearestZones, bytes.NewBuffer([]byte(tt.requestData)))
+
+			r.NearestZones()(w, req)
+
+			response := w.Result()
+			defer func() { require.NoError(t, response.Body.Close()) }()
+
+			require.Equal(t, response.Header.Get("Content-Type"), "application/json")
+			require.Equal(t, tt.expectedStatusCode, response.StatusCode)
+
+			if !tt.dbErr {
+				var actual errResponse
+				err := json.NewDecoder(response.Body).Decode(&actual)
+				require.NoError(t, err)
+				require.Equal(t, tt.expectedResponse, actual)
+			}
+		})
+	}
+}
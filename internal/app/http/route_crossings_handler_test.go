@@ -0,0 +1,253 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	storageMock "github.com/maxsnegir/zones_service/internal/repository/mocks"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+func TestRouteCrossings_Ok(t *testing.T) {
+	ctx := context.Background()
+
+	polygonZoneId, err := createZoneFixture(ctx, polygonGeoJson)
+	require.NoError(t, err)
+	multiPolygonZoneId, err := createZoneFixture(ctx, multiPolygonGeoJson)
+	require.NoError(t, err)
+
+	defer storage.CleanDB(ctx)
+
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	at := func(seconds int) *time.Time {
+		t := start.Add(time.Duration(seconds) * time.Second)
+		return &t
+	}
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+
+	// The route crosses the first square along y=0.5 and the second one along x=2.5.
+	request := dto.RouteCrossingIn{
+		ZoneIds: []int{polygonZoneId},
+		Points: []dto.RoutePoint{
+			{Point: dto.Point{Lon: -1, Lat: 0.5}, Time: at(0)},
+			{Point: dto.Point{Lon: 2.5, Lat: 0.5}, Time: at(35)},
+			{Point: dto.Point{Lon: 2.5, Lat: 4}, Time: at(70)},
+		},
+	}
+	expected := []dto.RouteSegment{
+		{
+			Entry:         dto.Point{Lon: 0, Lat: 0.5},
+			Exit:          dto.Point{Lon: 1, Lat: 0.5},
+			EntryFraction: 1.0 / 7,
+			ExitFraction:  2.0 / 7,
+			EntryTime:     at(10),
+			ExitTime:      at(20),
+		},
+		{
+			Entry:         dto.Point{Lon: 2.5, Lat: 2},
+			Exit:          dto.Point{Lon: 2.5, Lat: 3},
+			EntryFraction: 5.0 / 7,
+			ExitFraction:  6.0 / 7,
+			EntryTime:     at(50),
+			ExitTime:      at(60),
+		},
+	}
+
+	rawRequest, err := json.Marshal(request)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, routeCrossings, bytes.NewBuffer(rawRequest))
+	r.RouteCrossings()(w, req)
+
+	response := w.Result()
+	defer func() { require.NoError(t, response.Body.Close()) }()
+
+	require.Equal(t, response.Header.Get("Content-Type"), "application/json")
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var actual []dto.ZoneCrossingOut
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+	require.Len(t, actual, 1)
+	require.Equal(t, polygonZoneId, actual[0].ZoneId)
+	require.InDelta(t, 2.0/7, actual[0].Fraction, 0.01)
+	require.Len(t, actual[0].Segments, len(expected))
+
+	for i, segment := range actual[0].Segments {
+		require.InDelta(t, expected[i].Entry.Lon, segment.Entry.Lon, 1e-9)
+		require.InDelta(t, expected[i].Entry.Lat, segment.Entry.Lat, 1e-9)
+		require.InDelta(t, expected[i].Exit.Lon, segment.Exit.Lon, 1e-9)
+		require.InDelta(t, expected[i].Exit.Lat, segment.Exit.Lat, 1e-9)
+		require.InDelta(t, expected[i].EntryFraction, segment.EntryFraction, 1e-9)
+		require.InDelta(t, expected[i].ExitFraction, segment.ExitFraction, 1e-9)
+		require.WithinDuration(t, *expected[i].EntryTime, *segment.EntryTime, time.Millisecond)
+		require.WithinDuration(t, *expected[i].ExitTime, *segment.ExitTime, time.Millisecond)
+	}
+
+	t.Run("without ids and times", func(t *testing.T) {
+		request := dto.RouteCrossingIn{
+			Points: []dto.RoutePoint{
+				{Point: dto.Point{Lon: -1, Lat: 0.5}},
+				{Point: dto.Point{Lon: 4, Lat: 0.5}},
+			},
+		}
+		rawRequest, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, routeCrossings, bytes.NewBuffer(rawRequest))
+		r.RouteCrossings()(w, req)
+
+		response := w.Result()
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		var actual []dto.ZoneCrossingOut
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		require.Len(t, actual, 2)
+		require.Equal(t, polygonZoneId, actual[0].ZoneId)
+		require.Equal(t, multiPolygonZoneId, actual[1].ZoneId)
+		for _, crossing := range actual {
+			require.InDelta(t, 0.2, crossing.Fraction, 0.01)
+			require.Len(t, crossing.Segments, 1)
+			require.Nil(t, crossing.Segments[0].EntryTime)
+			require.Nil(t, crossing.Segments[0].ExitTime)
+		}
+	})
+
+	t.Run("out and back", func(t *testing.T) {
+		// The route goes out and back through the first square, the vertex at x=0.5
+		// inside the square does not split the first pass.
+		request := dto.RouteCrossingIn{
+			ZoneIds: []int{polygonZoneId},
+			Points: []dto.RoutePoint{
+				{Point: dto.Point{Lon: -1, Lat: 0.5}},
+				{Point: dto.Point{Lon: 0.5, Lat: 0.5}},
+				{Point: dto.Point{Lon: 2, Lat: 0.5}},
+				{Point: dto.Point{Lon: -1, Lat: 0.5}},
+			},
+		}
+		rawRequest, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, routeCrossings, bytes.NewBuffer(rawRequest))
+		r.RouteCrossings()(w, req)
+
+		response := w.Result()
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		var actual []dto.ZoneCrossingOut
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		require.Len(t, actual, 1)
+		require.InDelta(t, 1.0/3, actual[0].Fraction, 0.01)
+		require.Len(t, actual[0].Segments, 2)
+		require.InDelta(t, 1.0/6, actual[0].Segments[0].EntryFraction, 1e-9)
+		require.InDelta(t, 2.0/6, actual[0].Segments[0].ExitFraction, 1e-9)
+		require.InDelta(t, 4.0/6, actual[0].Segments[1].EntryFraction, 1e-9)
+		require.InDelta(t, 5.0/6, actual[0].Segments[1].ExitFraction, 1e-9)
+		require.InDelta(t, 1, actual[0].Segments[1].Entry.Lon, 1e-9)
+		require.InDelta(t, 0, actual[0].Segments[1].Exit.Lon, 1e-9)
+	})
+}
+
+func TestRouteCrossings_Err(t *testing.T) {
+
+	type errResponse struct {
+		Error string `json:"error"`
+	}
+
+	tests := []struct {
+		name               string
+		requestData        string
+		providerErr        error
+		expectedResponse   errResponse
+		expectedStatusCode int
+	}{
+		{
+			name:               "wrong body",
+			requestData:        `{"points": {"lon": 1}}`,
+			expectedResponse:   errResponse{Error: geojson.SerializationErr.Error()},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "single point",
+			requestData:        `{"points": [{"lon": 0, "lat": 0}]}`,
+			expectedResponse:   errResponse{Error: dto.ErrInvalidRoute.Error()},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "partial times",
+			requestData:        `{"points": [{"lon": 0, "lat": 0, "time": "2026-03-01T10:00:00Z"}, {"lon": 1, "lat": 1}]}`,
+			expectedResponse:   errResponse{Error: dto.ErrInvalidRouteTimes.Error()},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "not supported",
+			requestData:        `{"points": [{"lon": 0, "lat": 0}, {"lon": 1, "lat": 1}]}`,
+			providerErr:        dto.ErrNotSupported,
+			expectedResponse:   errResponse{Error: dto.ErrNotSupported.Error()},
+			expectedStatusCode: http.StatusNotImplemented,
+		},
+		{
+			name:               "db error",
+			requestData:        `{"points": [{"lon": 0, "lat": 0}, {"lon": 1, "lat": 1}]}`,
+			providerErr:        errors.New("DB DOWN"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockSaver := storageMock.NewMockSaver(ctrl)
+			mockProvider := storageMock.NewMockProvider(ctrl)
+			mockDeleter := storageMock.NewMockDeleter(ctrl)
+
+			if tt.providerErr != nil {
+				mockProvider.EXPECT().
+					RouteCrossings(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, tt.providerErr).
+					Times(1)
+			}
+
+			zoneService := zone.New(log, mockSaver, mockProvider, mockDeleter)
+			r := NewRouter(mux.NewRouter(), zoneService, log)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, routeCrossings, bytes.NewBuffer([]byte(tt.requestData)))
+
+			r.RouteCrossings()(w, req)
+
+			response := w.Result()
+			defer func() { require.NoError(t, response.Body.Close()) }()
+
+			require.Equal(t, response.Header.Get("Content-Type"), "application/json")
+			require.Equal(t, tt.expectedStatusCode, response.StatusCode)
+
+			if tt.expectedStatusCode != http.StatusInternalServerError {
+				var actual errResponse
+				err := json.NewDecoder(response.Body).Decode(&actual)
+				require.NoError(t, err)
+				require.Equal(t, tt.expectedResponse, actual)
+			}
+		})
+	}
+}
@@ -8,22 +8,66 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 
+	"github.com/maxsnegir/zones_service/internal/service/changes"
+	"github.com/maxsnegir/zones_service/internal/service/geofence"
+	"github.com/maxsnegir/zones_service/internal/service/layer"
+	"github.com/maxsnegir/zones_service/internal/service/webhook"
 	"github.com/maxsnegir/zones_service/internal/service/zone"
 )
 
+const (
+	mvtContentType     = "application/vnd.mapbox-vector-tile"
+	sseContentType     = "text/event-stream"
+	ndjsonContentType  = "application/x-ndjson"
+	geojsonContentType = "application/geo+json"
+)
+
 const (
 	createZoneRoute            = "/create"
 	getZonesRoute              = "/get"
 	zonesContainsPoint         = "/contains"
 	anyZonesContainsPoint      = "/any_contains"
 	batchAnyZonesContainsPoint = "/batch_any_contains"
+	findZonesByPoint           = "/find_by_point"
+	nearestZones               = "/nearest"
+	routeCrossings             = "/route_crossings"
 	deleteZoneRoute            = "/delete/{id}"
+	zonesRoute                 = "/zones"
+	zoneRoute                  = "/zones/{id}"
+	zoneChangesRoute           = "/zones/changes"
+	zonesImportRoute           = "/zones/import"
+	zonesCountRoute            = "/zones/count"
+	exportRoute                = "/export"
+	zoneVersionsRoute          = "/zones/{id}/versions"
+	zoneByExternalKeyRoute     = "/zones/external/{key}"
+	zonesTileRoute             = "/tiles/{z:[0-9]+}/{x:[0-9]+}/{y:[0-9]+}.mvt"
+	geofencePositionsRoute     = "/geofence/positions"
+	geofenceDeviceRoute        = "/geofence/devices/{id}"
+	webhooksRoute              = "/webhooks"
+	webhookRoute               = "/webhooks/{id:[0-9]+}"
+	webhookDeliveriesRoute     = "/webhooks/{id:[0-9]+}/deliveries"
+	webhookDeadLettersRoute    = "/webhooks/dead_letters"
+	webhookRetryRoute          = "/webhooks/dead_letters/{id:[0-9]+}/retry"
+	layersRoute                = "/layers"
+	layerRoute                 = "/layers/{name:[A-Za-z0-9_-]+}"
+	layerVersionsRoute         = "/layers/{name:[A-Za-z0-9_-]+}/versions"
+	layerValidateRoute         = "/layers/{name:[A-Za-z0-9_-]+}/versions/{version:[0-9]+}/validate"
+	layerPublishRoute          = "/layers/{name:[A-Za-z0-9_-]+}/versions/{version:[0-9]+}/publish"
+	layerRollbackRoute         = "/layers/{name:[A-Za-z0-9_-]+}/rollback"
 )
 
 type Router struct {
 	router      *mux.Router
 	log         *logrus.Logger
 	ZoneService *zone.Service
+	// GeofenceService is optional, geofence routes are registered only when it is set.
+	GeofenceService *geofence.Service
+	// ChangesService is optional, the change feed is registered only when it is set.
+	ChangesService *changes.Service
+	// WebhookService is optional, webhook routes are registered only when it is set.
+	WebhookService *webhook.Service
+	// LayerService is optional, layer routes are registered only when it is set.
+	LayerService *layer.Service
 }
 
 func NewRouter(router *mux.Router, zoneService *zone.Service, logger *logrus.Logger) *Router {
@@ -45,8 +89,52 @@ func (r *Router) ConfigureRouter() {
 	r.router.HandleFunc(zonesContainsPoint, r.ZonesContainsPoint()).Methods(http.MethodPost)
 	r.router.HandleFunc(anyZonesContainsPoint, r.AnyOfZonesContainsPint()).Methods(http.MethodPost)
 	r.router.HandleFunc(batchAnyZonesContainsPoint, r.BatchAnyOfZonesContainsPint()).Methods(http.MethodPost)
+	r.router.HandleFunc(findZonesByPoint, r.FindZonesByPoint()).Methods(http.MethodPost)
+	r.router.HandleFunc(nearestZones, r.NearestZones()).Methods(http.MethodPost)
+	r.router.HandleFunc(routeCrossings, r.RouteCrossings()).Methods(http.MethodPost)
 	r.router.HandleFunc(deleteZoneRoute, r.DeleteZone()).Methods(http.MethodDelete)
 
+	r.router.HandleFunc(zonesRoute, r.ListZones()).Methods(http.MethodGet)
+	r.router.HandleFunc(zonesRoute, r.CreateZone()).Methods(http.MethodPost)
+	r.router.HandleFunc(zonesImportRoute, r.ImportZones()).Methods(http.MethodPost)
+	r.router.HandleFunc(exportRoute, r.ExportZones()).Methods(http.MethodGet)
+	// Must be registered before zoneRoute, which matches it too.
+	r.router.HandleFunc(zonesCountRoute, r.ZonesCount()).Methods(http.MethodGet)
+	if r.ChangesService != nil {
+		// Must be registered before zoneRoute, which matches it too.
+		r.router.HandleFunc(zoneChangesRoute, r.ZoneChanges()).Methods(http.MethodGet)
+	}
+	r.router.HandleFunc(zoneRoute, r.GetZone()).Methods(http.MethodGet)
+	r.router.HandleFunc(zoneRoute, r.UpdateZone()).Methods(http.MethodPut)
+	r.router.HandleFunc(zoneRoute, r.PatchZone()).Methods(http.MethodPatch)
+	r.router.HandleFunc(zoneRoute, r.DeleteZone()).Methods(http.MethodDelete)
+	r.router.HandleFunc(zoneVersionsRoute, r.GetZoneVersions()).Methods(http.MethodGet)
+	r.router.HandleFunc(zoneByExternalKeyRoute, r.GetZoneByExternalKey()).Methods(http.MethodGet)
+	r.router.HandleFunc(zonesTileRoute, r.GetZonesTile()).Methods(http.MethodGet)
+
+	if r.GeofenceService != nil {
+		r.router.HandleFunc(geofencePositionsRoute, r.TrackDevicePosition()).Methods(http.MethodPost)
+		r.router.HandleFunc(geofenceDeviceRoute, r.GetDeviceState()).Methods(http.MethodGet)
+	}
+	if r.WebhookService != nil {
+		r.router.HandleFunc(webhooksRoute, r.CreateWebhookSubscription()).Methods(http.MethodPost)
+		r.router.HandleFunc(webhooksRoute, r.GetWebhookSubscriptions()).Methods(http.MethodGet)
+		r.router.HandleFunc(webhookRoute, r.DeleteWebhookSubscription()).Methods(http.MethodDelete)
+		r.router.HandleFunc(webhookDeliveriesRoute, r.GetWebhookDeliveries()).Methods(http.MethodGet)
+		r.router.HandleFunc(webhookDeadLettersRoute, r.GetWebhookDeadLetters()).Methods(http.MethodGet)
+		r.router.HandleFunc(webhookRetryRoute, r.RetryWebhookDeadLetter()).Methods(http.MethodPost)
+	}
+	if r.LayerService != nil {
+		r.router.HandleFunc(layersRoute, r.CreateLayer()).Methods(http.MethodPost)
+		r.router.HandleFunc(layersRoute, r.GetLayers()).Methods(http.MethodGet)
+		r.router.HandleFunc(layerRoute, r.GetLayer()).Methods(http.MethodGet)
+		r.router.HandleFunc(layerVersionsRoute, r.CreateLayerDraft()).Methods(http.MethodPost)
+		r.router.HandleFunc(layerVersionsRoute, r.GetLayerVersions()).Methods(http.MethodGet)
+		r.router.HandleFunc(layerValidateRoute, r.ValidateLayerVersion()).Methods(http.MethodPost)
+		r.router.HandleFunc(layerPublishRoute, r.PublishLayerVersion()).Methods(http.MethodPost)
+		r.router.HandleFunc(layerRollbackRoute, r.RollbackLayer()).Methods(http.MethodPost)
+	}
+
 	// Middlewares
 	r.router.Use(r.loggingMiddleware)
 }
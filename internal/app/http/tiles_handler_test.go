@@ -0,0 +1,144 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+	storageMock "github.com/maxsnegir/zones_service/internal/repository/mocks"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+func TestGetZonesTile_Ok(t *testing.T) {
+	ctx := context.Background()
+
+	polygonZoneId, err := createZoneFixture(ctx, polygonGeoJson)
+	require.NoError(t, err)
+	_, err = createZoneFixture(ctx, polygonWithMetadataGeoJson)
+	require.NoError(t, err)
+
+	defer storage.CleanDB(ctx)
+
+	tests := []struct {
+		name      string
+		url       string
+		wantEmpty bool
+	}{
+		{
+			name: "world tile",
+			url:  "/tiles/0/0/0.mvt",
+		},
+		{
+			name: "filtered by id",
+			url:  fmt.Sprintf("/tiles/0/0/0.mvt?ids=%d", polygonZoneId),
+		},
+		{
+			name: "filtered by tag",
+			url:  "/tiles/0/0/0.mvt?tag=night",
+		},
+		{
+			name:      "unknown tag",
+			url:       "/tiles/0/0/0.mvt?tag=unknown",
+			wantEmpty: true,
+		},
+		{
+			name:      "tile without zones",
+			url:       "/tiles/2/0/0.mvt",
+			wantEmpty: true,
+		},
+	}
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.ConfigureRouter()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			response := w.Result()
+			defer func() { require.NoError(t, response.Body.Close()) }()
+
+			require.Equal(t, http.StatusOK, response.StatusCode)
+			require.Equal(t, mvtContentType, response.Header.Get("Content-Type"))
+
+			body, err := io.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantEmpty, len(body) == 0)
+		})
+	}
+}
+
+func TestGetZonesTile_Err(t *testing.T) {
+	tests := []struct {
+		name               string
+		url                string
+		storageErr         error
+		expectedStatusCode int
+	}{
+		{
+			name:               "x out of range",
+			url:                "/tiles/1/2/0.mvt",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "zoom too big",
+			url:                "/tiles/25/0/0.mvt",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "invalid ids",
+			url:                "/tiles/0/0/0.mvt?ids=a",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "not supported",
+			url:                "/tiles/0/0/0.mvt",
+			storageErr:         dto.ErrNotSupported,
+			expectedStatusCode: http.StatusNotImplemented,
+		},
+		{
+			name:               "db error",
+			url:                "/tiles/0/0/0.mvt",
+			storageErr:         errors.New("DB DOWN"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockSaver := storageMock.NewMockSaver(ctrl)
+			mockProvider := storageMock.NewMockProvider(ctrl)
+			mockDeleter := storageMock.NewMockDeleter(ctrl)
+			if tt.storageErr != nil {
+				mockProvider.EXPECT().GetZonesTile(gomock.Any(), gomock.Any()).Return(nil, tt.storageErr).Times(1)
+			}
+
+			zoneService := zone.New(log, mockSaver, mockProvider, mockDeleter)
+			r := NewRouter(mux.NewRouter(), zoneService, log)
+			r.ConfigureRouter()
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			response := w.Result()
+			defer func() { require.NoError(t, response.Body.Close()) }()
+
+			require.Equal(t, response.Header.Get("Content-Type"), "application/json")
+			require.Equal(t, tt.expectedStatusCode, response.StatusCode)
+		})
+	}
+}
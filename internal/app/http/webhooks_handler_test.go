@@ -0,0 +1,144 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/config"
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	storageMock "github.com/maxsnegir/zones_service/internal/repository/mocks"
+	"github.com/maxsnegir/zones_service/internal/service/webhook"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+func TestWebhooks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+
+	zoneService := zone.New(log, storageMock.NewMockSaver(ctrl), storageMock.NewMockProvider(ctrl), storageMock.NewMockDeleter(ctrl))
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.WebhookService = webhook.New(log, storage, http.DefaultClient, config.WebhookConfig{})
+	r.ConfigureRouter()
+
+	type errResponse struct {
+		Error string `json:"error"`
+	}
+
+	do := func(t *testing.T, method, url, body string) *http.Response {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+		r.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	var subscription dto.WebhookSubscription
+	t.Run("create subscription", func(t *testing.T) {
+		response := do(t, http.MethodPost, webhooksRoute, `{"url": "https://example.com/hook", "event_types": ["zone.created"], "secret": "secret"}`)
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&subscription))
+		require.Equal(t, "https://example.com/hook", subscription.URL)
+		require.Equal(t, []dto.WebhookEventType{dto.WebhookEventZoneCreated}, subscription.EventTypes)
+		require.Equal(t, "secret", subscription.Secret)
+	})
+
+	t.Run("list subscriptions", func(t *testing.T) {
+		response := do(t, http.MethodGet, webhooksRoute, "")
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		var actual []dto.WebhookSubscription
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		require.Len(t, actual, 1)
+		require.Empty(t, actual[0].Secret)
+	})
+
+	t.Run("deliveries", func(t *testing.T) {
+		response := do(t, http.MethodGet, "/webhooks/1/deliveries?limit=10", "")
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+	})
+
+	t.Run("retry unknown dead letter", func(t *testing.T) {
+		response := do(t, http.MethodPost, "/webhooks/dead_letters/1/retry", "")
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+
+	t.Run("delete subscription", func(t *testing.T) {
+		response := do(t, http.MethodDelete, "/webhooks/1", "")
+		require.NoError(t, response.Body.Close())
+		require.Equal(t, http.StatusNoContent, response.StatusCode)
+
+		response = do(t, http.MethodDelete, "/webhooks/1", "")
+		require.NoError(t, response.Body.Close())
+		require.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+
+	errTests := []struct {
+		name             string
+		method           string
+		url              string
+		requestData      string
+		expectedResponse errResponse
+	}{
+		{
+			name:             "wrong body",
+			method:           http.MethodPost,
+			url:              webhooksRoute,
+			requestData:      `{"url": 1}`,
+			expectedResponse: errResponse{Error: geojson.SerializationErr.Error()},
+		},
+		{
+			name:             "wrong url",
+			method:           http.MethodPost,
+			url:              webhooksRoute,
+			requestData:      `{"url": "localhost", "event_types": ["zone.created"]}`,
+			expectedResponse: errResponse{Error: dto.ErrInvalidWebhookURL.Error()},
+		},
+		{
+			name:             "wrong event type",
+			method:           http.MethodPost,
+			url:              webhooksRoute,
+			requestData:      `{"url": "https://example.com", "event_types": ["zone.moved"]}`,
+			expectedResponse: errResponse{Error: dto.ErrInvalidEventType.Error()},
+		},
+		{
+			name:             "private url",
+			method:           http.MethodPost,
+			url:              webhooksRoute,
+			requestData:      `{"url": "http://169.254.169.254/latest/meta-data", "event_types": ["zone.created"]}`,
+			expectedResponse: errResponse{Error: dto.ErrPrivateWebhookURL.Error()},
+		},
+		{
+			name:             "wrong limit",
+			method:           http.MethodGet,
+			url:              "/webhooks/dead_letters?limit=0",
+			expectedResponse: errResponse{Error: dto.ErrInvalidLimit.Error()},
+		},
+	}
+
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := do(t, tt.method, tt.url, tt.requestData)
+			defer func() { require.NoError(t, response.Body.Close()) }()
+			require.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+			var actual errResponse
+			require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+			require.Equal(t, tt.expectedResponse, actual)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/config"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/service/changes"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+func TestZoneChanges(t *testing.T) {
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.ChangesService = changes.New(log, storage, config.ChangesConfig{
+		PollInterval:   5 * time.Millisecond,
+		Heartbeat:      10 * time.Millisecond,
+		MaxWaitTimeout: 50 * time.Millisecond,
+	})
+	r.ConfigureRouter()
+
+	featureCollection, err := decodeFeatureCollection(io.NopCloser(bytes.NewBufferString(polygonGeoJson)), dto.GeoJSONLenient, 0)
+	require.NoError(t, err)
+	zoneId, err := zoneService.SaveZoneFromFeatureCollection(ctx, featureCollection)
+	require.NoError(t, err)
+	require.NoError(t, zoneService.DeleteZone(ctx, zoneId))
+
+	type errResponse struct {
+		Error string `json:"error"`
+	}
+
+	t.Run("long poll", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/zones/changes?after=1", nil)
+		r.ServeHTTP(w, req)
+
+		response := w.Result()
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		var actual dto.ZoneChangesOut
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		require.Len(t, actual.Changes, 1)
+		require.Equal(t, dto.ZoneChangeDeleted, actual.Changes[0].Type)
+		require.Equal(t, int64(2), actual.LastSeq)
+	})
+
+	t.Run("long poll timeout", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/zones/changes?after=2&timeout=1", nil)
+		r.ServeHTTP(w, req)
+
+		response := w.Result()
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		var actual dto.ZoneChangesOut
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		require.Equal(t, dto.ZoneChangesOut{Changes: []dto.ZoneChange{}, LastSeq: 2}, actual)
+	})
+
+	t.Run("event stream resume", func(t *testing.T) {
+		reqCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, zoneChangesRoute, nil).WithContext(reqCtx)
+		req.Header.Set("Accept", sseContentType)
+		req.Header.Set("Last-Event-ID", "1")
+		r.ServeHTTP(w, req)
+
+		response := w.Result()
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, sseContentType, response.Header.Get("Content-Type"))
+
+		body := w.Body.String()
+		require.True(t, strings.HasPrefix(body, "id: 2\nevent: deleted\ndata: {"), body)
+		require.NotContains(t, body, "id: 1\n")
+		require.Contains(t, body, ": keepalive\n\n")
+	})
+
+	errTests := []struct {
+		name             string
+		url              string
+		expectedResponse errResponse
+	}{
+		{
+			name:             "wrong after",
+			url:              "/zones/changes?after=-1",
+			expectedResponse: errResponse{Error: dto.ErrInvalidSequence.Error()},
+		},
+		{
+			name:             "wrong limit",
+			url:              "/zones/changes?limit=100000",
+			expectedResponse: errResponse{Error: dto.ErrInvalidLimit.Error()},
+		},
+		{
+			name:             "wrong timeout",
+			url:              "/zones/changes?timeout=1m",
+			expectedResponse: errResponse{Error: ErrInvalidTimeout.Error()},
+		},
+	}
+
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			r.ServeHTTP(w, req)
+
+			response := w.Result()
+			defer func() { require.NoError(t, response.Body.Close()) }()
+			require.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+			var actual errResponse
+			require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+			require.Equal(t, tt.expectedResponse, actual)
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+const polygonWithMetadataGeoJson = `
+	{
+		"type": "FeatureCollection",
+		"metadata": {"name": "Downtown", "external_key": "dt-1", "tags": ["delivery", "night"]},
+		"features": [
+			{
+				"type": "Feature",
+				"properties": {"color": "#ff0000"},
+				"geometry": {
+					"type": "Polygon",
+					"coordinates": [[[0, 0], [0, 1], [1, 1], [1, 0], [0, 0]]]
+				}
+			}
+		]
+	}`
+
+func TestZoneMetadata_Ok(t *testing.T) {
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.ConfigureRouter()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, createZoneRoute, bytes.NewBufferString(polygonWithMetadataGeoJson)))
+	response := w.Result()
+	require.NoError(t, response.Body.Close())
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/zones/external/dt-1", nil))
+	response = w.Result()
+	defer func() { require.NoError(t, response.Body.Close()) }()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var actual dto.ZoneGeoJSON
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+	require.Equal(t, "Downtown", actual.Name)
+	require.NotNil(t, actual.ExternalKey)
+	require.Equal(t, "dt-1", *actual.ExternalKey)
+	require.Equal(t, []string{"delivery", "night"}, actual.Tags)
+	require.False(t, actual.CreatedAt.IsZero())
+	require.False(t, actual.UpdatedAt.Before(actual.CreatedAt))
+
+	zones, err := storage.GetZonesByIds(ctx, []int{actual.ZoneId}, nil, dto.WGS84)
+	require.NoError(t, err)
+	require.Len(t, zones, 1)
+	require.Equal(t, actual.ZoneMetadata, zones[0].ZoneMetadata)
+}
+
+func TestZoneMetadata_Err(t *testing.T) {
+	ctx := context.Background()
+	_, err := createZoneFixture(ctx, polygonWithMetadataGeoJson)
+	require.NoError(t, err)
+	defer storage.CleanDB(ctx)
+
+	tests := []struct {
+		name               string
+		method             string
+		url                string
+		body               string
+		expectedStatusCode int
+		expectedError      string
+	}{
+		{
+			name:               "duplicate external key",
+			method:             http.MethodPost,
+			url:                createZoneRoute,
+			body:               polygonWithMetadataGeoJson,
+			expectedStatusCode: http.StatusConflict,
+			expectedError:      dto.ErrExternalKeyExists.Error(),
+		},
+		{
+			name:   "empty external key",
+			method: http.MethodPost,
+			url:    createZoneRoute,
+			body: `{"type": "FeatureCollection", "metadata": {"external_key": " "}, "features": [{"type": "Feature",
+				"geometry": {"type": "Polygon", "coordinates": [[[0, 0], [0, 1], [1, 1], [1, 0], [0, 0]]]}}]}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      dto.ErrInvalidExternalKey.Error(),
+		},
+		{
+			name:   "invalid schedule time zone",
+			method: http.MethodPost,
+			url:    createZoneRoute,
+			body: `{"type": "FeatureCollection", "metadata": {"schedule": {"time_zone": "Mars/Olympus", "windows": []}},
+				"features": [{"type": "Feature",
+				"geometry": {"type": "Polygon", "coordinates": [[[0, 0], [0, 1], [1, 1], [1, 0], [0, 0]]]}}]}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      dto.ErrInvalidTimeZone.Error(),
+		},
+		{
+			name:               "unknown external key",
+			method:             http.MethodGet,
+			url:                "/zones/external/unknown",
+			expectedStatusCode: http.StatusNotFound,
+			expectedError:      dto.ErrZoneNotFound.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zoneService := zone.New(log, storage, storage, storage)
+			r := NewRouter(mux.NewRouter(), zoneService, log)
+			r.ConfigureRouter()
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, bytes.NewBufferString(tt.body)))
+			response := w.Result()
+			defer func() { require.NoError(t, response.Body.Close()) }()
+
+			require.Equal(t, response.Header.Get("Content-Type"), "application/json")
+			require.Equal(t, tt.expectedStatusCode, response.StatusCode)
+
+			var actual struct {
+				Error string `json:"error"`
+			}
+			require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+			require.Equal(t, tt.expectedError, actual.Error)
+		})
+	}
+}
+
+func TestZoneSchedule(t *testing.T) {
+	ctx := context.Background()
+	defer storage.CleanDB(ctx)
+
+	const scheduledGeoJson = `
+		{
+			"type": "FeatureCollection",
+			"metadata": {"schedule": {
+				"time_zone": "Europe/Moscow",
+				"windows": [{"weekdays": ["mon"], "start": "22:00", "end": "06:00"}],
+				"exceptions": [{"date": "2030-03-11"}]
+			}},
+			"features": [
+				{
+					"type": "Feature",
+					"properties": {},
+					"geometry": {
+						"type": "Polygon",
+						"coordinates": [[[0, 0], [0, 1], [1, 1], [1, 0], [0, 0]]]
+					}
+				}
+			]
+		}`
+	zoneId, err := createZoneFixture(ctx, scheduledGeoJson)
+	require.NoError(t, err)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.ConfigureRouter()
+
+	tests := []struct {
+		name     string
+		at       string
+		expected bool
+	}{
+		{name: "monday night", at: "2030-03-04T23:30:00+03:00", expected: true},
+		{name: "tuesday morning", at: "2030-03-05T05:00:00+03:00", expected: true},
+		{name: "tuesday day", at: "2030-03-05T12:00:00+03:00", expected: false},
+		{name: "exception date", at: "2030-03-11T23:30:00+03:00", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestData := fmt.Sprintf(`{"ids": [%d], "point": {"lon": 0.5, "lat": 0.5}, "at": %q}`, zoneId, tt.at)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, zonesContainsPoint, bytes.NewBufferString(requestData)))
+			response := w.Result()
+			defer func() { require.NoError(t, response.Body.Close()) }()
+			require.Equal(t, http.StatusOK, response.StatusCode)
+
+			var actual []dto.ZoneContainsPointOut
+			require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+			require.Len(t, actual, 1)
+			require.Equal(t, tt.expected, actual[0].Contains)
+		})
+	}
+}
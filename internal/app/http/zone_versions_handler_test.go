@@ -0,0 +1,100 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+func TestZoneVersions(t *testing.T) {
+	ctx := context.Background()
+
+	zoneId, err := createZoneFixture(ctx, polygonGeoJson)
+	require.NoError(t, err)
+	defer storage.CleanDB(ctx)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.ConfigureRouter()
+
+	featureCollection, err := decodeFeatureCollection(io.NopCloser(bytes.NewBufferString(multiPolygonGeoJson)), dto.GeoJSONLenient, 0)
+	require.NoError(t, err)
+	featureCollection.Features = featureCollection.Features[1:]
+	require.NoError(t, zoneService.UpdateZoneFromFeatureCollection(ctx, zoneId, featureCollection))
+
+	get := func(t *testing.T, target string, out interface{}) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		response := w.Result()
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		if out != nil && response.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(response.Body).Decode(out))
+		}
+		return response.StatusCode
+	}
+
+	var versions []dto.ZoneVersion
+	require.Equal(t, http.StatusOK, get(t, fmt.Sprintf("/zones/%d/versions", zoneId), &versions))
+	require.Len(t, versions, 2)
+	require.Equal(t, 1, versions[0].Version)
+	require.Len(t, versions[0].GeoJSON.Features, 2)
+	require.NotNil(t, versions[0].ValidTo)
+	require.Equal(t, 2, versions[1].Version)
+	require.Len(t, versions[1].GeoJSON.Features, 1)
+	require.Nil(t, versions[1].ValidTo)
+
+	firstVersionAt := versions[0].ValidFrom.Format(time.RFC3339Nano)
+
+	t.Run("get zone at", func(t *testing.T) {
+		var actual dto.ZoneGeoJSON
+		target := fmt.Sprintf("/zones/%d?at=%s", zoneId, url.QueryEscape(firstVersionAt))
+		require.Equal(t, http.StatusOK, get(t, target, &actual))
+		require.Equal(t, versions[0].GeoJSON.Features, actual.GeoJSON.Features)
+	})
+
+	t.Run("contains at", func(t *testing.T) {
+		// The point is in the feature removed by the update.
+		requestData := fmt.Sprintf(`{"ids": [%d], "point": {"lon": 0.5, "lat": 0.5}, "at": %q}`, zoneId, firstVersionAt)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, anyZonesContainsPoint, bytes.NewBufferString(requestData)))
+		response := w.Result()
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		var actual dto.AnyContainsPointOut
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		require.True(t, actual.Contains)
+
+		contains, err := storage.AnyContainsPoint(ctx, []int{zoneId}, dto.Point{Lon: 0.5, Lat: 0.5}, dto.ContainsOptions{})
+		require.NoError(t, err)
+		require.False(t, contains.Contains)
+	})
+
+	t.Run("deleted zone history", func(t *testing.T) {
+		require.NoError(t, zoneService.DeleteZone(ctx, zoneId))
+
+		var actual []dto.ZoneVersion
+		require.Equal(t, http.StatusOK, get(t, fmt.Sprintf("/zones/%d/versions", zoneId), &actual))
+		require.Len(t, actual, 2)
+		require.NotNil(t, actual[1].ValidTo)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, get(t, "/zones/100500/versions", nil))
+		require.Equal(t, http.StatusBadRequest, get(t, "/zones/0/versions", nil))
+		require.Equal(t, http.StatusBadRequest, get(t, fmt.Sprintf("/zones/%d?at=yesterday", zoneId), nil))
+	})
+}
@@ -0,0 +1,265 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+	storageMock "github.com/maxsnegir/zones_service/internal/repository/mocks"
+	"github.com/maxsnegir/zones_service/internal/service/zone"
+)
+
+func TestZonesResource_Ok(t *testing.T) {
+	ctx := context.Background()
+
+	polygonZoneId, err := createZoneFixture(ctx, polygonGeoJson)
+	require.NoError(t, err)
+	multiPolygonZoneId, err := createZoneFixture(ctx, multiPolygonGeoJson)
+	require.NoError(t, err)
+
+	defer storage.CleanDB(ctx)
+
+	zoneService := zone.New(log, storage, storage, storage)
+	r := NewRouter(mux.NewRouter(), zoneService, log)
+	r.ConfigureRouter()
+
+	t.Run("list zones", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, zonesRoute, nil))
+
+		response := w.Result()
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		var actual dto.ZonesPageOut
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		require.Len(t, actual.Zones, 2)
+		require.Equal(t, polygonZoneId, actual.Zones[0].ZoneId)
+		require.Equal(t, multiPolygonZoneId, actual.Zones[1].ZoneId)
+		require.Empty(t, actual.NextCursor)
+	})
+
+	t.Run("get zone", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/zones/%d", polygonZoneId), nil))
+
+		response := w.Result()
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		var actual dto.ZoneGeoJSON
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&actual))
+		require.Equal(t, polygonZoneId, actual.ZoneId)
+		require.Len(t, actual.GeoJSON.Features, 2)
+	})
+
+	t.Run("replace zone", func(t *testing.T) {
+		const newGeoJson = `{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {"color": "#0000ff"},
+			"geometry": {"type": "Polygon", "coordinates": [[[10, 10], [10, 11], [11, 11], [11, 10], [10, 10]]]}}]}`
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/zones/%d", polygonZoneId), bytes.NewBufferString(newGeoJson))
+		r.ServeHTTP(w, req)
+
+		response := w.Result()
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		contains, err := storage.ContainsPoint(ctx, []int{polygonZoneId}, dto.Point{Lon: 10.5, Lat: 10.5}, dto.ContainsOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []dto.ZoneContainsPointOut{{ZoneId: polygonZoneId, Contains: true, Position: dto.PositionInside}}, contains)
+
+		contains, err = storage.ContainsPoint(ctx, []int{polygonZoneId}, dto.Point{Lon: 0.5, Lat: 0.5}, dto.ContainsOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []dto.ZoneContainsPointOut{{ZoneId: polygonZoneId, Contains: false, Position: dto.PositionOutside}}, contains)
+	})
+
+	t.Run("patch properties", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(
+			http.MethodPatch,
+			fmt.Sprintf("/zones/%d", multiPolygonZoneId),
+			bytes.NewBufferString(`{"properties": [null, {"color": "#000000"}]}`),
+		)
+		r.ServeHTTP(w, req)
+
+		response := w.Result()
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		zones, err := storage.GetZonesByIds(ctx, []int{multiPolygonZoneId}, nil, dto.WGS84)
+		require.NoError(t, err)
+		require.Len(t, zones, 1)
+		require.Equal(t, map[string]interface{}{"color": "#ff0000"}, zones[0].GeoJSON.Features[0].Properties)
+		require.Equal(t, map[string]interface{}{"color": "#000000"}, zones[0].GeoJSON.Features[1].Properties)
+	})
+
+	t.Run("delete zone", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/zones/%d", multiPolygonZoneId), nil))
+
+		response := w.Result()
+		defer func() { require.NoError(t, response.Body.Close()) }()
+		require.Equal(t, http.StatusNoContent, response.StatusCode)
+
+		cnt, err := storage.GetZonesCount(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, cnt)
+	})
+}
+
+func TestZonesResource_Err(t *testing.T) {
+	tests := []struct {
+		name               string
+		method             string
+		url                string
+		body               string
+		mockSetup          func(saver *storageMock.MockSaver, provider *storageMock.MockProvider)
+		expectedStatusCode int
+	}{
+		{
+			name:               "get: invalid id",
+			method:             http.MethodGet,
+			url:                "/zones/a",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "get: not found",
+			method: http.MethodGet,
+			url:    "/zones/1",
+			mockSetup: func(saver *storageMock.MockSaver, provider *storageMock.MockProvider) {
+				provider.EXPECT().GetZonesByIds(gomock.Any(), []int{1}, nil, dto.WGS84).Return([]dto.ZoneGeoJSON{}, nil).Times(1)
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:   "get: db error",
+			method: http.MethodGet,
+			url:    "/zones/1",
+			mockSetup: func(saver *storageMock.MockSaver, provider *storageMock.MockProvider) {
+				provider.EXPECT().GetZonesByIds(gomock.Any(), []int{1}, nil, dto.WGS84).Return(nil, errors.New("DB DOWN")).Times(1)
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "list: db error",
+			method: http.MethodGet,
+			url:    zonesRoute,
+			mockSetup: func(saver *storageMock.MockSaver, provider *storageMock.MockProvider) {
+				provider.EXPECT().ListZones(gomock.Any(), gomock.Any()).Return(nil, errors.New("DB DOWN")).Times(1)
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:               "list: invalid limit",
+			method:             http.MethodGet,
+			url:                zonesRoute + "?limit=0.5",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "put: invalid id",
+			method:             http.MethodPut,
+			url:                "/zones/0",
+			body:               polygonGeoJson,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "put: wrong body",
+			method:             http.MethodPut,
+			url:                "/zones/1",
+			body:               `{"type": "FeatureCollection"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "put: not found",
+			method: http.MethodPut,
+			url:    "/zones/1",
+			body:   polygonGeoJson,
+			mockSetup: func(saver *storageMock.MockSaver, provider *storageMock.MockProvider) {
+				saver.EXPECT().UpdateZoneFromFeatureCollection(gomock.Any(), 1, gomock.Any()).Return(dto.ErrZoneNotFound).Times(1)
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:   "put: db error",
+			method: http.MethodPut,
+			url:    "/zones/1",
+			body:   polygonGeoJson,
+			mockSetup: func(saver *storageMock.MockSaver, provider *storageMock.MockProvider) {
+				saver.EXPECT().UpdateZoneFromFeatureCollection(gomock.Any(), 1, gomock.Any()).Return(errors.New("DB DOWN")).Times(1)
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:               "patch: wrong body",
+			method:             http.MethodPatch,
+			url:                "/zones/1",
+			body:               `{"properties": {"color": "red"}}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "patch: empty properties",
+			method:             http.MethodPatch,
+			url:                "/zones/1",
+			body:               `{"properties": []}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "patch: properties count mismatch",
+			method: http.MethodPatch,
+			url:    "/zones/1",
+			body:   `{"properties": [{"color": "red"}]}`,
+			mockSetup: func(saver *storageMock.MockSaver, provider *storageMock.MockProvider) {
+				saver.EXPECT().UpdateZoneProperties(gomock.Any(), 1, gomock.Any()).Return(dto.ErrPropertiesCount).Times(1)
+			},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "patch: not found",
+			method: http.MethodPatch,
+			url:    "/zones/1",
+			body:   `{"properties": [{"color": "red"}]}`,
+			mockSetup: func(saver *storageMock.MockSaver, provider *storageMock.MockProvider) {
+				saver.EXPECT().UpdateZoneProperties(gomock.Any(), 1, gomock.Any()).Return(dto.ErrZoneNotFound).Times(1)
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockSaver := storageMock.NewMockSaver(ctrl)
+			mockProvider := storageMock.NewMockProvider(ctrl)
+			mockDeleter := storageMock.NewMockDeleter(ctrl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockSaver, mockProvider)
+			}
+
+			zoneService := zone.New(log, mockSaver, mockProvider, mockDeleter)
+			r := NewRouter(mux.NewRouter(), zoneService, log)
+			r.ConfigureRouter()
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, bytes.NewBufferString(tt.body)))
+
+			response := w.Result()
+			defer func() { require.NoError(t, response.Body.Close()) }()
+
+			require.Equal(t, response.Header.Get("Content-Type"), "application/json")
+			require.Equal(t, tt.expectedStatusCode, response.StatusCode)
+		})
+	}
+}
@@ -4,18 +4,44 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Env     string        `yaml:"env" env-default:"local"`
-	Storage StorageConfig `yaml:"storage" env-required:"true"`
-	Server  ServerConfig  `yaml:"server"`
+	Env      string         `yaml:"env" env-default:"local"`
+	Storage  StorageConfig  `yaml:"storage" env-required:"true"`
+	Server   ServerConfig   `yaml:"server"`
+	Geofence GeofenceConfig `yaml:"geofence"`
+	Webhook  WebhookConfig  `yaml:"webhook"`
+	Changes  ChangesConfig  `yaml:"changes"`
 }
 
 type StorageConfig struct {
-	DSN string `yaml:"dsn"`
+	Type string `yaml:"type" env-default:"postgres"`
+	DSN  string `yaml:"dsn"`
+}
+
+type GeofenceConfig struct {
+	DwellTime time.Duration `yaml:"dwell_time" env-default:"5m"`
+}
+
+type WebhookConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"1s"`
+	Timeout      time.Duration `yaml:"timeout" env-default:"10s"`
+	MaxAttempts  int           `yaml:"max_attempts" env-default:"8"`
+	BackoffBase  time.Duration `yaml:"backoff_base" env-default:"2s"`
+	BackoffMax   time.Duration `yaml:"backoff_max" env-default:"1h"`
+	BatchSize    int           `yaml:"batch_size" env-default:"50"`
+}
+
+type ChangesConfig struct {
+	// PollInterval is a fallback for missed database notifications, waiting requests
+	// are woken up by notifications and normally don't poll.
+	PollInterval   time.Duration `yaml:"poll_interval" env-default:"10s"`
+	Heartbeat      time.Duration `yaml:"heartbeat" env-default:"15s"`
+	MaxWaitTimeout time.Duration `yaml:"max_wait_timeout" env-default:"60s"`
 }
 
 type ServerConfig struct {
@@ -6,3 +6,9 @@ const (
 	EnvDev   = "dev"
 	EnvProd  = "prod"
 )
+
+const (
+	StorageTypePostgres = "postgres"
+	// StorageTypeIndexed keeps zones in Postgres too and answers point lookups from an in-memory index of them.
+	StorageTypeIndexed = "indexed"
+)
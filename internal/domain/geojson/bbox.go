@@ -0,0 +1,67 @@
+package geojson
+
+import (
+	"github.com/twpayne/go-geom"
+)
+
+// bboxTolerance allows for bboxes rounded by the tools producing them.
+const bboxTolerance = 1e-9
+
+// BBoxOf is the min_lon, min_lat, max_lon, max_lat bbox of the geometries, nil when they are empty.
+func BBoxOf(geometries ...geom.T) []float64 {
+	bounds := geom.NewBounds(geom.XY)
+	for _, g := range flatten(geometries...) {
+		if g.Empty() {
+			continue
+		}
+		bounds.Extend(g)
+	}
+	if bounds.IsEmpty() {
+		return nil
+	}
+	return []float64{bounds.Min(0), bounds.Min(1), bounds.Max(0), bounds.Max(1)}
+}
+
+// bboxContains reports whether the bbox contains every coordinate of the geometries.
+// A bbox with min_lon greater than max_lon crosses the antimeridian as RFC 7946 allows.
+func bboxContains(bbox []float64, geometries ...geom.T) bool {
+	if len(bbox) != 4 {
+		return false
+	}
+	for _, v := range bbox {
+		if !isFinite(v) {
+			return false
+		}
+	}
+	minLon, minLat, maxLon, maxLat := bbox[0], bbox[1], bbox[2], bbox[3]
+	if minLat > maxLat {
+		return false
+	}
+	for _, g := range flatten(geometries...) {
+		coords, stride := g.FlatCoords(), g.Stride()
+		for i := 0; i+1 < len(coords); i += stride {
+			lon, lat := coords[i], coords[i+1]
+			if lat < minLat-bboxTolerance || lat > maxLat+bboxTolerance {
+				return false
+			}
+			west, east := lon >= minLon-bboxTolerance, lon <= maxLon+bboxTolerance
+			if (minLon <= maxLon && !(west && east)) || (minLon > maxLon && !(west || east)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// flatten replaces GeometryCollections with their geometries, they have no coordinates of their own.
+func flatten(geometries ...geom.T) []geom.T {
+	result := make([]geom.T, 0, len(geometries))
+	for _, g := range geometries {
+		if collection, ok := g.(*geom.GeometryCollection); ok {
+			result = append(result, flatten(collection.Geoms()...)...)
+			continue
+		}
+		result = append(result, g)
+	}
+	return result
+}
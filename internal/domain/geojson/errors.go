@@ -6,12 +6,22 @@ import (
 )
 
 var (
-	SerializationErr                   = errors.New("serialization error")
-	FeaturesIsRequiredErr              = errors.New("features is required")
-	GeometryTypeIsRequiredErr          = errors.New("geometry is required")
-	CoordinatesIsRequiredErr           = errors.New("coordinates is required")
-	NotValidPolygonCoordinatesErr      = errors.New("not valid polygon coordinates")
-	NotValidMultiPolygonCoordinatesErr = errors.New("not valid multipolygon coordinates")
+	SerializationErr                      = errors.New("serialization error")
+	FeaturesIsRequiredErr                 = errors.New("features is required")
+	GeometryTypeIsRequiredErr             = errors.New("geometry is required")
+	CoordinatesIsRequiredErr              = errors.New("coordinates is required")
+	NotValidPolygonCoordinatesErr         = errors.New("not valid polygon coordinates")
+	NotValidMultiPolygonCoordinatesErr    = errors.New("not valid multipolygon coordinates")
+	NotValidPointCoordinatesErr           = errors.New("not valid point coordinates")
+	NotValidMultiPointCoordinatesErr      = errors.New("not valid multipoint coordinates")
+	NotValidLineStringCoordinatesErr      = errors.New("not valid linestring coordinates, at least two points expected")
+	NotValidMultiLineStringCoordinatesErr = errors.New("not valid multilinestring coordinates, at least two points in each line expected")
+	GeometriesIsRequiredErr               = errors.New("geometries is required")
+	NestedGeometryCollectionErr           = errors.New("nested geometry collections are not allowed")
+	RadiusIsRequiredErr                   = errors.New("radius is required for points and lines")
+	NotValidRadiusErr                     = errors.New("not valid radius, meters up to 100000 expected")
+	NotValidFeatureIdErr                  = errors.New("not valid feature id, string or number expected")
+	NotValidBBoxErr                       = errors.New("not valid bbox, min_lon,min_lat,max_lon,max_lat containing the geometries expected")
 )
 
 type UnsupportedGeometryTypeErr struct {
@@ -37,3 +47,21 @@ type NotValidFeatureType struct {
 func (e NotValidFeatureType) Error() string {
 	return fmt.Sprintf("not valid feature type: %s", e.T)
 }
+
+type DuplicateFeatureIdErr struct {
+	Id string
+}
+
+func (e DuplicateFeatureIdErr) Error() string {
+	return fmt.Sprintf("duplicate feature id: %s", e.Id)
+}
+
+// ForbiddenMemberErr is a member RFC 7946 does not allow in the object.
+type ForbiddenMemberErr struct {
+	Object string
+	Member string
+}
+
+func (e ForbiddenMemberErr) Error() string {
+	return fmt.Sprintf("%s must not have %s member", e.Object, e.Member)
+}
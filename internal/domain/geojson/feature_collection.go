@@ -1,6 +1,7 @@
 package geojson
 
 import (
+	"bytes"
 	"encoding/json"
 
 	"github.com/twpayne/go-geom"
@@ -9,17 +10,44 @@ import (
 )
 
 type FeatureCollection struct {
-	Type     string
-	Features []*Feature
+	Type           string
+	Features       []*Feature
+	Metadata       *dto.ZoneMetadata
+	ForeignMembers dto.ForeignMembers
+	// SRID is the crs the coordinates are in, 0 when it is not declared and they are lon/lat.
+	SRID int
 }
 
 type Feature struct {
-	Type       string
-	Geometry   PostgisGeometry
-	Properties map[string]interface{}
+	Type string
+	// Id is a JSON string or number, nil when the feature has none.
+	Id             json.RawMessage
+	Geometry       PostgisGeometry
+	Properties     map[string]interface{}
+	ForeignMembers dto.ForeignMembers
+	// Radius is the buffer in meters of points and lines of the geometry, 0 for areal geometries.
+	Radius float64
 }
 
+// MaxRadius is the largest radius in meters.
+const MaxRadius = 100_000.0
+
+// The members RFC 7946 forbids in the objects.
+var (
+	forbiddenFeatureCollectionMembers = []string{"coordinates", "geometries", "geometry", "properties"}
+	forbiddenFeatureMembers           = []string{"coordinates", "geometries", "features"}
+)
+
+// FromFeatureCollectionJSON parses the FeatureCollection leniently.
 func (fc *FeatureCollection) FromFeatureCollectionJSON(geojson dto.FeatureCollectionJSON) error {
+	return fc.ParseFeatureCollectionJSON(geojson, dto.GeoJSONLenient)
+}
+
+// ParseFeatureCollectionJSON parses the FeatureCollection checking it as the mode requires.
+// Feature ids and foreign members are kept, bboxes are not as they are computed on output.
+// The legacy crs member declares the SRID, it is not kept as a foreign member.
+func (fc *FeatureCollection) ParseFeatureCollectionJSON(geojson dto.FeatureCollectionJSON, mode dto.GeoJSONMode) error {
+	strict := mode == dto.GeoJSONStrict
 	if geojson.Type != "FeatureCollection" {
 		return NotValidFeatureCollectionType{geojson.Type}
 	}
@@ -27,32 +55,253 @@ func (fc *FeatureCollection) FromFeatureCollectionJSON(geojson dto.FeatureCollec
 	if geojson.Features == nil || len(geojson.Features) == 0 {
 		return FeaturesIsRequiredErr
 	}
+	if geojson.Metadata != nil {
+		if err := geojson.Metadata.Validate(); err != nil {
+			return err
+		}
+	}
+	if strict {
+		if err := checkMembers("FeatureCollection", geojson.ForeignMembers, forbiddenFeatureCollectionMembers); err != nil {
+			return err
+		}
+	}
 
 	features := make([]*Feature, 0, len(geojson.Features))
+	geometries := make([]geom.T, 0, len(geojson.Features))
+	ids := make(map[string]struct{})
 	for _, feature := range geojson.Features {
-		geometry, err := decodeGeometryJson(feature.Geometry)
+		if feature.Type != "Feature" && (strict || feature.Type != "") {
+			return NotValidFeatureType{feature.Type}
+		}
+		geometry, err := decodeGeometryJson(feature.Geometry, strict)
+		if err != nil {
+			return err
+		}
+		radius, err := featureRadius(feature.Radius, geometry.Geom())
 		if err != nil {
 			return err
 		}
+		id, err := decodeFeatureId(feature.Id, strict)
+		if err != nil {
+			return err
+		}
+		if strict {
+			if err = checkMembers("Feature", feature.ForeignMembers, forbiddenFeatureMembers); err != nil {
+				return err
+			}
+			if id != nil {
+				if _, ok := ids[string(id)]; ok {
+					return DuplicateFeatureIdErr{Id: string(id)}
+				}
+				ids[string(id)] = struct{}{}
+			}
+			if feature.BBox != nil && !bboxContains(feature.BBox, geometry.Geom()) {
+				return NotValidBBoxErr
+			}
+		}
 		features = append(features, &Feature{
-			Type:       feature.Type,
-			Geometry:   geometry,
-			Properties: feature.Properties,
+			Type:           "Feature",
+			Id:             id,
+			Geometry:       geometry,
+			Properties:     feature.Properties,
+			ForeignMembers: feature.ForeignMembers,
+			Radius:         radius,
 		})
+		geometries = append(geometries, geometry.Geom())
+	}
+	if strict && geojson.BBox != nil && !bboxContains(geojson.BBox, geometries...) {
+		return NotValidBBoxErr
+	}
+	srid, members, err := decodeCRSMember(geojson.ForeignMembers)
+	if err != nil {
+		return err
 	}
 	fc.Type = geojson.Type
 	fc.Features = features
+	fc.Metadata = geojson.Metadata
+	fc.ForeignMembers = members
+	fc.SRID = srid
+	return nil
+}
+
+// CRS returns the SRID of the coordinates, dto.WGS84 when none is declared.
+func (fc *FeatureCollection) CRS() int {
+	if fc.SRID == 0 {
+		return dto.WGS84
+	}
+	return fc.SRID
+}
+
+// DeclareCRS sets the SRID requested for the collection, it must match the one of its crs member.
+func (fc *FeatureCollection) DeclareCRS(srid int) error {
+	if fc.SRID != 0 && fc.SRID != srid {
+		return dto.ErrConflictingCRS
+	}
+	fc.SRID = srid
+	return nil
+}
+
+// decodeCRSMember returns the SRID the crs member declares, 0 without one, and the other members.
+func decodeCRSMember(members dto.ForeignMembers) (int, dto.ForeignMembers, error) {
+	data, ok := members[dto.CRSMemberName]
+	if !ok {
+		return 0, members, nil
+	}
+	var member dto.CRSMember
+	if err := json.Unmarshal(data, &member); err != nil {
+		return 0, nil, dto.ErrInvalidCRS
+	}
+	srid, err := member.SRID()
+	if err != nil {
+		return 0, nil, err
+	}
+	result := make(dto.ForeignMembers, len(members)-1)
+	for key, value := range members {
+		if key != dto.CRSMemberName {
+			result[key] = value
+		}
+	}
+	if len(result) == 0 {
+		result = nil
+	}
+	return srid, result, nil
+}
+
+// decodeFeatureId returns the compacted id, a string or a number. Other ids are rejected
+// in strict mode and dropped otherwise.
+func decodeFeatureId(id json.RawMessage, strict bool) (json.RawMessage, error) {
+	if len(id) == 0 {
+		return nil, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(id, &value); err != nil {
+		return nil, NotValidFeatureIdErr
+	}
+	switch value.(type) {
+	case string, float64:
+		var b bytes.Buffer
+		if err := json.Compact(&b, id); err != nil {
+			return nil, NotValidFeatureIdErr
+		}
+		return b.Bytes(), nil
+	case nil:
+		return nil, nil
+	}
+	if strict {
+		return nil, NotValidFeatureIdErr
+	}
+	return nil, nil
+}
+
+func checkMembers(object string, members dto.ForeignMembers, forbidden []string) error {
+	for _, member := range forbidden {
+		if _, ok := members[member]; ok {
+			return ForbiddenMemberErr{Object: object, Member: member}
+		}
+	}
 	return nil
 }
 
-func decodeGeometryJson(fg dto.FeatureGeometryJSON) (PostgisGeometry, error) {
+// featureRadius checks the radius of geometries with points or lines, it is ignored for areal ones.
+func featureRadius(radius *float64, g geom.T) (float64, error) {
+	if IsAreal(g) {
+		return 0, nil
+	}
+	if radius == nil {
+		return 0, RadiusIsRequiredErr
+	}
+	if *radius <= 0 || *radius > MaxRadius {
+		return 0, NotValidRadiusErr
+	}
+	return *radius, nil
+}
+
+func decodeGeometryJson(fg dto.FeatureGeometryJSON, strict bool) (PostgisGeometry, error) {
+	g, err := decodeGeom(fg, strict, false)
+	if err != nil {
+		return nil, err
+	}
+	return NewPostgisGeometry(g)
+}
+
+// decodeGeom decodes the geometry, nested tells a geometry of a GeometryCollection.
+// Nested GeometryCollections are rejected in strict mode as RFC 7946 advises against them.
+func decodeGeom(fg dto.FeatureGeometryJSON, strict, nested bool) (geom.T, error) {
 	if fg.Type == "" {
 		return nil, GeometryTypeIsRequiredErr
 	}
+	if fg.Type == "GeometryCollection" {
+		if strict && nested {
+			return nil, NestedGeometryCollectionErr
+		}
+		if len(fg.Geometries) == 0 {
+			return nil, GeometriesIsRequiredErr
+		}
+		collection := geom.NewGeometryCollection()
+		for _, g := range fg.Geometries {
+			decoded, err := decodeGeom(g, strict, true)
+			if err != nil {
+				return nil, err
+			}
+			if err = collection.Push(decoded); err != nil {
+				return nil, GeometriesIsRequiredErr
+			}
+		}
+		return collection, nil
+	}
 	if fg.Coordinates == nil {
 		return nil, CoordinatesIsRequiredErr
 	}
 	switch fg.Type {
+	case "Point":
+		var coord geom.Coord
+		if err := json.Unmarshal(*fg.Coordinates, &coord); err != nil {
+			return nil, NotValidPointCoordinatesErr
+		}
+		point, err := geom.NewPoint(geom.XY).SetCoords(coord)
+		if err != nil {
+			return nil, NotValidPointCoordinatesErr
+		}
+		return point, nil
+
+	case "MultiPoint":
+		var coords []geom.Coord
+		if err := json.Unmarshal(*fg.Coordinates, &coords); err != nil {
+			return nil, NotValidMultiPointCoordinatesErr
+		}
+		multiPoint, err := geom.NewMultiPoint(geom.XY).SetCoords(coords)
+		if err != nil || multiPoint.Empty() {
+			return nil, NotValidMultiPointCoordinatesErr
+		}
+		return multiPoint, nil
+
+	case "LineString":
+		var coords []geom.Coord
+		if err := json.Unmarshal(*fg.Coordinates, &coords); err != nil {
+			return nil, NotValidLineStringCoordinatesErr
+		}
+		lineString, err := geom.NewLineString(geom.XY).SetCoords(coords)
+		if err != nil || lineString.NumCoords() < 2 {
+			return nil, NotValidLineStringCoordinatesErr
+		}
+		return lineString, nil
+
+	case "MultiLineString":
+		var coords [][]geom.Coord
+		if err := json.Unmarshal(*fg.Coordinates, &coords); err != nil {
+			return nil, NotValidMultiLineStringCoordinatesErr
+		}
+		multiLineString, err := geom.NewMultiLineString(geom.XY).SetCoords(coords)
+		if err != nil || multiLineString.Empty() {
+			return nil, NotValidMultiLineStringCoordinatesErr
+		}
+		for i := 0; i < multiLineString.NumLineStrings(); i++ {
+			if multiLineString.LineString(i).NumCoords() < 2 {
+				return nil, NotValidMultiLineStringCoordinatesErr
+			}
+		}
+		return multiLineString, nil
+
 	case "Polygon":
 		var coords [][]geom.Coord
 		if err := json.Unmarshal(*fg.Coordinates, &coords); err != nil {
@@ -62,7 +311,7 @@ func decodeGeometryJson(fg dto.FeatureGeometryJSON) (PostgisGeometry, error) {
 		if err != nil || polygon.Empty() {
 			return nil, NotValidPolygonCoordinatesErr
 		}
-		return &PostgisPolygon{Polygon: *polygon}, nil
+		return polygon, nil
 
 	case "MultiPolygon":
 		var coords [][][]geom.Coord
@@ -73,7 +322,7 @@ func decodeGeometryJson(fg dto.FeatureGeometryJSON) (PostgisGeometry, error) {
 		if err != nil || multipolygon.Empty() {
 			return nil, NotValidMultiPolygonCoordinatesErr
 		}
-		return &PostgisMultiPolygon{MultiPolygon: *multipolygon}, nil
+		return multipolygon, nil
 
 	}
 	return nil, UnsupportedGeometryTypeErr{T: fg.Type}
@@ -110,6 +110,23 @@ func TestFeatureCollection_FromFeatureCollectionJSON_Err(t *testing.T) {
 			featureCol:  dto.FeatureCollectionJSON{Type: "FeatureCollection"},
 			expectedErr: FeaturesIsRequiredErr,
 		},
+		{
+			name: "invalid metadata",
+			featureCol: dto.FeatureCollectionJSON{
+				Type:     "FeatureCollection",
+				Features: []dto.FeatureJSON{{Type: "Feature"}},
+				Metadata: &dto.ZoneMetadata{Tags: []string{""}},
+			},
+			expectedErr: dto.ErrInvalidTag,
+		},
+		{
+			name: "wrong feature type",
+			featureCol: dto.FeatureCollectionJSON{
+				Type:     "FeatureCollection",
+				Features: []dto.FeatureJSON{{Type: "Polygon"}},
+			},
+			expectedErr: NotValidFeatureType{"Polygon"},
+		},
 		{
 			name:        "empty feature",
 			featureCol:  dto.FeatureCollectionJSON{Type: "FeatureCollection", Features: []dto.FeatureJSON{}},
@@ -233,3 +250,238 @@ func TestFeatureCollection_FromFeatureCollectionJSON_Err(t *testing.T) {
 		})
 	}
 }
+
+func parseFeatureCollection(t *testing.T, data string, mode dto.GeoJSONMode) (FeatureCollection, error) {
+	t.Helper()
+
+	var featureCollectionJSON dto.FeatureCollectionJSON
+	require.NoError(t, json.Unmarshal([]byte(data), &featureCollectionJSON))
+
+	var featureCollection FeatureCollection
+	err := featureCollection.ParseFeatureCollectionJSON(featureCollectionJSON, mode)
+	return featureCollection, err
+}
+
+func TestFeatureCollection_ParseFeatureCollectionJSON_Lenient(t *testing.T) {
+	featureCollection, err := parseFeatureCollection(t, `{
+		"type": "FeatureCollection",
+		"title": "parks",
+		"bbox": [100, 100, 101, 101],
+		"features": [
+			{"id": "a", "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}},
+			{"type": "Feature", "id": {"not": "an id"}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}, "source": "osm"},
+			{"type": "Feature", "id": 1.50, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}}
+		]
+	}`, dto.GeoJSONLenient)
+	require.NoError(t, err)
+
+	require.Equal(t, dto.ForeignMembers{"title": json.RawMessage(`"parks"`)}, featureCollection.ForeignMembers)
+	require.Len(t, featureCollection.Features, 3)
+	require.Equal(t, "Feature", featureCollection.Features[0].Type)
+	require.Equal(t, json.RawMessage(`"a"`), featureCollection.Features[0].Id)
+	require.Nil(t, featureCollection.Features[1].Id)
+	require.Equal(t, dto.ForeignMembers{"source": json.RawMessage(`"osm"`)}, featureCollection.Features[1].ForeignMembers)
+	require.Equal(t, json.RawMessage(`1.50`), featureCollection.Features[2].Id)
+}
+
+func TestFeatureCollection_ParseFeatureCollectionJSON_Strict(t *testing.T) {
+	const polygon = `{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}`
+	tests := []struct {
+		name        string
+		data        string
+		expectedErr error
+	}{
+		{
+			name:        "ok",
+			data:        `{"type": "FeatureCollection", "bbox": [0, 0, 1, 1], "features": [{"type": "Feature", "id": 1, "bbox": [0, 0, 1, 1], "geometry": ` + polygon + `}]}`,
+			expectedErr: nil,
+		},
+		{
+			name:        "missing feature type",
+			data:        `{"type": "FeatureCollection", "features": [{"geometry": ` + polygon + `}]}`,
+			expectedErr: NotValidFeatureType{""},
+		},
+		{
+			name:        "not valid feature id",
+			data:        `{"type": "FeatureCollection", "features": [{"type": "Feature", "id": true, "geometry": ` + polygon + `}]}`,
+			expectedErr: NotValidFeatureIdErr,
+		},
+		{
+			name:        "duplicate feature id",
+			data:        `{"type": "FeatureCollection", "features": [{"type": "Feature", "id": "a", "geometry": ` + polygon + `}, {"type": "Feature", "id": "a", "geometry": ` + polygon + `}]}`,
+			expectedErr: DuplicateFeatureIdErr{Id: `"a"`},
+		},
+		{
+			name:        "feature bbox not containing the geometry",
+			data:        `{"type": "FeatureCollection", "features": [{"type": "Feature", "bbox": [0, 0, 0.5, 0.5], "geometry": ` + polygon + `}]}`,
+			expectedErr: NotValidBBoxErr,
+		},
+		{
+			name:        "not valid feature collection bbox",
+			data:        `{"type": "FeatureCollection", "bbox": [0, 0, 1], "features": [{"type": "Feature", "geometry": ` + polygon + `}]}`,
+			expectedErr: NotValidBBoxErr,
+		},
+		{
+			name:        "feature with features member",
+			data:        `{"type": "FeatureCollection", "features": [{"type": "Feature", "features": [], "geometry": ` + polygon + `}]}`,
+			expectedErr: ForbiddenMemberErr{Object: "Feature", Member: "features"},
+		},
+		{
+			name:        "feature collection with geometry member",
+			data:        `{"type": "FeatureCollection", "geometry": ` + polygon + `, "features": [{"type": "Feature", "geometry": ` + polygon + `}]}`,
+			expectedErr: ForbiddenMemberErr{Object: "FeatureCollection", Member: "geometry"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseFeatureCollection(t, tt.data, dto.GeoJSONStrict)
+			if tt.expectedErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestBBoxOf(t *testing.T) {
+	featureCollection, err := parseFeatureCollection(t, `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}},
+		{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[-3, 2], [5, 2], [5, 4], [-3, 2]]]}}
+	]}`, dto.GeoJSONLenient)
+	require.NoError(t, err)
+
+	require.Equal(t, []float64{0, 0, 1, 1}, BBoxOf(featureCollection.Features[0].Geometry.Geom()))
+	require.Equal(t, []float64{-3, 0, 5, 4}, BBoxOf(featureCollection.Features[0].Geometry.Geom(), featureCollection.Features[1].Geometry.Geom()))
+	require.Nil(t, BBoxOf())
+}
+
+func TestFeatureCollection_ParseFeatureCollectionJSON_Geometries(t *testing.T) {
+	featureCollection, err := parseFeatureCollection(t, `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {"name": "pickup"}, "radius": 50, "geometry": {"type": "Point", "coordinates": [1, 2]}},
+		{"type": "Feature", "properties": {}, "radius": 50, "geometry": {"type": "MultiPoint", "coordinates": [[1, 2], [3, 4]]}},
+		{"type": "Feature", "properties": {}, "radius": 50, "geometry": {"type": "LineString", "coordinates": [[1, 2], [3, 4]]}},
+		{"type": "Feature", "properties": {}, "radius": 50, "geometry": {"type": "MultiLineString", "coordinates": [[[1, 2], [3, 4]], [[5, 6], [7, 8]]]}},
+		{"type": "Feature", "properties": {}, "radius": 50, "geometry": {"type": "GeometryCollection", "geometries": [
+			{"type": "Point", "coordinates": [1, 2]},
+			{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}
+		]}},
+		{"type": "Feature", "properties": {}, "radius": 50, "geometry": {"type": "GeometryCollection", "geometries": [
+			{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}
+		]}}
+	]}`, dto.GeoJSONStrict)
+	require.NoError(t, err)
+
+	expected := []struct {
+		geometry PostgisGeometry
+		radius   float64
+	}{
+		{geometry: &PostgisPoint{}, radius: 50},
+		{geometry: &PostgisMultiPoint{}, radius: 50},
+		{geometry: &PostgisLineString{}, radius: 50},
+		{geometry: &PostgisMultiLineString{}, radius: 50},
+		{geometry: &PostgisGeometryCollection{}, radius: 50},
+		{geometry: &PostgisGeometryCollection{}, radius: 0},
+	}
+	require.Len(t, featureCollection.Features, len(expected))
+	for i, feature := range featureCollection.Features {
+		require.IsType(t, expected[i].geometry, feature.Geometry)
+		require.Equal(t, expected[i].radius, feature.Radius)
+		require.NotNil(t, feature.Geometry.ToEwkb())
+	}
+	require.Equal(t, map[string]interface{}{"name": "pickup"}, featureCollection.Features[0].Properties)
+	require.Empty(t, featureCollection.Features[0].ForeignMembers)
+	require.Equal(t, []float64{0, 0, 1, 2}, BBoxOf(featureCollection.Features[4].Geometry.Geom()))
+}
+
+func TestFeatureCollection_ParseFeatureCollectionJSON_GeometriesErr(t *testing.T) {
+	const point = `{"type": "Point", "coordinates": [1, 2]}`
+	tests := []struct {
+		name        string
+		data        string
+		mode        dto.GeoJSONMode
+		expectedErr error
+	}{
+		{
+			name:        "point without radius",
+			data:        `{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {}, "geometry": ` + point + `}]}`,
+			expectedErr: RadiusIsRequiredErr,
+		},
+		{
+			name:        "not valid radius",
+			data:        `{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {}, "radius": 0, "geometry": ` + point + `}]}`,
+			expectedErr: NotValidRadiusErr,
+		},
+		{
+			name:        "too large radius",
+			data:        `{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {}, "radius": 100001, "geometry": ` + point + `}]}`,
+			expectedErr: NotValidRadiusErr,
+		},
+		{
+			name:        "not valid point coordinates",
+			data:        `{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {}, "radius": 5, "geometry": {"type": "Point", "coordinates": [1]}}]}`,
+			expectedErr: NotValidPointCoordinatesErr,
+		},
+		{
+			name:        "empty multipoint",
+			data:        `{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {}, "radius": 5, "geometry": {"type": "MultiPoint", "coordinates": []}}]}`,
+			expectedErr: NotValidMultiPointCoordinatesErr,
+		},
+		{
+			name:        "one point line",
+			data:        `{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {}, "radius": 5, "geometry": {"type": "MultiLineString", "coordinates": [[[1, 2], [3, 4]], [[1, 2]]]}}]}`,
+			expectedErr: NotValidMultiLineStringCoordinatesErr,
+		},
+		{
+			name:        "empty geometry collection",
+			data:        `{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {}, "geometry": {"type": "GeometryCollection", "geometries": []}}]}`,
+			expectedErr: GeometriesIsRequiredErr,
+		},
+		{
+			name:        "nested geometry collection",
+			data:        `{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {}, "radius": 5, "geometry": {"type": "GeometryCollection", "geometries": [{"type": "GeometryCollection", "geometries": [` + point + `]}]}}]}`,
+			mode:        dto.GeoJSONStrict,
+			expectedErr: NestedGeometryCollectionErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode := tt.mode
+			if mode == "" {
+				mode = dto.GeoJSONLenient
+			}
+			_, err := parseFeatureCollection(t, tt.data, mode)
+			require.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestFeatureCollection_CRS(t *testing.T) {
+	const polygon = `{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [500000, 0], [500000, 500000], [0, 0]]]}}`
+
+	featureCollection, err := parseFeatureCollection(t, `{
+		"type": "FeatureCollection",
+		"title": "parks",
+		"crs": {"type": "name", "properties": {"name": "urn:ogc:def:crs:EPSG::3857"}},
+		"features": [`+polygon+`]
+	}`, dto.GeoJSONStrict)
+	require.NoError(t, err)
+	require.Equal(t, 3857, featureCollection.CRS())
+	require.Equal(t, dto.ForeignMembers{"title": json.RawMessage(`"parks"`)}, featureCollection.ForeignMembers)
+	require.Empty(t, featureCollection.Validate())
+
+	require.NoError(t, featureCollection.DeclareCRS(3857))
+	require.ErrorIs(t, featureCollection.DeclareCRS(32633), dto.ErrConflictingCRS)
+
+	featureCollection, err = parseFeatureCollection(t, `{"type": "FeatureCollection", "features": [`+polygon+`]}`, dto.GeoJSONLenient)
+	require.NoError(t, err)
+	require.Equal(t, dto.WGS84, featureCollection.CRS())
+	require.NotEmpty(t, featureCollection.Validate())
+	require.NoError(t, featureCollection.DeclareCRS(32633))
+	require.Equal(t, 32633, featureCollection.CRS())
+
+	_, err = parseFeatureCollection(t, `{"type": "FeatureCollection", "crs": {"type": "name", "properties": {"name": "web mercator"}}, "features": [`+polygon+`]}`, dto.GeoJSONLenient)
+	require.ErrorIs(t, err, dto.ErrInvalidCRS)
+}
@@ -2,6 +2,7 @@ package geojson
 
 import (
 	"database/sql"
+	"fmt"
 
 	"github.com/twpayne/go-geom"
 	"github.com/twpayne/go-geom/encoding/ewkb"
@@ -9,6 +10,7 @@ import (
 
 type PostgisGeometry interface {
 	ToEwkb() sql.Scanner
+	Geom() geom.T
 }
 
 type PostgisMultiPolygon struct {
@@ -23,6 +25,133 @@ func (p *PostgisPolygon) ToEwkb() sql.Scanner {
 	return &ewkb.Polygon{Polygon: &p.Polygon}
 }
 
+func (p *PostgisPolygon) Geom() geom.T {
+	return &p.Polygon
+}
+
 func (mp *PostgisMultiPolygon) ToEwkb() sql.Scanner {
 	return &ewkb.MultiPolygon{MultiPolygon: &mp.MultiPolygon}
 }
+
+func (mp *PostgisMultiPolygon) Geom() geom.T {
+	return &mp.MultiPolygon
+}
+
+type PostgisPoint struct {
+	geom.Point
+}
+
+func (p *PostgisPoint) ToEwkb() sql.Scanner {
+	return &ewkb.Point{Point: &p.Point}
+}
+
+func (p *PostgisPoint) Geom() geom.T {
+	return &p.Point
+}
+
+type PostgisMultiPoint struct {
+	geom.MultiPoint
+}
+
+func (mp *PostgisMultiPoint) ToEwkb() sql.Scanner {
+	return &ewkb.MultiPoint{MultiPoint: &mp.MultiPoint}
+}
+
+func (mp *PostgisMultiPoint) Geom() geom.T {
+	return &mp.MultiPoint
+}
+
+type PostgisLineString struct {
+	geom.LineString
+}
+
+func (ls *PostgisLineString) ToEwkb() sql.Scanner {
+	return &ewkb.LineString{LineString: &ls.LineString}
+}
+
+func (ls *PostgisLineString) Geom() geom.T {
+	return &ls.LineString
+}
+
+type PostgisMultiLineString struct {
+	geom.MultiLineString
+}
+
+func (mls *PostgisMultiLineString) ToEwkb() sql.Scanner {
+	return &ewkb.MultiLineString{MultiLineString: &mls.MultiLineString}
+}
+
+func (mls *PostgisMultiLineString) Geom() geom.T {
+	return &mls.MultiLineString
+}
+
+type PostgisGeometryCollection struct {
+	geom.GeometryCollection
+}
+
+func (gc *PostgisGeometryCollection) ToEwkb() sql.Scanner {
+	return &ewkb.GeometryCollection{GeometryCollection: &gc.GeometryCollection}
+}
+
+func (gc *PostgisGeometryCollection) Geom() geom.T {
+	return &gc.GeometryCollection
+}
+
+// NewPostgisGeometry wraps a geometry, e.g. one repaired by PostGIS.
+func NewPostgisGeometry(g geom.T) (PostgisGeometry, error) {
+	switch g := g.(type) {
+	case *geom.Point:
+		return &PostgisPoint{Point: *g}, nil
+	case *geom.MultiPoint:
+		return &PostgisMultiPoint{MultiPoint: *g}, nil
+	case *geom.LineString:
+		return &PostgisLineString{LineString: *g}, nil
+	case *geom.MultiLineString:
+		return &PostgisMultiLineString{MultiLineString: *g}, nil
+	case *geom.Polygon:
+		return &PostgisPolygon{Polygon: *g}, nil
+	case *geom.MultiPolygon:
+		return &PostgisMultiPolygon{MultiPolygon: *g}, nil
+	case *geom.GeometryCollection:
+		return &PostgisGeometryCollection{GeometryCollection: *g}, nil
+	}
+	return nil, UnsupportedGeometryTypeErr{T: GeometryType(g)}
+}
+
+// IsAreal reports whether the geometry has polygons only. Points and lines are buffered
+// by the feature radius on save, as zones are stored as areal geometries.
+func IsAreal(g geom.T) bool {
+	switch g := g.(type) {
+	case *geom.Polygon, *geom.MultiPolygon:
+		return true
+	case *geom.GeometryCollection:
+		for i := 0; i < g.NumGeoms(); i++ {
+			if !IsAreal(g.Geom(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// GeometryType is the GeoJSON type of the geometry.
+func GeometryType(g geom.T) string {
+	switch g.(type) {
+	case *geom.Point:
+		return "Point"
+	case *geom.MultiPoint:
+		return "MultiPoint"
+	case *geom.LineString:
+		return "LineString"
+	case *geom.MultiLineString:
+		return "MultiLineString"
+	case *geom.Polygon:
+		return "Polygon"
+	case *geom.MultiPolygon:
+		return "MultiPolygon"
+	case *geom.GeometryCollection:
+		return "GeometryCollection"
+	}
+	return fmt.Sprintf("%T", g)
+}
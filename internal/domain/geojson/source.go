@@ -0,0 +1,36 @@
+package geojson
+
+// Source yields feature collections one by one, e.g. the lines of an import as they
+// are read, so they need not be held at once. Like pgx.CopyFromSource, Next reports
+// whether there is a feature collection and Err why the source stopped, nil at its end.
+type Source interface {
+	Next() bool
+	FeatureCollection() FeatureCollection
+	Err() error
+}
+
+// NewSliceSource yields the feature collections of a slice.
+func NewSliceSource(featureCollections ...FeatureCollection) Source {
+	return &sliceSource{featureCollections: featureCollections, i: -1}
+}
+
+type sliceSource struct {
+	featureCollections []FeatureCollection
+	i                  int
+}
+
+func (s *sliceSource) Next() bool {
+	if s.i+1 >= len(s.featureCollections) {
+		return false
+	}
+	s.i++
+	return true
+}
+
+func (s *sliceSource) FeatureCollection() FeatureCollection {
+	return s.featureCollections[s.i]
+}
+
+func (s *sliceSource) Err() error {
+	return nil
+}
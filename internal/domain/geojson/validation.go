@@ -0,0 +1,306 @@
+package geojson
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/twpayne/go-geom"
+	"github.com/twpayne/go-geom/xy"
+	"github.com/twpayne/go-geom/xy/location"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+// The messages PostGIS fails to build or validate such geometries with.
+const (
+	TooFewPointsMessage     = "Polygon must have at least four points in each ring"
+	NotClosedMessage        = "geometry contains non-closed rings"
+	HoleOutsideShellMessage = "Hole lies outside shell"
+)
+
+const (
+	NotFiniteMessage         = "coordinate is not a finite number"
+	OutOfRangeMessage        = "coordinate is out of the lon/lat range"
+	DuplicateVertexMessage   = "vertex repeats the previous one"
+	ShellWindingOrderMessage = "exterior ring is not counterclockwise"
+	HoleWindingOrderMessage  = "interior ring is not clockwise"
+)
+
+const maxLon, maxLat = 180.0, 90.0
+
+// GeometryPointer is the JSON pointer to the geometry of the feature-th feature or,
+// given the indexes, to its coordinates, e.g. /features/3/geometry/coordinates/0/5.
+func GeometryPointer(feature int, indexes ...int) string {
+	return partPointer(feature, nil, indexes...)
+}
+
+// partPointer is GeometryPointer of a geometry inside GeometryCollections, collection holds
+// its positions among their geometries, e.g. /features/3/geometry/geometries/1/coordinates/0/5.
+func partPointer(feature int, collection []int, indexes ...int) string {
+	pointer := fmt.Sprintf("/features/%d/geometry", feature)
+	for _, i := range collection {
+		pointer += "/geometries/" + strconv.Itoa(i)
+	}
+	if len(indexes) == 0 {
+		return pointer
+	}
+	elements := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		elements = append(elements, strconv.Itoa(i))
+	}
+	return pointer + "/coordinates/" + strings.Join(elements, "/")
+}
+
+// Validate checks the geometries without the database: rings are closed and have at least
+// four points, coordinates are finite, lon/lat in EPSG:4326, and holes lie inside their shells.
+// A zone with such errors is rejected, the errors are ordered by feature and coordinate.
+func (fc *FeatureCollection) Validate() []dto.GeometryError {
+	result := make([]dto.GeometryError, 0)
+	lonLat := fc.CRS() == dto.WGS84
+	for i, feature := range fc.Features {
+		result = append(result, geometryErrors(i, feature.Geometry.Geom(), nil, lonLat)...)
+	}
+	return result
+}
+
+// geometryErrors validates the polygons of g as Validate does and the coordinates of its points and lines,
+// lonLat tells coordinates are checked to be in the lon/lat range.
+func geometryErrors(feature int, g geom.T, collection []int, lonLat bool) []dto.GeometryError {
+	result := make([]dto.GeometryError, 0)
+	switch g := g.(type) {
+	case *geom.GeometryCollection:
+		for i := 0; i < g.NumGeoms(); i++ {
+			result = append(result, geometryErrors(feature, g.Geom(i), withIndex(collection, i), lonLat)...)
+		}
+	case *geom.Point:
+		if geometryErr, ok := coordinateError(feature, g.Coords(), partPointer(feature, collection)+"/coordinates", lonLat); ok {
+			result = append(result, geometryErr)
+		}
+	case *geom.MultiPoint:
+		result = append(result, coordinatesErrors(feature, g.Coords(), collection, lonLat)...)
+	case *geom.LineString:
+		result = append(result, coordinatesErrors(feature, g.Coords(), collection, lonLat)...)
+	case *geom.MultiLineString:
+		for i := 0; i < g.NumLineStrings(); i++ {
+			result = append(result, coordinatesErrors(feature, g.LineString(i).Coords(), collection, lonLat, i)...)
+		}
+	default:
+		for _, p := range polygons(g, collection) {
+			result = append(result, polygonErrors(feature, p, lonLat)...)
+		}
+	}
+	return result
+}
+
+// Warnings reports what does not prevent saving a geometry: duplicate consecutive vertices
+// and rings not following the RFC 7946 right-hand rule, which parsers should not reject.
+func (fc *FeatureCollection) Warnings() []dto.GeometryError {
+	result := make([]dto.GeometryError, 0)
+	for i, feature := range fc.Features {
+		for _, p := range polygons(feature.Geometry.Geom(), nil) {
+			result = append(result, polygonWarnings(i, p)...)
+		}
+	}
+	return result
+}
+
+// polygonWithIndex is a polygon of a geometry, index is its position among the
+// MultiPolygon polygons and is nil for a Polygon. collection is its position
+// among GeometryCollection geometries, if any.
+type polygonWithIndex struct {
+	*geom.Polygon
+	collection []int
+	index      []int
+}
+
+func (p polygonWithIndex) pointer(feature int, indexes ...int) string {
+	return partPointer(feature, p.collection, append(append([]int(nil), p.index...), indexes...)...)
+}
+
+func polygonErrors(feature int, p polygonWithIndex, lonLat bool) []dto.GeometryError {
+	result := make([]dto.GeometryError, 0)
+	valid := true
+	for i := 0; i < p.NumLinearRings(); i++ {
+		errs := ringErrors(feature, p, i, lonLat)
+		valid = valid && len(errs) == 0
+		result = append(result, errs...)
+	}
+	if !valid || p.NumLinearRings() == 0 {
+		return result
+	}
+
+	shell := p.LinearRing(0).FlatCoords()
+	for i := 1; i < p.NumLinearRings(); i++ {
+		hole := p.LinearRing(i)
+		for j := 0; j < hole.NumCoords(); j++ {
+			c := hole.Coord(j)
+			if xy.LocatePointInRing(p.Layout(), c, shell) == location.Exterior {
+				result = append(result, dto.GeometryError{
+					Feature:  feature,
+					Reason:   dto.GeometryErrorHoleOutsideShell,
+					Message:  HoleOutsideShellMessage,
+					Location: &dto.Point{Lon: c.X(), Lat: c.Y()},
+					Path:     p.pointer(feature, i, j),
+				})
+				break
+			}
+		}
+	}
+	return result
+}
+
+// ringErrors checks the coordinates of the ring and what it needs to be built at all.
+// Ring errors are reported at the first point of the ring.
+func ringErrors(feature int, p polygonWithIndex, ringIndex int, lonLat bool) []dto.GeometryError {
+	result := make([]dto.GeometryError, 0)
+	ring := p.LinearRing(ringIndex)
+	n := ring.NumCoords()
+	if n == 0 {
+		return result
+	}
+
+	finite := true
+	for i := 0; i < n; i++ {
+		if geometryErr, ok := coordinateError(feature, ring.Coord(i), p.pointer(feature, ringIndex, i), lonLat); ok {
+			finite = finite && geometryErr.Reason != dto.GeometryErrorNotFinite
+			result = append(result, geometryErr)
+		}
+	}
+
+	first := ring.Coord(0)
+	ringErr := dto.GeometryError{Feature: feature, Path: p.pointer(feature, ringIndex)}
+	if finite {
+		ringErr.Location = &dto.Point{Lon: first.X(), Lat: first.Y()}
+	}
+	switch {
+	case n < 4:
+		ringErr.Reason, ringErr.Message = dto.GeometryErrorTooFewPoints, TooFewPointsMessage
+	case !isClosed(ring):
+		ringErr.Reason, ringErr.Message = dto.GeometryErrorRingNotClosed, NotClosedMessage
+	default:
+		return result
+	}
+	return append(result, ringErr)
+}
+
+// coordinatesErrors checks the coordinates of a line or points, indexes is the position of the line.
+func coordinatesErrors(feature int, coords []geom.Coord, collection []int, lonLat bool, indexes ...int) []dto.GeometryError {
+	result := make([]dto.GeometryError, 0)
+	for i, c := range coords {
+		if geometryErr, ok := coordinateError(feature, c, partPointer(feature, collection, withIndex(indexes, i)...), lonLat); ok {
+			result = append(result, geometryErr)
+		}
+	}
+	return result
+}
+
+// coordinateError reports a coordinate that is not finite or, given lonLat, not a lon/lat.
+func coordinateError(feature int, c geom.Coord, path string, lonLat bool) (dto.GeometryError, bool) {
+	switch {
+	case !isFinite(c.X()) || !isFinite(c.Y()):
+		return dto.GeometryError{
+			Feature: feature,
+			Reason:  dto.GeometryErrorNotFinite,
+			Message: NotFiniteMessage,
+			Path:    path,
+		}, true
+	case lonLat && (math.Abs(c.X()) > maxLon || math.Abs(c.Y()) > maxLat):
+		return dto.GeometryError{
+			Feature:  feature,
+			Reason:   dto.GeometryErrorCoordinateOutOfRange,
+			Message:  OutOfRangeMessage,
+			Location: &dto.Point{Lon: c.X(), Lat: c.Y()},
+			Path:     path,
+		}, true
+	}
+	return dto.GeometryError{}, false
+}
+
+func polygonWarnings(feature int, p polygonWithIndex) []dto.GeometryError {
+	result := make([]dto.GeometryError, 0)
+	for i := 0; i < p.NumLinearRings(); i++ {
+		ring := p.LinearRing(i)
+		for j := 1; j < ring.NumCoords(); j++ {
+			previous, c := ring.Coord(j-1), ring.Coord(j)
+			if previous.X() == c.X() && previous.Y() == c.Y() {
+				result = append(result, dto.GeometryError{
+					Feature:  feature,
+					Reason:   dto.GeometryErrorDuplicateVertex,
+					Message:  DuplicateVertexMessage,
+					Location: &dto.Point{Lon: c.X(), Lat: c.Y()},
+					Path:     p.pointer(feature, i, j),
+				})
+			}
+		}
+
+		if !isClosed(ring) {
+			continue
+		}
+		area := signedArea(ring)
+		if area == 0 || math.IsNaN(area) || (i == 0) == (area > 0) {
+			continue
+		}
+		warning := dto.GeometryError{
+			Feature:  feature,
+			Reason:   dto.GeometryErrorWindingOrder,
+			Message:  ShellWindingOrderMessage,
+			Location: &dto.Point{Lon: ring.Coord(0).X(), Lat: ring.Coord(0).Y()},
+			Path:     p.pointer(feature, i),
+		}
+		if i > 0 {
+			warning.Message = HoleWindingOrderMessage
+		}
+		result = append(result, warning)
+	}
+	return result
+}
+
+// signedArea is positive for counterclockwise rings.
+func signedArea(ring *geom.LinearRing) float64 {
+	var area float64
+	for i := 1; i < ring.NumCoords(); i++ {
+		a, b := ring.Coord(i-1), ring.Coord(i)
+		area += a.X()*b.Y() - b.X()*a.Y()
+	}
+	return area / 2
+}
+
+func isClosed(ring *geom.LinearRing) bool {
+	n := ring.NumCoords()
+	if n < 4 {
+		return false
+	}
+	first, last := ring.Coord(0), ring.Coord(n-1)
+	return first.X() == last.X() && first.Y() == last.Y()
+}
+
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// polygons returns the polygons of g, including the ones of its GeometryCollection geometries.
+func polygons(g geom.T, collection []int) []polygonWithIndex {
+	switch g := g.(type) {
+	case *geom.Polygon:
+		return []polygonWithIndex{{Polygon: g, collection: collection}}
+	case *geom.MultiPolygon:
+		result := make([]polygonWithIndex, 0, g.NumPolygons())
+		for i := 0; i < g.NumPolygons(); i++ {
+			result = append(result, polygonWithIndex{Polygon: g.Polygon(i), collection: collection, index: []int{i}})
+		}
+		return result
+	case *geom.GeometryCollection:
+		result := make([]polygonWithIndex, 0)
+		for i := 0; i < g.NumGeoms(); i++ {
+			result = append(result, polygons(g.Geom(i), withIndex(collection, i))...)
+		}
+		return result
+	}
+	return nil
+}
+
+func withIndex(indexes []int, i int) []int {
+	return append(append([]int(nil), indexes...), i)
+}
@@ -0,0 +1,96 @@
+package geojson
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-geom"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+func featureCollectionOf(t *testing.T, features ...dto.FeatureJSON) FeatureCollection {
+	var featureCollection FeatureCollection
+	err := featureCollection.FromFeatureCollectionJSON(dto.FeatureCollectionJSON{Type: "FeatureCollection", Features: features})
+	require.NoError(t, err)
+	return featureCollection
+}
+
+func featureOf(geometryType, coordinates string) dto.FeatureJSON {
+	raw := json.RawMessage(coordinates)
+	return dto.FeatureJSON{Type: "Feature", Geometry: dto.FeatureGeometryJSON{Type: geometryType, Coordinates: &raw}}
+}
+
+func TestFeatureCollection_Validate(t *testing.T) {
+	featureCollection := featureCollectionOf(t,
+		featureOf("Polygon", `[[[0, 0], [1, 0], [1, 1], [0, 0]]]`),
+		featureOf("Polygon", `[[[0, 0], [0, 1], [0, 0]]]`),
+		featureOf("MultiPolygon", `[[[[0, 0], [1, 0], [1, 1], [0, 0]]], [[[5, 5], [5, 6], [6, 6], [6, 5]]]]`),
+		featureOf("Polygon", `[[[0, 0], [4, 0], [4, 4], [0, 0]], [[1, 1], [1, 2], [2, 2]]]`),
+		featureOf("Polygon", `[[[179, 0], [181, 0], [181, 1], [179, 0]]]`),
+		featureOf("Polygon", `[[[0, 0], [4, 0], [4, 4], [0, 4], [0, 0]], [[1, 1], [2, 1], [5, 5], [1, 1]]]`),
+	)
+
+	require.Equal(t, []dto.GeometryError{
+		{Feature: 1, Reason: dto.GeometryErrorTooFewPoints, Message: TooFewPointsMessage, Location: &dto.Point{Lon: 0, Lat: 0}, Path: "/features/1/geometry/coordinates/0"},
+		{Feature: 2, Reason: dto.GeometryErrorRingNotClosed, Message: NotClosedMessage, Location: &dto.Point{Lon: 5, Lat: 5}, Path: "/features/2/geometry/coordinates/1/0"},
+		{Feature: 3, Reason: dto.GeometryErrorTooFewPoints, Message: TooFewPointsMessage, Location: &dto.Point{Lon: 1, Lat: 1}, Path: "/features/3/geometry/coordinates/1"},
+		{Feature: 4, Reason: dto.GeometryErrorCoordinateOutOfRange, Message: OutOfRangeMessage, Location: &dto.Point{Lon: 181, Lat: 0}, Path: "/features/4/geometry/coordinates/0/1"},
+		{Feature: 4, Reason: dto.GeometryErrorCoordinateOutOfRange, Message: OutOfRangeMessage, Location: &dto.Point{Lon: 181, Lat: 1}, Path: "/features/4/geometry/coordinates/0/2"},
+		{Feature: 5, Reason: dto.GeometryErrorHoleOutsideShell, Message: HoleOutsideShellMessage, Location: &dto.Point{Lon: 5, Lat: 5}, Path: "/features/5/geometry/coordinates/1/2"},
+	}, featureCollection.Validate())
+}
+
+func TestFeatureCollection_ValidateGeometryCollection(t *testing.T) {
+	radius := 10.0
+	featureCollection := featureCollectionOf(t,
+		dto.FeatureJSON{
+			Type:       "Feature",
+			Properties: map[string]interface{}{},
+			Radius:     &radius,
+			Geometry: dto.FeatureGeometryJSON{Type: "GeometryCollection", Geometries: []dto.FeatureGeometryJSON{
+				featureOf("LineString", `[[0, 0], [200, 0]]`).Geometry,
+				featureOf("Polygon", `[[[0, 0], [1, 0], [1, 1], [0, 1]]]`).Geometry,
+				featureOf("Point", `[0, 91]`).Geometry,
+			}},
+		},
+	)
+
+	require.Equal(t, []dto.GeometryError{
+		{Feature: 0, Reason: dto.GeometryErrorCoordinateOutOfRange, Message: OutOfRangeMessage, Location: &dto.Point{Lon: 200, Lat: 0}, Path: "/features/0/geometry/geometries/0/coordinates/1"},
+		{Feature: 0, Reason: dto.GeometryErrorRingNotClosed, Message: NotClosedMessage, Location: &dto.Point{Lon: 0, Lat: 0}, Path: "/features/0/geometry/geometries/1/coordinates/0"},
+		{Feature: 0, Reason: dto.GeometryErrorCoordinateOutOfRange, Message: OutOfRangeMessage, Location: &dto.Point{Lon: 0, Lat: 91}, Path: "/features/0/geometry/geometries/2/coordinates"},
+	}, featureCollection.Validate())
+}
+
+func TestFeatureCollection_ValidateNotFinite(t *testing.T) {
+	polygon := geom.NewPolygonFlat(geom.XY, []float64{0, 0, math.NaN(), 0, 1, math.Inf(1), 0, 0}, []int{8})
+	featureCollection := FeatureCollection{Features: []*Feature{{Geometry: &PostgisPolygon{Polygon: *polygon}}}}
+
+	require.Equal(t, []dto.GeometryError{
+		{Feature: 0, Reason: dto.GeometryErrorNotFinite, Message: NotFiniteMessage, Path: "/features/0/geometry/coordinates/0/1"},
+		{Feature: 0, Reason: dto.GeometryErrorNotFinite, Message: NotFiniteMessage, Path: "/features/0/geometry/coordinates/0/2"},
+	}, featureCollection.Validate())
+}
+
+func TestFeatureCollection_Warnings(t *testing.T) {
+	featureCollection := featureCollectionOf(t,
+		featureOf("Polygon", `[[[0, 0], [4, 0], [4, 4], [0, 4], [0, 0]], [[1, 1], [1, 2], [2, 2], [1, 1]]]`),
+		featureOf("Polygon", `[[[0, 0], [0, 1], [1, 1], [1, 1], [0, 0]]]`),
+		featureOf("MultiPolygon", `[[[[0, 0], [1, 0], [1, 1], [0, 0]]], [[[5, 5], [6, 5], [6, 6], [6, 6], [5, 5]], [[5.2, 5.1], [5.8, 5.1], [5.8, 5.7], [5.2, 5.1]]]]`),
+	)
+
+	require.Equal(t, []dto.GeometryError{
+		{Feature: 1, Reason: dto.GeometryErrorDuplicateVertex, Message: DuplicateVertexMessage, Location: &dto.Point{Lon: 1, Lat: 1}, Path: "/features/1/geometry/coordinates/0/3"},
+		{Feature: 1, Reason: dto.GeometryErrorWindingOrder, Message: ShellWindingOrderMessage, Location: &dto.Point{Lon: 0, Lat: 0}, Path: "/features/1/geometry/coordinates/0"},
+		{Feature: 2, Reason: dto.GeometryErrorDuplicateVertex, Message: DuplicateVertexMessage, Location: &dto.Point{Lon: 6, Lat: 6}, Path: "/features/2/geometry/coordinates/1/0/3"},
+		{Feature: 2, Reason: dto.GeometryErrorWindingOrder, Message: HoleWindingOrderMessage, Location: &dto.Point{Lon: 5.2, Lat: 5.1}, Path: "/features/2/geometry/coordinates/1/1"},
+	}, featureCollection.Warnings())
+}
+
+func TestGeometryPointer(t *testing.T) {
+	require.Equal(t, "/features/3/geometry", GeometryPointer(3))
+	require.Equal(t, "/features/3/geometry/coordinates/0/5", GeometryPointer(3, 0, 5))
+}
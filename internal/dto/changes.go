@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DefaultZoneChangesLimit = 100
+	MaxZoneChangesLimit     = 1000
+)
+
+var ErrInvalidSequence = errors.New("invalid sequence number")
+
+type ZoneChangeType string
+
+const (
+	ZoneChangeCreated ZoneChangeType = "created"
+	ZoneChangeUpdated ZoneChangeType = "updated"
+	ZoneChangeDeleted ZoneChangeType = "deleted"
+)
+
+// WebhookEventType returns the webhook event published for the change.
+func (t ZoneChangeType) WebhookEventType() WebhookEventType {
+	switch t {
+	case ZoneChangeCreated:
+		return WebhookEventZoneCreated
+	case ZoneChangeDeleted:
+		return WebhookEventZoneDeleted
+	default:
+		return WebhookEventZoneUpdated
+	}
+}
+
+// ZoneChange is an entry of the zone change log. Seq grows in commit order,
+// so a consumer may resume from the last seen Seq without missing changes.
+type ZoneChange struct {
+	Seq       int64          `json:"seq"`
+	Type      ZoneChangeType `json:"type"`
+	ZoneId    int            `json:"zone_id"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+type ZoneChangesOut struct {
+	Changes []ZoneChange `json:"changes"`
+	// LastSeq is the sequence to resume from, it equals the requested one when there are no changes.
+	LastSeq int64 `json:"last_seq"`
+}
@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"errors"
+	"time"
+)
+
+// MaxTolerance limits the tolerance to GPS jitter scale, in meters.
+const MaxTolerance = 1000
+
+var (
+	ErrInvalidPredicate = errors.New("invalid predicate")
+	ErrInvalidTolerance = errors.New("invalid tolerance")
+)
+
+type Predicate string
+
+const (
+	PredicateContains   Predicate = "contains"
+	PredicateCovers     Predicate = "covers"
+	PredicateIntersects Predicate = "intersects"
+)
+
+// Position classifies a point against a zone boundary.
+type Position string
+
+const (
+	PositionInside  Position = "inside"
+	PositionOnEdge  Position = "on_edge"
+	PositionOutside Position = "outside"
+)
+
+// ContainsOptions selects the spatial predicate for contains queries. A point
+// within Tolerance meters of a zone matches it whatever the predicate is and is
+// classified as on the edge when it is that close to the boundary. Zones are
+// evaluated as they were at At when it is set, and zone schedules are evaluated
+// at At or at the time of the query.
+type ContainsOptions struct {
+	Predicate Predicate  `json:"predicate,omitempty"`
+	Tolerance float64    `json:"tolerance,omitempty"`
+	At        *time.Time `json:"at,omitempty"`
+}
+
+func (o ContainsOptions) Validate() error {
+	switch o.Predicate {
+	case "", PredicateContains, PredicateCovers, PredicateIntersects:
+	default:
+		return ErrInvalidPredicate
+	}
+	if o.Tolerance < 0 || o.Tolerance > MaxTolerance {
+		return ErrInvalidTolerance
+	}
+	return nil
+}
+
+// PredicateOrDefault keeps st_contains for requests without a predicate.
+func (o ContainsOptions) PredicateOrDefault() Predicate {
+	if o.Predicate == "" {
+		return PredicateContains
+	}
+	return o.Predicate
+}
+
+// TimeOrNow is the time zone schedules are evaluated at.
+func (o ContainsOptions) TimeOrNow() time.Time {
+	if o.At == nil {
+		return time.Now()
+	}
+	return *o.At
+}
@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainsOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        ContainsOptions
+		expectedErr error
+	}{
+		{name: "defaults", opts: ContainsOptions{}},
+		{name: "covers with tolerance", opts: ContainsOptions{Predicate: PredicateCovers, Tolerance: 15}},
+		{name: "intersects", opts: ContainsOptions{Predicate: PredicateIntersects}},
+		{name: "unknown predicate", opts: ContainsOptions{Predicate: "within"}, expectedErr: ErrInvalidPredicate},
+		{name: "negative tolerance", opts: ContainsOptions{Tolerance: -1}, expectedErr: ErrInvalidTolerance},
+		{name: "tolerance too big", opts: ContainsOptions{Tolerance: MaxTolerance + 1}, expectedErr: ErrInvalidTolerance},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, tt.opts.Validate(), tt.expectedErr)
+		})
+	}
+}
+
+func TestContainsOptions_PredicateOrDefault(t *testing.T) {
+	require.Equal(t, PredicateContains, ContainsOptions{}.PredicateOrDefault())
+	require.Equal(t, PredicateCovers, ContainsOptions{Predicate: PredicateCovers}.PredicateOrDefault())
+}
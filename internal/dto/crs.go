@@ -0,0 +1,95 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WGS84 is the SRID of EPSG:4326, zones are stored and returned in it unless another crs is requested.
+const WGS84 = 4326
+
+// CRSMemberName is the legacy GeoJSON member declaring the crs of a FeatureCollection.
+const CRSMemberName = "crs"
+
+var (
+	ErrInvalidCRS     = errors.New("invalid crs, EPSG:<code> expected")
+	ErrUnknownCRS     = errors.New("unknown crs")
+	ErrConflictingCRS = errors.New("crs does not match the crs member")
+)
+
+// The prefixes of the EPSG crs names, the code follows them.
+var epsgPrefixes = []string{
+	"EPSG:",
+	"URN:OGC:DEF:CRS:EPSG:",
+	"HTTP://WWW.OPENGIS.NET/DEF/CRS/EPSG/",
+	"HTTPS://WWW.OPENGIS.NET/DEF/CRS/EPSG/",
+}
+
+// The names of OGC CRS84, it is EPSG:4326 with the lon/lat axis order GeoJSON uses.
+var crs84Names = []string{
+	"CRS84",
+	"OGC:CRS84",
+	"URN:OGC:DEF:CRS:OGC:1.3:CRS84",
+	"URN:OGC:DEF:CRS:OGC::CRS84",
+	"HTTP://WWW.OPENGIS.NET/DEF/CRS/OGC/1.3/CRS84",
+	"HTTPS://WWW.OPENGIS.NET/DEF/CRS/OGC/1.3/CRS84",
+}
+
+// ParseCRS returns the SRID of a crs named as EPSG:3857, urn:ogc:def:crs:EPSG::3857,
+// http://www.opengis.net/def/crs/EPSG/0/3857 or as OGC CRS84.
+func ParseCRS(name string) (int, error) {
+	upper := strings.ToUpper(strings.TrimSpace(name))
+	for _, crs84 := range crs84Names {
+		if upper == crs84 {
+			return WGS84, nil
+		}
+	}
+	for _, prefix := range epsgPrefixes {
+		if !strings.HasPrefix(upper, prefix) {
+			continue
+		}
+		// The code follows the version of urns and urls, e.g. EPSG::3857 and EPSG/0/3857.
+		code := upper[len(prefix):]
+		code = code[strings.LastIndexAny(code, ":/")+1:]
+		srid, err := strconv.Atoi(code)
+		if err != nil || srid < 1 {
+			return 0, ErrInvalidCRS
+		}
+		return srid, nil
+	}
+	return 0, ErrInvalidCRS
+}
+
+// CRSMember is the legacy GeoJSON crs member of the 2008 specification, RFC 7946 dropped it.
+// Named crs carry the name, EPSG crs the code.
+type CRSMember struct {
+	Type       string `json:"type"`
+	Properties struct {
+		Name string `json:"name,omitempty"`
+		Code int    `json:"code,omitempty"`
+	} `json:"properties"`
+}
+
+// NewCRSMember names the crs of srid.
+func NewCRSMember(srid int) CRSMember {
+	var member CRSMember
+	member.Type = "name"
+	member.Properties.Name = fmt.Sprintf("urn:ogc:def:crs:EPSG::%d", srid)
+	return member
+}
+
+// SRID returns the SRID of the crs the member declares.
+func (m CRSMember) SRID() (int, error) {
+	switch strings.ToLower(m.Type) {
+	case "name":
+		return ParseCRS(m.Properties.Name)
+	case "epsg":
+		if m.Properties.Code < 1 {
+			return 0, ErrInvalidCRS
+		}
+		return m.Properties.Code, nil
+	}
+	return 0, ErrInvalidCRS
+}
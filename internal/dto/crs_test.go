@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCRS(t *testing.T) {
+	tests := map[string]int{
+		"EPSG:3857":                                    3857,
+		"epsg:32633":                                   32633,
+		"urn:ogc:def:crs:EPSG::3857":                   3857,
+		"urn:ogc:def:crs:EPSG:6.6:4326":                4326,
+		"http://www.opengis.net/def/crs/EPSG/0/3857":   3857,
+		"urn:ogc:def:crs:OGC:1.3:CRS84":                WGS84,
+		"http://www.opengis.net/def/crs/OGC/1.3/CRS84": WGS84,
+	}
+	for name, expected := range tests {
+		srid, err := ParseCRS(name)
+		require.NoError(t, err, name)
+		require.Equal(t, expected, srid, name)
+	}
+
+	for _, name := range []string{"", "3857", "EPSG:", "EPSG:web", "EPSG:-1", "urn:ogc:def:crs:OGC::WGS84"} {
+		_, err := ParseCRS(name)
+		require.ErrorIs(t, err, ErrInvalidCRS, name)
+	}
+}
+
+func TestCRSMember_SRID(t *testing.T) {
+	tests := []struct {
+		data        string
+		expected    int
+		expectedErr error
+	}{
+		{data: `{"type": "name", "properties": {"name": "urn:ogc:def:crs:EPSG::3857"}}`, expected: 3857},
+		{data: `{"type": "EPSG", "properties": {"code": 32633}}`, expected: 32633},
+		{data: `{"type": "link", "properties": {"href": "http://example.com/crs/42"}}`, expectedErr: ErrInvalidCRS},
+		{data: `{"type": "name", "properties": {}}`, expectedErr: ErrInvalidCRS},
+	}
+	for _, tt := range tests {
+		var member CRSMember
+		require.NoError(t, json.Unmarshal([]byte(tt.data), &member))
+		srid, err := member.SRID()
+		require.ErrorIs(t, err, tt.expectedErr, tt.data)
+		require.Equal(t, tt.expected, srid, tt.data)
+	}
+
+	srid, err := NewCRSMember(3857).SRID()
+	require.NoError(t, err)
+	require.Equal(t, 3857, srid)
+}
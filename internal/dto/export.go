@@ -0,0 +1,177 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+type ExportFormat string
+
+const (
+	// ExportFormatNDJSON writes a zone per line in the import format, so an export
+	// can be imported as it is.
+	ExportFormatNDJSON ExportFormat = "ndjson"
+	// ExportFormatGeoJSON writes a single FeatureCollection of all zone features.
+	ExportFormatGeoJSON ExportFormat = "geojson"
+)
+
+var (
+	ErrInvalidExportFormat = errors.New("invalid export format, ndjson or geojson expected")
+	ErrInvalidIdRange      = errors.New("invalid id range")
+	ErrInvalidCursor       = errors.New("invalid cursor")
+)
+
+// ZonesFilter selects zones by id range, layer and tag. Zones are ordered by id,
+// After is the id of the last zone already read and resumes the listing after it.
+// Zero values do not filter.
+type ZonesFilter struct {
+	FromId int
+	ToId   int
+	Layer  string
+	Tag    string
+	After  int
+}
+
+func (f ZonesFilter) Validate() error {
+	if f.FromId < 0 || f.ToId < 0 || (f.ToId > 0 && f.ToId < f.FromId) {
+		return ErrInvalidIdRange
+	}
+	if f.After < 0 {
+		return ErrInvalidCursor
+	}
+	if f.Layer != "" {
+		if err := ValidateLayerName(f.Layer); err != nil {
+			return err
+		}
+	}
+	if f.Tag != "" && (strings.TrimSpace(f.Tag) == "" || len(f.Tag) > maxMetadataLength) {
+		return ErrInvalidTag
+	}
+	return nil
+}
+
+// Matches reports whether the zone passes the filter.
+func (f ZonesFilter) Matches(zoneId int, metadata ZoneMetadata) bool {
+	if zoneId < f.FromId || (f.ToId > 0 && zoneId > f.ToId) || zoneId <= f.After {
+		return false
+	}
+	if f.Layer != "" && metadata.Layer != f.Layer {
+		return false
+	}
+	if f.Tag == "" {
+		return true
+	}
+	for _, tag := range metadata.Tags {
+		if tag == f.Tag {
+			return true
+		}
+	}
+	return false
+}
+
+type ExportIn struct {
+	Format ExportFormat
+	ZonesFilter
+}
+
+func (in ExportIn) Validate() error {
+	if in.Format != ExportFormatNDJSON && in.Format != ExportFormatGeoJSON {
+		return ErrInvalidExportFormat
+	}
+	return in.ZonesFilter.Validate()
+}
+
+// ExportZoneJSON is an NDJSON export line, a FeatureCollection with the zone
+// metadata and the zone id as foreign members.
+type ExportZoneJSON struct {
+	ZoneId int `json:"id"`
+	FeatureCollectionJSON
+}
+
+// ExportFeatureJSON is a feature of a GeoJSON export, the zone id is its id and
+// the feature id, if any, is its feature_id.
+type ExportFeatureJSON struct {
+	ZoneId int `json:"id"`
+	FeatureJSON
+	Metadata *ZoneMetadata `json:"metadata,omitempty"`
+}
+
+func NewExportZoneJSON(zone ZoneGeoJSON) ExportZoneJSON {
+	metadata := zone.ZoneMetadata
+	featureCollection := zone.GeoJSON
+	featureCollection.Metadata = &metadata
+	return ExportZoneJSON{ZoneId: zone.ZoneId, FeatureCollectionJSON: featureCollection}
+}
+
+func (e ExportZoneJSON) MarshalJSON() ([]byte, error) {
+	type featureCollection FeatureCollectionJSON
+	line := struct {
+		ZoneId int `json:"id"`
+		featureCollection
+	}{ZoneId: e.ZoneId, featureCollection: featureCollection(e.FeatureCollectionJSON)}
+	return marshalWithForeignMembers(line, e.ForeignMembers, featureCollectionMembers)
+}
+
+func (e *ExportZoneJSON) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &e.FeatureCollectionJSON); err != nil {
+		return err
+	}
+	var line struct {
+		ZoneId int `json:"id"`
+	}
+	if err := json.Unmarshal(data, &line); err != nil {
+		return err
+	}
+	e.ZoneId = line.ZoneId
+	return nil
+}
+
+func (e ExportFeatureJSON) MarshalJSON() ([]byte, error) {
+	feature := e.FeatureJSON
+	members := feature.ForeignMembers
+	var err error
+	if len(feature.Id) > 0 {
+		if members, err = members.With(exportFeatureIdMember, feature.Id); err != nil {
+			return nil, err
+		}
+	}
+	if e.Metadata != nil {
+		if members, err = members.With(exportMetadataMember, e.Metadata); err != nil {
+			return nil, err
+		}
+	}
+	if feature.Id, err = json.Marshal(e.ZoneId); err != nil {
+		return nil, err
+	}
+	feature.ForeignMembers = members
+	return json.Marshal(feature)
+}
+
+func (e *ExportFeatureJSON) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &e.FeatureJSON); err != nil {
+		return err
+	}
+	if len(e.Id) > 0 {
+		if err := json.Unmarshal(e.Id, &e.ZoneId); err != nil {
+			return err
+		}
+	}
+	e.Id = e.ForeignMembers[exportFeatureIdMember]
+	if metadata, ok := e.ForeignMembers[exportMetadataMember]; ok {
+		if err := json.Unmarshal(metadata, &e.Metadata); err != nil {
+			return err
+		}
+	}
+	delete(e.ForeignMembers, exportFeatureIdMember)
+	delete(e.ForeignMembers, exportMetadataMember)
+	if len(e.ForeignMembers) == 0 {
+		e.ForeignMembers = nil
+	}
+	return nil
+}
+
+const (
+	exportFeatureIdMember = "feature_id"
+	exportMetadataMember  = "metadata"
+)
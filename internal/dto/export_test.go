@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestZonesFilter_Matches(t *testing.T) {
+	metadata := ZoneMetadata{Layer: "pricing", Tags: []string{"night", "vip"}}
+	tests := []struct {
+		name     string
+		filter   ZonesFilter
+		zoneId   int
+		expected bool
+	}{
+		{name: "no filter", zoneId: 1, expected: true},
+		{name: "before range", filter: ZonesFilter{FromId: 2, ToId: 4}, zoneId: 1, expected: false},
+		{name: "range end", filter: ZonesFilter{FromId: 2, ToId: 4}, zoneId: 4, expected: true},
+		{name: "open range", filter: ZonesFilter{FromId: 2}, zoneId: 100, expected: true},
+		{name: "cursor", filter: ZonesFilter{After: 5}, zoneId: 5, expected: false},
+		{name: "layer", filter: ZonesFilter{Layer: "pricing"}, zoneId: 1, expected: true},
+		{name: "other layer", filter: ZonesFilter{Layer: DefaultLayer}, zoneId: 1, expected: false},
+		{name: "tag", filter: ZonesFilter{Tag: "vip"}, zoneId: 1, expected: true},
+		{name: "missing tag", filter: ZonesFilter{Tag: "day"}, zoneId: 1, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, tt.filter.Matches(tt.zoneId, metadata))
+		})
+	}
+}
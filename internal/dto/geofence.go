@@ -0,0 +1,88 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidDeviceId = errors.New("invalid device id")
+	ErrInvalidTime     = errors.New("invalid time")
+	ErrDeviceNotFound  = errors.New("device not found")
+	ErrStalePosition   = errors.New("position is older than the last known one")
+)
+
+type GeofenceEventType string
+
+const (
+	GeofenceEventEnter GeofenceEventType = "enter"
+	GeofenceEventExit  GeofenceEventType = "exit"
+	GeofenceEventDwell GeofenceEventType = "dwell"
+)
+
+type DevicePositionIn struct {
+	DeviceId string    `json:"device_id"`
+	ZoneIds  ZoneIds   `json:"ids"`
+	Point    Point     `json:"point"`
+	Time     time.Time `json:"time"`
+	ContainsOptions
+}
+
+func (in DevicePositionIn) Validate() error {
+	if strings.TrimSpace(in.DeviceId) == "" || len(in.DeviceId) > maxMetadataLength {
+		return ErrInvalidDeviceId
+	}
+	if len(in.ZoneIds) == 0 {
+		return EmptyIdsErr
+	}
+	if err := in.ZoneIds.Validate(); err != nil {
+		return err
+	}
+	if in.Time.IsZero() {
+		return ErrInvalidTime
+	}
+	if err := in.ContainsOptions.Validate(); err != nil {
+		return err
+	}
+	return in.Point.Validate()
+}
+
+// ZoneStay is a zone the device is currently inside.
+type ZoneStay struct {
+	ZoneId    int       `json:"id"`
+	EnteredAt time.Time `json:"entered_at"`
+	Dwelled   bool      `json:"dwelled"`
+}
+
+type DeviceState struct {
+	DeviceId string     `json:"device_id"`
+	Point    Point      `json:"point"`
+	LastSeen time.Time  `json:"last_seen"`
+	Zones    []ZoneStay `json:"zones"`
+	// Sequence is the sequence of the last event of the device.
+	Sequence int64 `json:"sequence"`
+	// Unpublished are events not published yet, oldest first.
+	Unpublished []GeofenceEvent `json:"-"`
+}
+
+type GeofenceEvent struct {
+	Type      GeofenceEventType `json:"type"`
+	DeviceId  string            `json:"device_id"`
+	Sequence  int64             `json:"sequence"`
+	ZoneId    int               `json:"zone_id"`
+	Point     Point             `json:"point"`
+	Time      time.Time         `json:"time"`
+	EnteredAt *time.Time        `json:"entered_at,omitempty"`
+}
+
+// IdempotencyKey identifies the event, it is published once however often it is sent.
+func (e GeofenceEvent) IdempotencyKey() string {
+	return fmt.Sprintf("device:%s:%d", e.DeviceId, e.Sequence)
+}
+
+type DevicePositionOut struct {
+	DeviceState
+	Events []GeofenceEvent `json:"events"`
+}
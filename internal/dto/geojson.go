@@ -1,29 +1,168 @@
 package dto
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
+	"slices"
+	"sort"
+	"time"
 )
 
 type ZoneGeoJSON struct {
-	ZoneId  int                   `json:"id"`
-	GeoJSON FeatureCollectionJSON `json:"geojson"`
+	ZoneId int `json:"id"`
+	ZoneMetadata
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+	GeoJSON   FeatureCollectionJSON `json:"geojson"`
 }
 
+// GeoJSONMode is how strictly GeoJSON input is checked against RFC 7946.
+type GeoJSONMode string
+
+const (
+	// GeoJSONLenient accepts features without a type, drops ids other than strings and numbers
+	// and ignores bboxes, they are computed on output.
+	GeoJSONLenient GeoJSONMode = "lenient"
+	// GeoJSONStrict rejects such features and ids, duplicate ids, bboxes not containing
+	// their geometries and members RFC 7946 forbids.
+	GeoJSONStrict GeoJSONMode = "strict"
+)
+
 type FeatureCollectionJSON struct {
 	Type     string        `json:"type"`
 	Features []FeatureJSON `json:"features"`
+	BBox     []float64     `json:"bbox,omitempty"`
+	// Metadata is a foreign member carrying zone attributes on create and replace.
+	Metadata *ZoneMetadata `json:"metadata,omitempty"`
+	// ForeignMembers are the other members RFC 7946 does not define, they are kept as they are.
+	ForeignMembers ForeignMembers `json:"-"`
 }
 
 type FeatureJSON struct {
-	Type       string                 `json:"type"`
+	Type string `json:"type"`
+	// Id is a string or a number, it is kept as it is.
+	Id         json.RawMessage        `json:"id,omitempty"`
 	Geometry   FeatureGeometryJSON    `json:"geometry"`
 	Properties map[string]interface{} `json:"properties"`
+	BBox       []float64              `json:"bbox,omitempty"`
+	// Radius is the buffer in meters of the points and lines of the geometry, a foreign
+	// member so that the properties are kept as they are.
+	Radius *float64 `json:"radius,omitempty"`
+	// SourceGeometry is the point or line geometry the areal geometry of the feature
+	// is buffered from, it is set on output only.
+	SourceGeometry *FeatureGeometryJSON `json:"source_geometry,omitempty"`
+	ForeignMembers ForeignMembers       `json:"-"`
 }
 
 type FeatureGeometryJSON struct {
 	Type        string           `json:"type"`
-	Coordinates *json.RawMessage `json:"coordinates"`
+	Coordinates *json.RawMessage `json:"coordinates,omitempty"`
+	// Geometries are the geometries of a GeometryCollection.
+	Geometries []FeatureGeometryJSON `json:"geometries,omitempty"`
+}
+
+// ForeignMembers are the members of a GeoJSON object other than the ones it defines.
+type ForeignMembers map[string]json.RawMessage
+
+var (
+	// featureCollectionMembers include the zone id of exports, it is not kept as a foreign member.
+	featureCollectionMembers = []string{"type", "features", "bbox", "metadata", "id"}
+	featureMembers           = []string{"type", "id", "geometry", "properties", "bbox", "radius", "source_geometry"}
+)
+
+// With returns a copy of the members with the value of the key set.
+func (m ForeignMembers) With(key string, value interface{}) (ForeignMembers, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	result := make(ForeignMembers, len(m)+1)
+	for k, v := range m {
+		result[k] = v
+	}
+	result[key] = data
+	return result, nil
+}
+
+func (fc FeatureCollectionJSON) MarshalJSON() ([]byte, error) {
+	type featureCollection FeatureCollectionJSON
+	return marshalWithForeignMembers(featureCollection(fc), fc.ForeignMembers, featureCollectionMembers)
+}
+
+func (fc *FeatureCollectionJSON) UnmarshalJSON(data []byte) error {
+	type featureCollection FeatureCollectionJSON
+	if err := json.Unmarshal(data, (*featureCollection)(fc)); err != nil {
+		return err
+	}
+	members, err := foreignMembers(data, featureCollectionMembers)
+	fc.ForeignMembers = members
+	return err
+}
+
+func (f FeatureJSON) MarshalJSON() ([]byte, error) {
+	type feature FeatureJSON
+	return marshalWithForeignMembers(feature(f), f.ForeignMembers, featureMembers)
+}
+
+func (f *FeatureJSON) UnmarshalJSON(data []byte) error {
+	type feature FeatureJSON
+	if err := json.Unmarshal(data, (*feature)(f)); err != nil {
+		return err
+	}
+	members, err := foreignMembers(data, featureMembers)
+	f.ForeignMembers = members
+	return err
+}
+
+// marshalWithForeignMembers appends the foreign members to the JSON object of v in the key order,
+// members named as the defined ones are skipped.
+func marshalWithForeignMembers(v interface{}, members ForeignMembers, defined []string) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(members) == 0 {
+		return data, err
+	}
+
+	keys := make([]string, 0, len(members))
+	for key := range members {
+		if !slices.Contains(defined, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	b.Write(data[:len(data)-1])
+	for i, key := range keys {
+		if i > 0 || len(data) > 2 {
+			b.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		if err = json.Compact(&b, members[key]); err != nil {
+			return nil, err
+		}
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+func foreignMembers(data []byte, defined []string) (ForeignMembers, error) {
+	var members ForeignMembers
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	for _, key := range defined {
+		delete(members, key)
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+	return members, nil
 }
 
 func NewFeatureCollectionJSON(r io.ReadCloser) (*FeatureCollectionJSON, error) {
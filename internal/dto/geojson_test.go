@@ -0,0 +1,74 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatureCollectionJSON_ForeignMembers(t *testing.T) {
+	const data = `{
+		"type": "FeatureCollection",
+		"title": "parks",
+		"bbox": [0, 0, 1, 1],
+		"metadata": {"layer": "parks"},
+		"features": [
+			{
+				"type": "Feature",
+				"id": "north",
+				"properties": null,
+				"geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]},
+				"source": {"name": "osm"}
+			},
+			{
+				"type": "Feature",
+				"properties": {"name": "gate"},
+				"geometry": {"type": "Point", "coordinates": [0, 0]},
+				"radius": 50
+			}
+		]
+	}`
+
+	var featureCollection FeatureCollectionJSON
+	require.NoError(t, json.Unmarshal([]byte(data), &featureCollection))
+	require.Equal(t, ForeignMembers{"title": json.RawMessage(`"parks"`)}, featureCollection.ForeignMembers)
+	require.Equal(t, []float64{0, 0, 1, 1}, featureCollection.BBox)
+	require.Equal(t, "parks", featureCollection.Metadata.Layer)
+	require.Equal(t, json.RawMessage(`"north"`), featureCollection.Features[0].Id)
+	require.Equal(t, ForeignMembers{"source": json.RawMessage(`{"name": "osm"}`)}, featureCollection.Features[0].ForeignMembers)
+	require.Equal(t, 50.0, *featureCollection.Features[1].Radius)
+	require.Empty(t, featureCollection.Features[1].ForeignMembers)
+
+	actual, err := json.Marshal(featureCollection)
+	require.NoError(t, err)
+	require.JSONEq(t, data, string(actual))
+}
+
+func TestExportFeatureJSON_FeatureId(t *testing.T) {
+	coordinates := json.RawMessage(`[[[0,0],[1,0],[1,1],[0,0]]]`)
+	feature := ExportFeatureJSON{
+		ZoneId: 3,
+		FeatureJSON: FeatureJSON{
+			Type:     "Feature",
+			Id:       json.RawMessage(`"north"`),
+			Geometry: FeatureGeometryJSON{Type: "Polygon", Coordinates: &coordinates},
+		},
+		Metadata: &ZoneMetadata{Layer: "parks"},
+	}
+
+	data, err := json.Marshal(feature)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "Feature",
+		"id": 3,
+		"feature_id": "north",
+		"metadata": {"layer": "parks"},
+		"properties": null,
+		"geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}
+	}`, string(data))
+
+	var actual ExportFeatureJSON
+	require.NoError(t, json.Unmarshal(data, &actual))
+	require.Equal(t, feature, actual)
+}
@@ -0,0 +1,90 @@
+package dto
+
+import (
+	"fmt"
+	"strings"
+)
+
+type GeometryErrorReason string
+
+const (
+	GeometryErrorSelfIntersection GeometryErrorReason = "self_intersection"
+	GeometryErrorRingNotClosed    GeometryErrorReason = "ring_not_closed"
+	GeometryErrorTooFewPoints     GeometryErrorReason = "too_few_points"
+	GeometryErrorHoleOutsideShell GeometryErrorReason = "hole_outside_shell"
+	GeometryErrorNotFinite        GeometryErrorReason = "not_finite"
+	// GeometryErrorCoordinateOutOfRange is a coordinate out of the lon/lat range.
+	GeometryErrorCoordinateOutOfRange GeometryErrorReason = "coordinate_out_of_range"
+	// GeometryErrorDuplicateVertex and GeometryErrorWindingOrder are reported as warnings only.
+	GeometryErrorDuplicateVertex GeometryErrorReason = "duplicate_vertex"
+	GeometryErrorWindingOrder    GeometryErrorReason = "winding_order"
+	// GeometryErrorInvalid is any other reason ST_IsValidDetail reports, e.g. a hole outside its shell.
+	GeometryErrorInvalid GeometryErrorReason = "invalid"
+)
+
+// GeometryErrorReasonOf maps an ST_IsValidDetail reason to a GeometryErrorReason.
+func GeometryErrorReasonOf(reason string) GeometryErrorReason {
+	switch {
+	case strings.Contains(reason, "Self-intersection"):
+		return GeometryErrorSelfIntersection
+	case strings.Contains(reason, "Too few points"):
+		return GeometryErrorTooFewPoints
+	case strings.Contains(reason, "not closed"), strings.Contains(reason, "non-closed"):
+		return GeometryErrorRingNotClosed
+	case strings.Contains(reason, "Hole lies outside shell"):
+		return GeometryErrorHoleOutsideShell
+	}
+	return GeometryErrorInvalid
+}
+
+// Repairable reports whether ST_MakeValid can fix a geometry with such an error,
+// it cannot build geometries with broken rings or coordinates.
+func (r GeometryErrorReason) Repairable() bool {
+	switch r {
+	case GeometryErrorSelfIntersection, GeometryErrorHoleOutsideShell, GeometryErrorInvalid:
+		return true
+	}
+	return false
+}
+
+// UnrepairableGeometryErrors filters the errors ST_MakeValid cannot fix.
+func UnrepairableGeometryErrors(errs []GeometryError) []GeometryError {
+	result := make([]GeometryError, 0)
+	for _, err := range errs {
+		if !err.Reason.Repairable() {
+			result = append(result, err)
+		}
+	}
+	return result
+}
+
+// GeometryError describes an invalid geometry of the Feature-th feature of a zone,
+// Location is the offending point when it is known. Path is the JSON pointer to the
+// offending part of the request, e.g. /features/3/geometry/coordinates/0/5.
+type GeometryError struct {
+	Feature  int                 `json:"feature"`
+	Reason   GeometryErrorReason `json:"reason"`
+	Message  string              `json:"message"`
+	Location *Point              `json:"location,omitempty"`
+	Path     string              `json:"path,omitempty"`
+}
+
+// GeometryValidationErr rejects a zone with invalid geometries, Errors are ordered by feature.
+type GeometryValidationErr struct {
+	Errors []GeometryError
+}
+
+func (e GeometryValidationErr) Error() string {
+	if len(e.Errors) == 0 {
+		return "invalid geometry"
+	}
+	return fmt.Sprintf("invalid geometry of feature %d: %s", e.Errors[0].Feature, e.Errors[0].Message)
+}
+
+// GeometryRepair describes a geometry fixed with ST_MakeValid, the error it had
+// and how its type changed, a self-intersecting Polygon becomes a MultiPolygon.
+type GeometryRepair struct {
+	GeometryError
+	FromType string `json:"from_type"`
+	ToType   string `json:"to_type"`
+}
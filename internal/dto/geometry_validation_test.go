@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeometryErrorReasonOf(t *testing.T) {
+	tests := map[string]GeometryErrorReason{
+		"Self-intersection":                    GeometryErrorSelfIntersection,
+		"Ring Self-intersection":               GeometryErrorSelfIntersection,
+		"Too few points in geometry component": GeometryErrorTooFewPoints,
+		"geometry contains non-closed rings":   GeometryErrorRingNotClosed,
+		"Hole lies outside shell":              GeometryErrorHoleOutsideShell,
+		"Interior is disconnected":             GeometryErrorInvalid,
+	}
+	for reason, expected := range tests {
+		require.Equal(t, expected, GeometryErrorReasonOf(reason), reason)
+	}
+
+	errs := []GeometryError{
+		{Feature: 0, Reason: GeometryErrorSelfIntersection},
+		{Feature: 1, Reason: GeometryErrorCoordinateOutOfRange},
+		{Feature: 2, Reason: GeometryErrorHoleOutsideShell},
+		{Feature: 3, Reason: GeometryErrorTooFewPoints},
+	}
+	require.Equal(t, []GeometryError{errs[1], errs[3]}, UnrepairableGeometryErrors(errs))
+
+	err := GeometryValidationErr{Errors: []GeometryError{{Feature: 2, Message: "Self-intersection"}}}
+	require.Equal(t, "invalid geometry of feature 2: Self-intersection", err.Error())
+}
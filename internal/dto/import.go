@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// MaxImportLineSize limits a single NDJSON line, that is a single zone of an import.
+	MaxImportLineSize = 16 << 20
+	// MaxImportErrors limits the line errors collected before an import is rejected.
+	MaxImportErrors = 100
+)
+
+var (
+	ErrImportLineTooLong          = errors.New("line is too long")
+	ErrDuplicateImportExternalKey = errors.New("duplicate external key in import")
+)
+
+// ImportLineError points at a rejected line of an NDJSON import, lines start at 1.
+type ImportLineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ZoneImportOut lists the ids of the imported zones in the order of the input lines.
+// An import with errors is rejected as a whole and creates no zones.
+type ZoneImportOut struct {
+	Created []int             `json:"created"`
+	Errors  []ImportLineError `json:"errors,omitempty"`
+}
+
+// ImportZoneErr rejects a zone of an import by its index in the imported zones.
+type ImportZoneErr struct {
+	Index int
+	Err   error
+}
+
+func (e ImportZoneErr) Error() string {
+	return fmt.Sprintf("zone %d: %v", e.Index, e.Err)
+}
+
+func (e ImportZoneErr) Unwrap() error {
+	return e.Err
+}
+
+// ImportZonesErr is returned by storages for the imported zones they reject, e.g. zones
+// with taken external keys or unknown layers. Nothing is imported then.
+type ImportZonesErr []ImportZoneErr
+
+func (e ImportZonesErr) Error() string {
+	return fmt.Sprintf("%d imported zones rejected", len(e))
+}
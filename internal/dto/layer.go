@@ -0,0 +1,94 @@
+package dto
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+// DefaultLayer holds zones created without a layer.
+const DefaultLayer = "default"
+
+const maxLayerNameLength = 64
+
+var (
+	ErrInvalidLayerName          = errors.New("invalid layer name")
+	ErrLayerNotFound             = errors.New("layer not found")
+	ErrLayerExists               = errors.New("layer already exists")
+	ErrLayerVersionNotFound      = errors.New("layer version not found")
+	ErrLayerVersionNotDraft      = errors.New("layer version is not a draft")
+	ErrLayerVersionNotValidated  = errors.New("layer version is not validated")
+	ErrNoPreviousLayerVersion    = errors.New("layer has no previous published version")
+	ErrLayerVersionNotValid      = errors.New("layer version is not valid anymore")
+	ErrLayerZoneMismatch         = errors.New("zone belongs to another layer")
+	ErrDuplicateLayerExternalKey = errors.New("duplicate external key in layer")
+	ErrIdsWithLayer              = errors.New("ids and layer cannot be combined")
+)
+
+// layerNameRe keeps layer names usable as path segments.
+var layerNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func ValidateLayerName(name string) error {
+	if len(name) > maxLayerNameLength || !layerNameRe.MatchString(name) {
+		return ErrInvalidLayerName
+	}
+	return nil
+}
+
+type LayerVersionStatus string
+
+const (
+	LayerVersionDraft      LayerVersionStatus = "draft"
+	LayerVersionValidated  LayerVersionStatus = "validated"
+	LayerVersionInvalid    LayerVersionStatus = "invalid"
+	LayerVersionPublished  LayerVersionStatus = "published"
+	LayerVersionSuperseded LayerVersionStatus = "superseded"
+	LayerVersionRolledBack LayerVersionStatus = "rolled_back"
+)
+
+// IsDraft reports whether the version was not published yet.
+func (s LayerVersionStatus) IsDraft() bool {
+	return s == LayerVersionDraft || s == LayerVersionValidated || s == LayerVersionInvalid
+}
+
+type Layer struct {
+	Name             string    `json:"name"`
+	PublishedVersion *int      `json:"published_version"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type LayerIn struct {
+	Name string `json:"name"`
+}
+
+func (in LayerIn) Validate() error {
+	return ValidateLayerName(in.Name)
+}
+
+// LayerDraftIn is a complete zone set of a layer. Zones are matched to the published
+// ones by external key on publish, zones without a key are recreated.
+type LayerDraftIn struct {
+	Zones []FeatureCollectionJSON `json:"zones"`
+}
+
+// LayerVersion is a dataset uploaded to a layer. Drafts are validated before they
+// are published, the published version is superseded by the next publish and is
+// rolled back to the previous superseded one.
+type LayerVersion struct {
+	Layer       string                 `json:"layer"`
+	Version     int                    `json:"version"`
+	Status      LayerVersionStatus     `json:"status"`
+	ZonesCount  int                    `json:"zones_count"`
+	Errors      []LayerValidationError `json:"errors,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	PublishedAt *time.Time             `json:"published_at,omitempty"`
+}
+
+// LayerValidationError points at the zone of the dataset, and at its feature when
+// the error is about a geometry.
+type LayerValidationError struct {
+	Zone    int    `json:"zone"`
+	Feature *int   `json:"feature,omitempty"`
+	Error   string `json:"error"`
+}
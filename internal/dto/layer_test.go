@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayerIn_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   LayerIn
+		err  error
+	}{
+		{name: "ok", in: LayerIn{Name: "delivery_zones-2"}},
+		{name: "empty", in: LayerIn{}, err: ErrInvalidLayerName},
+		{name: "path separator", in: LayerIn{Name: "a/b"}, err: ErrInvalidLayerName},
+		{name: "too long", in: LayerIn{Name: strings.Repeat("a", maxLayerNameLength+1)}, err: ErrInvalidLayerName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, tt.in.Validate(), tt.err)
+		})
+	}
+}
+
+func TestZoneContainsPointIn_ValidateLayer(t *testing.T) {
+	point := Point{Lon: 37.6, Lat: 55.7}
+	tests := []struct {
+		name string
+		in   ZoneContainsPointIn
+		err  error
+	}{
+		{name: "ids", in: ZoneContainsPointIn{ZoneIds: ZoneIds{1}, Point: point}},
+		{name: "layer", in: ZoneContainsPointIn{Layer: "delivery", Point: point}},
+		{name: "ids and layer", in: ZoneContainsPointIn{ZoneIds: ZoneIds{1}, Layer: "delivery", Point: point}, err: ErrIdsWithLayer},
+		{name: "invalid layer", in: ZoneContainsPointIn{Layer: "a b", Point: point}, err: ErrInvalidLayerName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, tt.in.Validate(), tt.err)
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package dto
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultZonesPageSize = 100
+	MaxZonesPageSize     = 1000
+)
+
+type ZonesSort string
+
+const (
+	ZonesSortId        ZonesSort = "id"
+	ZonesSortCreatedAt ZonesSort = "created_at"
+)
+
+var (
+	ErrInvalidZonesSort      = errors.New("invalid sort, id or created_at expected")
+	ErrInvalidBBox           = errors.New("invalid bbox, min_lon,min_lat,max_lon,max_lat expected")
+	ErrInvalidPropertyFilter = errors.New("invalid property filter, key:value with a scalar value expected")
+)
+
+// BBox is a lon/lat bounding box, zones intersecting it are listed.
+type BBox struct {
+	MinLon float64
+	MinLat float64
+	MaxLon float64
+	MaxLat float64
+}
+
+func (b BBox) Validate() error {
+	for _, point := range []Point{{Lon: b.MinLon, Lat: b.MinLat}, {Lon: b.MaxLon, Lat: b.MaxLat}} {
+		if err := point.Validate(); err != nil {
+			return ErrInvalidBBox
+		}
+	}
+	if b.MinLon > b.MaxLon || b.MinLat > b.MaxLat {
+		return ErrInvalidBBox
+	}
+	return nil
+}
+
+// ZonesCursor is the position of the last zone of a page in the listing order.
+// CreatedAt is set for listings sorted by creation time only.
+type ZonesCursor struct {
+	Sort      ZonesSort
+	CreatedAt time.Time
+	ZoneId    int
+}
+
+func NewZonesCursor(sort ZonesSort, zone ZoneGeoJSON) ZonesCursor {
+	cursor := ZonesCursor{Sort: sort, ZoneId: zone.ZoneId}
+	if sort == ZonesSortCreatedAt {
+		cursor.CreatedAt = zone.CreatedAt
+	}
+	return cursor
+}
+
+// String encodes the cursor as an opaque token.
+func (c ZonesCursor) String() string {
+	value := fmt.Sprintf("%s:%d", c.Sort, c.ZoneId)
+	if c.Sort == ZonesSortCreatedAt {
+		value = fmt.Sprintf("%s:%d:%d", c.Sort, c.CreatedAt.UnixNano(), c.ZoneId)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+func ParseZonesCursor(token string) (ZonesCursor, error) {
+	var cursor ZonesCursor
+
+	value, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	parts := strings.Split(string(value), ":")
+	cursor.Sort = ZonesSort(parts[0])
+	switch {
+	case cursor.Sort == ZonesSortId && len(parts) == 2:
+	case cursor.Sort == ZonesSortCreatedAt && len(parts) == 3:
+		nanos, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return cursor, ErrInvalidCursor
+		}
+		cursor.CreatedAt = time.Unix(0, nanos)
+	default:
+		return cursor, ErrInvalidCursor
+	}
+	if cursor.ZoneId, err = strconv.Atoi(parts[len(parts)-1]); err != nil || cursor.ZoneId < 1 {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// Precedes reports whether the zone is listed before the cursor or is the cursor zone itself.
+func (c ZonesCursor) Precedes(zoneId int, createdAt time.Time) bool {
+	if c.Sort == ZonesSortCreatedAt && !createdAt.Equal(c.CreatedAt) {
+		return createdAt.Before(c.CreatedAt)
+	}
+	return zoneId <= c.ZoneId
+}
+
+// ZonesListIn selects a page of zones. Properties match zones having a feature with
+// all the given property values. Zones without geometry carry metadata only.
+type ZonesListIn struct {
+	ZonesFilter
+	Properties      map[string]interface{}
+	BBox            *BBox
+	Sort            ZonesSort
+	Limit           int
+	Cursor          *ZonesCursor
+	WithoutGeometry bool
+}
+
+func (in *ZonesListIn) Validate() error {
+	if in.Sort == "" {
+		in.Sort = ZonesSortId
+	}
+	if in.Sort != ZonesSortId && in.Sort != ZonesSortCreatedAt {
+		return ErrInvalidZonesSort
+	}
+	if in.Limit == 0 {
+		in.Limit = DefaultZonesPageSize
+	}
+	if in.Limit < 0 || in.Limit > MaxZonesPageSize {
+		return ErrInvalidLimit
+	}
+	if in.Cursor != nil && in.Cursor.Sort != in.Sort {
+		return ErrInvalidCursor
+	}
+	if in.BBox != nil {
+		if err := in.BBox.Validate(); err != nil {
+			return err
+		}
+	}
+	for key, value := range in.Properties {
+		if key == "" || !isScalarProperty(value) {
+			return ErrInvalidPropertyFilter
+		}
+	}
+	return in.ZonesFilter.Validate()
+}
+
+// MatchesProperties reports whether properties have every filtered value, as jsonb
+// containment does for scalar values.
+func (in ZonesListIn) MatchesProperties(properties map[string]interface{}) bool {
+	for key, expected := range in.Properties {
+		actual, ok := properties[key]
+		if !ok || !reflect.DeepEqual(actual, expected) {
+			return false
+		}
+	}
+	return true
+}
+
+func isScalarProperty(value interface{}) bool {
+	switch value.(type) {
+	case nil, string, float64, bool:
+		return true
+	}
+	return false
+}
+
+// ZoneListItemJSON is a listed zone, GeoJSON is omitted for listings without geometry.
+type ZoneListItemJSON struct {
+	ZoneId int `json:"id"`
+	ZoneMetadata
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+	GeoJSON   *FeatureCollectionJSON `json:"geojson,omitempty"`
+}
+
+type ZonesPageOut struct {
+	Zones []ZoneListItemJSON `json:"zones"`
+	// NextCursor is set when more zones may follow, it is passed as cursor to get the next page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
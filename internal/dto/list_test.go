@@ -0,0 +1,65 @@
+package dto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestZonesCursor(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 123456000, time.UTC)
+
+	for _, cursor := range []ZonesCursor{
+		{Sort: ZonesSortId, ZoneId: 42},
+		{Sort: ZonesSortCreatedAt, CreatedAt: createdAt, ZoneId: 7},
+	} {
+		parsed, err := ParseZonesCursor(cursor.String())
+		require.NoError(t, err)
+		require.Equal(t, cursor.Sort, parsed.Sort)
+		require.Equal(t, cursor.ZoneId, parsed.ZoneId)
+		require.True(t, cursor.CreatedAt.Equal(parsed.CreatedAt))
+	}
+
+	for _, token := range []string{"", "!", "aWQ6MA", "c2l6ZTox", "Y3JlYXRlZF9hdDox"} {
+		_, err := ParseZonesCursor(token)
+		require.ErrorIs(t, err, ErrInvalidCursor, token)
+	}
+
+	cursor := ZonesCursor{Sort: ZonesSortCreatedAt, CreatedAt: createdAt, ZoneId: 7}
+	require.True(t, cursor.Precedes(9, createdAt.Add(-time.Second)))
+	require.True(t, cursor.Precedes(7, createdAt))
+	require.False(t, cursor.Precedes(8, createdAt))
+	require.False(t, cursor.Precedes(1, createdAt.Add(time.Second)))
+}
+
+func TestZonesListIn_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          ZonesListIn
+		expectedErr error
+	}{
+		{name: "defaults", in: ZonesListIn{}},
+		{name: "unknown sort", in: ZonesListIn{Sort: "name"}, expectedErr: ErrInvalidZonesSort},
+		{name: "limit too big", in: ZonesListIn{Limit: MaxZonesPageSize + 1}, expectedErr: ErrInvalidLimit},
+		{name: "cursor of another sort", in: ZonesListIn{Cursor: &ZonesCursor{Sort: ZonesSortCreatedAt, ZoneId: 1}}, expectedErr: ErrInvalidCursor},
+		{name: "reversed bbox", in: ZonesListIn{BBox: &BBox{MinLon: 1, MaxLon: 0}}, expectedErr: ErrInvalidBBox},
+		{name: "bbox out of range", in: ZonesListIn{BBox: &BBox{MinLat: -91, MaxLat: 0}}, expectedErr: ErrInvalidBBox},
+		{name: "object property", in: ZonesListIn{Properties: map[string]interface{}{"a": map[string]interface{}{}}}, expectedErr: ErrInvalidPropertyFilter},
+		{name: "empty property key", in: ZonesListIn{Properties: map[string]interface{}{"": "a"}}, expectedErr: ErrInvalidPropertyFilter},
+		{name: "invalid id range", in: ZonesListIn{ZonesFilter: ZonesFilter{FromId: 2, ToId: 1}}, expectedErr: ErrInvalidIdRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.in.Validate()
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, ZonesSortId, tt.in.Sort)
+			require.Equal(t, DefaultZonesPageSize, tt.in.Limit)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"errors"
+	"strings"
+)
+
+const maxMetadataLength = 255
+
+var (
+	ErrInvalidName        = errors.New("invalid name")
+	ErrInvalidExternalKey = errors.New("invalid external key")
+	ErrInvalidTag         = errors.New("invalid tag")
+	ErrExternalKeyExists  = errors.New("zone with this external key already exists")
+)
+
+type ZoneMetadata struct {
+	Name        string   `json:"name,omitempty"`
+	ExternalKey *string  `json:"external_key,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// Schedule limits when the zone is active for contains queries.
+	Schedule *ZoneSchedule `json:"schedule,omitempty"`
+	// Layer is DefaultLayer for zones created without it, replacing a zone
+	// without a layer keeps it in its layer.
+	Layer string `json:"layer,omitempty"`
+}
+
+func (m ZoneMetadata) Validate() error {
+	if len(m.Name) > maxMetadataLength {
+		return ErrInvalidName
+	}
+	if m.ExternalKey != nil && (strings.TrimSpace(*m.ExternalKey) == "" || len(*m.ExternalKey) > maxMetadataLength) {
+		return ErrInvalidExternalKey
+	}
+	for _, tag := range m.Tags {
+		if strings.TrimSpace(tag) == "" || len(tag) > maxMetadataLength {
+			return ErrInvalidTag
+		}
+	}
+	if m.Layer != "" {
+		if err := ValidateLayerName(m.Layer); err != nil {
+			return err
+		}
+	}
+	if m.Schedule != nil {
+		return m.Schedule.Validate()
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"errors"
+)
+
+const (
+	DefaultNearestLimit = 10
+	MaxNearestLimit     = 100
+)
+
+var ErrInvalidLimit = errors.New("invalid limit")
+
+type NearestZonesIn struct {
+	ZoneIds ZoneIds `json:"ids"`
+	Point   Point   `json:"point"`
+	Limit   int     `json:"limit"`
+}
+
+func (in *NearestZonesIn) Validate() error {
+	if in.Limit == 0 {
+		in.Limit = DefaultNearestLimit
+	}
+	if in.Limit < 0 || in.Limit > MaxNearestLimit {
+		return ErrInvalidLimit
+	}
+	if err := in.ZoneIds.Validate(); err != nil {
+		return err
+	}
+	return in.Point.Validate()
+}
+
+// NearestZoneOut describes the distance from a point to a zone boundary.
+// Distance is geodesic, in meters, and negative when the point is inside the zone.
+type NearestZoneOut struct {
+	ZoneId        int     `json:"id"`
+	Distance      float64 `json:"distance"`
+	Inside        bool    `json:"inside"`
+	BoundaryPoint Point   `json:"boundary_point"`
+}
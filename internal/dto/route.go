@@ -0,0 +1,118 @@
+package dto
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+const MaxRoutePoints = 10000
+
+var (
+	ErrInvalidRoute      = errors.New("route must have from 2 to 10000 points")
+	ErrInvalidRouteTimes = errors.New("route times must be set for every point and must not decrease")
+)
+
+type RoutePoint struct {
+	Point
+	Time *time.Time `json:"time,omitempty"`
+}
+
+type RouteCrossingIn struct {
+	ZoneIds ZoneIds      `json:"ids"`
+	Points  []RoutePoint `json:"points"`
+}
+
+func (in RouteCrossingIn) Validate() error {
+	if len(in.Points) < 2 || len(in.Points) > MaxRoutePoints {
+		return ErrInvalidRoute
+	}
+	if err := in.ZoneIds.Validate(); err != nil {
+		return err
+	}
+	timed := in.Points[0].Time != nil
+	for i, p := range in.Points {
+		if err := p.Point.Validate(); err != nil {
+			return err
+		}
+		if (p.Time != nil) != timed {
+			return ErrInvalidRouteTimes
+		}
+		if timed && i > 0 && p.Time.Before(*in.Points[i-1].Time) {
+			return ErrInvalidRouteTimes
+		}
+	}
+	return nil
+}
+
+func (in RouteCrossingIn) Track() []Point {
+	track := make([]Point, 0, len(in.Points))
+	for _, p := range in.Points {
+		track = append(track, p.Point)
+	}
+	return track
+}
+
+// RouteTimes interpolates times along a route, the lengths of its points along the route
+// are computed once.
+type RouteTimes struct {
+	points  []RoutePoint
+	lengths []float64
+}
+
+// Times returns the interpolator of the route times. Fractions are measured along the
+// route in the lon/lat plane like ST_LineLocatePoint does.
+func (in RouteCrossingIn) Times() RouteTimes {
+	lengths := make([]float64, len(in.Points))
+	for i := 1; i < len(in.Points); i++ {
+		a, b := in.Points[i-1], in.Points[i]
+		lengths[i] = lengths[i-1] + math.Hypot(b.Lon-a.Lon, b.Lat-a.Lat)
+	}
+	return RouteTimes{points: in.Points, lengths: lengths}
+}
+
+// At interpolates the time at a fraction of the route.
+// It returns nil when points carry no time.
+func (t RouteTimes) At(fraction float64) *time.Time {
+	n := len(t.points)
+	if n == 0 || t.points[0].Time == nil {
+		return nil
+	}
+	total := t.lengths[n-1]
+	if total == 0 || n == 1 {
+		at := *t.points[0].Time
+		return &at
+	}
+
+	target := math.Max(0, math.Min(1, fraction)) * total
+	// i is the end of the first segment reaching the target, the last one past the end.
+	i := min(sort.Search(n-1, func(j int) bool { return t.lengths[j+1] >= target })+1, n-1)
+	from, to := *t.points[i-1].Time, *t.points[i].Time
+	segment := t.lengths[i] - t.lengths[i-1]
+	if segment == 0 {
+		return &to
+	}
+	share := (target - t.lengths[i-1]) / segment
+	at := from.Add(time.Duration(share * float64(to.Sub(from))))
+	return &at
+}
+
+// RouteSegment is a continuous part of the route inside a zone. Fractions locate the
+// entry and exit along the route; an entry at 0 means the route starts inside the zone.
+type RouteSegment struct {
+	Entry         Point      `json:"entry"`
+	Exit          Point      `json:"exit"`
+	EntryFraction float64    `json:"entry_fraction"`
+	ExitFraction  float64    `json:"exit_fraction"`
+	EntryTime     *time.Time `json:"entry_time,omitempty"`
+	ExitTime      *time.Time `json:"exit_time,omitempty"`
+}
+
+// ZoneCrossingOut describes how a route crosses a zone. Fraction is the share
+// of the route geodesic length inside the zone.
+type ZoneCrossingOut struct {
+	ZoneId   int            `json:"id"`
+	Fraction float64        `json:"fraction"`
+	Segments []RouteSegment `json:"segments"`
+}
@@ -0,0 +1,98 @@
+package dto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteCrossingIn_Validate(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	later := now.Add(time.Minute)
+
+	tests := []struct {
+		name        string
+		in          RouteCrossingIn
+		expectedErr error
+	}{
+		{
+			name: "valid",
+			in:   RouteCrossingIn{Points: []RoutePoint{{Point: Point{Lon: 1, Lat: 1}}, {Point: Point{Lon: 2, Lat: 2}}}},
+		},
+		{
+			name: "valid with times",
+			in: RouteCrossingIn{Points: []RoutePoint{
+				{Point: Point{Lon: 1, Lat: 1}, Time: &now},
+				{Point: Point{Lon: 2, Lat: 2}, Time: &later},
+			}},
+		},
+		{
+			name:        "single point",
+			in:          RouteCrossingIn{Points: []RoutePoint{{Point: Point{Lon: 1, Lat: 1}}}},
+			expectedErr: ErrInvalidRoute,
+		},
+		{
+			name: "wrong point",
+			in: RouteCrossingIn{Points: []RoutePoint{
+				{Point: Point{Lon: 1, Lat: 1}},
+				{Point: Point{Lon: 1, Lat: 91}},
+			}},
+			expectedErr: InvalidLatitudeError,
+		},
+		{
+			name: "wrong ids",
+			in: RouteCrossingIn{
+				ZoneIds: []int{0},
+				Points:  []RoutePoint{{Point: Point{Lon: 1, Lat: 1}}, {Point: Point{Lon: 2, Lat: 2}}},
+			},
+			expectedErr: ErrInvalidId,
+		},
+		{
+			name: "partial times",
+			in: RouteCrossingIn{Points: []RoutePoint{
+				{Point: Point{Lon: 1, Lat: 1}, Time: &now},
+				{Point: Point{Lon: 2, Lat: 2}},
+			}},
+			expectedErr: ErrInvalidRouteTimes,
+		},
+		{
+			name: "decreasing times",
+			in: RouteCrossingIn{Points: []RoutePoint{
+				{Point: Point{Lon: 1, Lat: 1}, Time: &later},
+				{Point: Point{Lon: 2, Lat: 2}, Time: &now},
+			}},
+			expectedErr: ErrInvalidRouteTimes,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, tt.in.Validate(), tt.expectedErr)
+		})
+	}
+}
+
+func TestRouteTimes_At(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	at := func(seconds int) *time.Time {
+		t := start.Add(time.Duration(seconds) * time.Second)
+		return &t
+	}
+
+	in := RouteCrossingIn{Points: []RoutePoint{
+		{Point: Point{Lon: 0, Lat: 0}, Time: at(0)},
+		{Point: Point{Lon: 3, Lat: 0}, Time: at(30)},
+		{Point: Point{Lon: 3, Lat: 1}, Time: at(100)},
+	}}
+
+	times := in.Times()
+	require.Equal(t, at(0), times.At(0))
+	require.Equal(t, at(10), times.At(0.25))
+	require.Equal(t, at(30), times.At(0.75))
+	require.Equal(t, at(65), times.At(0.875))
+	require.Equal(t, at(100), times.At(1))
+
+	untimed := RouteCrossingIn{Points: []RoutePoint{{Point: Point{Lon: 0, Lat: 0}}, {Point: Point{Lon: 1, Lat: 0}}}}
+	require.Nil(t, untimed.Times().At(0.5))
+}
@@ -0,0 +1,213 @@
+package dto
+
+import (
+	"errors"
+	"sync"
+	"time"
+	_ "time/tzdata"
+)
+
+const (
+	scheduleDateLayout = "2006-01-02"
+	minutesPerDay      = 24 * 60
+	maxScheduleWindows = 100
+)
+
+var (
+	ErrInvalidTimeZone        = errors.New("invalid schedule time zone")
+	ErrInvalidScheduleWindow  = errors.New("invalid schedule window")
+	ErrInvalidScheduleDate    = errors.New("invalid schedule exception date")
+	ErrDuplicateScheduleDate  = errors.New("duplicate schedule exception date")
+	ErrTooManyScheduleWindows = errors.New("too many schedule windows")
+)
+
+// locations caches loaded time zones, schedules are evaluated on every contains query.
+var locations sync.Map
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ZoneSchedule limits when a zone is active. Windows recur weekly in the local time
+// of TimeZone, exceptions replace the windows of a single date. A zone without
+// a schedule is always active.
+type ZoneSchedule struct {
+	TimeZone   string              `json:"time_zone"`
+	Windows    []ScheduleWindow    `json:"windows"`
+	Exceptions []ScheduleException `json:"exceptions,omitempty"`
+}
+
+// ScheduleWindow is a time range on the given weekdays ("mon" ... "sun"). Times are
+// "HH:MM", End may be "24:00". A window with End before Start ends on the next day.
+type ScheduleWindow struct {
+	Weekdays []string `json:"weekdays"`
+	TimeRange
+}
+
+// ScheduleException replaces the windows starting on Date ("YYYY-MM-DD").
+// An exception without windows makes the zone inactive for the whole date.
+type ScheduleException struct {
+	Date    string      `json:"date"`
+	Windows []TimeRange `json:"windows,omitempty"`
+}
+
+type TimeRange struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+func (s ZoneSchedule) Validate() error {
+	if _, err := loadLocation(s.TimeZone); err != nil {
+		return ErrInvalidTimeZone
+	}
+	if len(s.Windows) > maxScheduleWindows {
+		return ErrTooManyScheduleWindows
+	}
+	for _, w := range s.Windows {
+		if len(w.Weekdays) == 0 {
+			return ErrInvalidScheduleWindow
+		}
+		for _, day := range w.Weekdays {
+			if _, ok := weekdays[day]; !ok {
+				return ErrInvalidScheduleWindow
+			}
+		}
+		if err := w.TimeRange.validate(); err != nil {
+			return err
+		}
+	}
+	dates := make(map[string]struct{}, len(s.Exceptions))
+	for _, e := range s.Exceptions {
+		if _, err := time.Parse(scheduleDateLayout, e.Date); err != nil {
+			return ErrInvalidScheduleDate
+		}
+		if _, ok := dates[e.Date]; ok {
+			return ErrDuplicateScheduleDate
+		}
+		dates[e.Date] = struct{}{}
+		if len(e.Windows) > maxScheduleWindows {
+			return ErrTooManyScheduleWindows
+		}
+		for _, r := range e.Windows {
+			if err := r.validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ActiveAt reports whether t falls into a window of the schedule. Windows belong
+// to the date they start on, so an overnight window is checked against the local
+// date before t as well. Schedules with an unknown time zone are never active.
+func (s ZoneSchedule) ActiveAt(t time.Time) bool {
+	loc, err := loadLocation(s.TimeZone)
+	if err != nil {
+		return false
+	}
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	year, month, day := local.Date()
+
+	for _, r := range s.rangesOn(year, month, day) {
+		start, end := r.minutes()
+		if start < end && minute >= start && minute < end {
+			return true
+		}
+		// Overnight ranges cover the rest of the day they start on.
+		if end <= start && minute >= start {
+			return true
+		}
+	}
+	year, month, day = time.Date(year, month, day-1, 0, 0, 0, 0, loc).Date()
+	for _, r := range s.rangesOn(year, month, day) {
+		start, end := r.minutes()
+		if end <= start && minute < end {
+			return true
+		}
+	}
+	return false
+}
+
+// rangesOn returns the ranges starting on the local date, an exception for
+// the date wins over the weekly windows.
+func (s ZoneSchedule) rangesOn(year int, month time.Month, day int) []TimeRange {
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	key := date.Format(scheduleDateLayout)
+	for _, e := range s.Exceptions {
+		if e.Date == key {
+			return e.Windows
+		}
+	}
+
+	ranges := make([]TimeRange, 0, len(s.Windows))
+	for _, w := range s.Windows {
+		for _, name := range w.Weekdays {
+			if weekdays[name] == date.Weekday() {
+				ranges = append(ranges, w.TimeRange)
+				break
+			}
+		}
+	}
+	return ranges
+}
+
+func (r TimeRange) validate() error {
+	start, ok := parseClock(r.Start)
+	if !ok || start == minutesPerDay {
+		return ErrInvalidScheduleWindow
+	}
+	end, ok := parseClock(r.End)
+	if !ok || start == end {
+		return ErrInvalidScheduleWindow
+	}
+	return nil
+}
+
+// minutes returns validated start and end minutes of the day.
+func (r TimeRange) minutes() (int, int) {
+	start, _ := parseClock(r.Start)
+	end, _ := parseClock(r.End)
+	return start, end
+}
+
+// parseClock parses "HH:MM" into minutes of the day, "24:00" is the end of the day.
+func parseClock(clock string) (int, bool) {
+	if len(clock) != len("15:04") || clock[2] != ':' {
+		return 0, false
+	}
+	digits := [4]int{}
+	for i, c := range clock[:2] + clock[3:] {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		digits[i] = int(c - '0')
+	}
+	hours, minutes := digits[0]*10+digits[1], digits[2]*10+digits[3]
+	if minutes > 59 || hours*60+minutes > minutesPerDay {
+		return 0, false
+	}
+	return hours*60 + minutes, true
+}
+
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	// An empty name is UTC for time.LoadLocation, schedules must name their zone.
+	if name == "" {
+		return nil, ErrInvalidTimeZone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
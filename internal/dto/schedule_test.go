@@ -0,0 +1,128 @@
+package dto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestZoneSchedule_Validate(t *testing.T) {
+	window := ScheduleWindow{Weekdays: []string{"mon"}, TimeRange: TimeRange{Start: "22:00", End: "06:00"}}
+	tests := []struct {
+		name        string
+		schedule    ZoneSchedule
+		expectedErr error
+	}{
+		{name: "overnight window", schedule: ZoneSchedule{TimeZone: "Europe/Moscow", Windows: []ScheduleWindow{window}}},
+		{
+			name: "whole day and closed date",
+			schedule: ZoneSchedule{
+				TimeZone:   "UTC",
+				Windows:    []ScheduleWindow{{Weekdays: []string{"sat", "sun"}, TimeRange: TimeRange{Start: "00:00", End: "24:00"}}},
+				Exceptions: []ScheduleException{{Date: "2026-12-31"}},
+			},
+		},
+		{name: "empty time zone", schedule: ZoneSchedule{Windows: []ScheduleWindow{window}}, expectedErr: ErrInvalidTimeZone},
+		{name: "unknown time zone", schedule: ZoneSchedule{TimeZone: "Mars/Olympus"}, expectedErr: ErrInvalidTimeZone},
+		{
+			name:        "unknown weekday",
+			schedule:    ZoneSchedule{TimeZone: "UTC", Windows: []ScheduleWindow{{Weekdays: []string{"monday"}, TimeRange: window.TimeRange}}},
+			expectedErr: ErrInvalidScheduleWindow,
+		},
+		{
+			name:        "no weekdays",
+			schedule:    ZoneSchedule{TimeZone: "UTC", Windows: []ScheduleWindow{{TimeRange: window.TimeRange}}},
+			expectedErr: ErrInvalidScheduleWindow,
+		},
+		{
+			name:        "invalid clock",
+			schedule:    ZoneSchedule{TimeZone: "UTC", Windows: []ScheduleWindow{{Weekdays: []string{"mon"}, TimeRange: TimeRange{Start: "9:00", End: "18:00"}}}},
+			expectedErr: ErrInvalidScheduleWindow,
+		},
+		{
+			name:        "start at the end of the day",
+			schedule:    ZoneSchedule{TimeZone: "UTC", Windows: []ScheduleWindow{{Weekdays: []string{"mon"}, TimeRange: TimeRange{Start: "24:00", End: "06:00"}}}},
+			expectedErr: ErrInvalidScheduleWindow,
+		},
+		{
+			name:        "empty range",
+			schedule:    ZoneSchedule{TimeZone: "UTC", Windows: []ScheduleWindow{{Weekdays: []string{"mon"}, TimeRange: TimeRange{Start: "10:00", End: "10:00"}}}},
+			expectedErr: ErrInvalidScheduleWindow,
+		},
+		{
+			name:        "invalid date",
+			schedule:    ZoneSchedule{TimeZone: "UTC", Exceptions: []ScheduleException{{Date: "31.12.2026"}}},
+			expectedErr: ErrInvalidScheduleDate,
+		},
+		{
+			name:        "duplicate date",
+			schedule:    ZoneSchedule{TimeZone: "UTC", Exceptions: []ScheduleException{{Date: "2026-12-31"}, {Date: "2026-12-31"}}},
+			expectedErr: ErrDuplicateScheduleDate,
+		},
+		{
+			name: "invalid exception window",
+			schedule: ZoneSchedule{
+				TimeZone:   "UTC",
+				Exceptions: []ScheduleException{{Date: "2026-12-31", Windows: []TimeRange{{Start: "10:00", End: "25:00"}}}},
+			},
+			expectedErr: ErrInvalidScheduleWindow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, tt.schedule.Validate(), tt.expectedErr)
+		})
+	}
+}
+
+func TestZoneSchedule_ActiveAt(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	// 2026-03-02 is a Monday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 3, day, hour, minute, 0, 0, moscow)
+	}
+
+	schedule := ZoneSchedule{
+		TimeZone: "Europe/Moscow",
+		Windows: []ScheduleWindow{
+			{Weekdays: []string{"mon", "fri"}, TimeRange: TimeRange{Start: "22:00", End: "06:00"}},
+			{Weekdays: []string{"sat", "sun"}, TimeRange: TimeRange{Start: "00:00", End: "24:00"}},
+		},
+		Exceptions: []ScheduleException{
+			// Monday night is off, Tuesday morning goes with it.
+			{Date: "2026-03-09"},
+			// Friday opens early.
+			{Date: "2026-03-13", Windows: []TimeRange{{Start: "18:00", End: "06:00"}}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		time     time.Time
+		expected bool
+	}{
+		{name: "before window", time: at(2, 21, 59), expected: false},
+		{name: "window start", time: at(2, 22, 0), expected: true},
+		{name: "after midnight", time: at(3, 5, 59), expected: true},
+		{name: "window end", time: at(3, 6, 0), expected: false},
+		{name: "weekday without window", time: at(4, 23, 0), expected: false},
+		{name: "whole weekend day", time: at(7, 12, 0), expected: true},
+		{name: "end of weekend", time: at(8, 23, 59), expected: true},
+		{name: "monday morning after weekend", time: at(9, 1, 0), expected: false},
+		{name: "closed date", time: at(9, 23, 0), expected: false},
+		{name: "morning after closed date", time: at(10, 1, 0), expected: false},
+		{name: "exception window", time: at(13, 19, 0), expected: true},
+		{name: "other time zone", time: time.Date(2026, 3, 2, 19, 30, 0, 0, time.UTC), expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, schedule.ActiveAt(tt.time))
+		})
+	}
+
+	require.False(t, ZoneSchedule{TimeZone: "Mars/Olympus"}.ActiveAt(at(2, 22, 0)))
+}
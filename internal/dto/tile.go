@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"errors"
+)
+
+const (
+	MaxTileZoom = 24
+
+	// webMercatorExtent is the half-width of the EPSG:3857 world square in meters.
+	webMercatorExtent = 20037508.342789244
+)
+
+var (
+	ErrInvalidTile  = errors.New("invalid tile coordinates")
+	ErrNotSupported = errors.New("operation is not supported by storage")
+)
+
+type TileIn struct {
+	Z       int
+	X       int
+	Y       int
+	ZoneIds ZoneIds
+	Tag     string
+}
+
+func (in TileIn) Validate() error {
+	if in.Z < 0 || in.Z > MaxTileZoom {
+		return ErrInvalidTile
+	}
+	n := 1 << in.Z
+	if in.X < 0 || in.X >= n || in.Y < 0 || in.Y >= n {
+		return ErrInvalidTile
+	}
+	if len(in.Tag) > maxMetadataLength {
+		return ErrInvalidTag
+	}
+	return in.ZoneIds.Validate()
+}
+
+// Envelope returns tile bounds in EPSG:3857 as minX, minY, maxX, maxY.
+func (in TileIn) Envelope() (float64, float64, float64, float64) {
+	size := 2 * webMercatorExtent / float64(int(1)<<in.Z)
+	minX := -webMercatorExtent + float64(in.X)*size
+	maxY := webMercatorExtent - float64(in.Y)*size
+	return minX, maxY - size, minX + size, maxY
+}
@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTileIn_Envelope(t *testing.T) {
+	tests := []struct {
+		name     string
+		tile     TileIn
+		expected [4]float64
+	}{
+		{
+			name:     "world",
+			tile:     TileIn{Z: 0, X: 0, Y: 0},
+			expected: [4]float64{-webMercatorExtent, -webMercatorExtent, webMercatorExtent, webMercatorExtent},
+		},
+		{
+			name:     "north west quarter",
+			tile:     TileIn{Z: 1, X: 0, Y: 0},
+			expected: [4]float64{-webMercatorExtent, 0, 0, webMercatorExtent},
+		},
+		{
+			name:     "south east quarter",
+			tile:     TileIn{Z: 1, X: 1, Y: 1},
+			expected: [4]float64{0, -webMercatorExtent, webMercatorExtent, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minX, minY, maxX, maxY := tt.tile.Envelope()
+			require.InDeltaSlice(t, tt.expected[:], []float64{minX, minY, maxX, maxY}, 1e-6)
+		})
+	}
+}
+
+func TestTileIn_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		tile        TileIn
+		expectedErr error
+	}{
+		{name: "valid", tile: TileIn{Z: 2, X: 3, Y: 3}},
+		{name: "negative zoom", tile: TileIn{Z: -1}, expectedErr: ErrInvalidTile},
+		{name: "zoom too big", tile: TileIn{Z: MaxTileZoom + 1}, expectedErr: ErrInvalidTile},
+		{name: "x out of range", tile: TileIn{Z: 2, X: 4, Y: 0}, expectedErr: ErrInvalidTile},
+		{name: "y out of range", tile: TileIn{Z: 2, X: 0, Y: 4}, expectedErr: ErrInvalidTile},
+		{name: "invalid ids", tile: TileIn{Z: 0, ZoneIds: ZoneIds{0}}, expectedErr: ErrInvalidId},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tile.Validate()
+			if tt.expectedErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
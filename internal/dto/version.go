@@ -0,0 +1,15 @@
+package dto
+
+import (
+	"time"
+)
+
+// ZoneVersion is the zone geometry valid from ValidFrom until ValidTo,
+// ValidTo is nil for the current version.
+type ZoneVersion struct {
+	ZoneId    int                   `json:"id"`
+	Version   int                   `json:"version"`
+	ValidFrom time.Time             `json:"valid_from"`
+	ValidTo   *time.Time            `json:"valid_to"`
+	GeoJSON   FeatureCollectionJSON `json:"geojson"`
+}
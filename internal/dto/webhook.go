@@ -0,0 +1,169 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultWebhookDeliveriesLimit = 50
+	MaxWebhookDeliveriesLimit     = 500
+)
+
+var (
+	ErrInvalidWebhookURL       = errors.New("invalid webhook url")
+	ErrPrivateWebhookURL       = errors.New("webhook url must not point to a loopback, link-local or private address")
+	ErrInvalidEventType        = errors.New("invalid event type")
+	ErrInvalidWebhookSecret    = errors.New("invalid webhook secret")
+	ErrSubscriptionNotFound    = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrWebhookClaimExpired is returned when a delivery was claimed again after the lease
+	// of the completing worker ran out.
+	ErrWebhookClaimExpired = errors.New("webhook delivery claim expired")
+)
+
+type WebhookEventType string
+
+const (
+	WebhookEventZoneCreated   WebhookEventType = "zone.created"
+	WebhookEventZoneUpdated   WebhookEventType = "zone.updated"
+	WebhookEventZoneDeleted   WebhookEventType = "zone.deleted"
+	WebhookEventDeviceEntered WebhookEventType = "device.entered"
+	WebhookEventDeviceExited  WebhookEventType = "device.exited"
+	WebhookEventDeviceDwelled WebhookEventType = "device.dwelled"
+)
+
+func (t WebhookEventType) Valid() bool {
+	switch t {
+	case WebhookEventZoneCreated, WebhookEventZoneUpdated, WebhookEventZoneDeleted,
+		WebhookEventDeviceEntered, WebhookEventDeviceExited, WebhookEventDeviceDwelled:
+		return true
+	}
+	return false
+}
+
+// ZoneEventPayload is the data of zone.* webhook events.
+type ZoneEventPayload struct {
+	ZoneId int `json:"zone_id"`
+}
+
+type WebhookSubscriptionIn struct {
+	URL        string             `json:"url"`
+	EventTypes []WebhookEventType `json:"event_types"`
+	Secret     string             `json:"secret,omitempty"`
+}
+
+func (in WebhookSubscriptionIn) Validate() error {
+	u, err := url.Parse(in.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	if !publicHost(u.Hostname()) {
+		return ErrPrivateWebhookURL
+	}
+	if len(in.EventTypes) == 0 {
+		return ErrInvalidEventType
+	}
+	for _, eventType := range in.EventTypes {
+		if !eventType.Valid() {
+			return ErrInvalidEventType
+		}
+	}
+	if len(in.Secret) > maxMetadataLength {
+		return ErrInvalidWebhookSecret
+	}
+	return nil
+}
+
+// PublicAddr reports whether webhooks may be sent to addr. Loopback, link-local, private and
+// unspecified addresses are refused, they reach the network of the service, e.g. cloud metadata.
+func PublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsUnspecified()
+}
+
+// publicHost rejects the ip literals PublicAddr refuses and localhost. Other host names are
+// resolved only when webhooks are sent, their addresses are checked then.
+func publicHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return true
+	}
+	return PublicAddr(addr)
+}
+
+type WebhookSubscription struct {
+	Id         int                `json:"id"`
+	URL        string             `json:"url"`
+	EventTypes []WebhookEventType `json:"event_types"`
+	Secret     string             `json:"secret,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+// WebhookEventIn is an event to enqueue. An event with the idempotency key of an
+// enqueued one is skipped, an empty key is never skipped.
+type WebhookEventIn struct {
+	Type           WebhookEventType
+	Data           interface{}
+	IdempotencyKey string
+}
+
+// WebhookEvent is the body of every webhook request.
+type WebhookEvent struct {
+	Id        int64            `json:"id"`
+	Type      WebhookEventType `json:"type"`
+	Data      json.RawMessage  `json:"data"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryDead      WebhookDeliveryStatus = "dead"
+)
+
+type WebhookDelivery struct {
+	Id             int64                 `json:"id"`
+	SubscriptionId int                   `json:"subscription_id"`
+	Event          WebhookEvent          `json:"event"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty"`
+	LastStatusCode int                   `json:"last_status_code,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// WebhookDeliveryJob is a claimed delivery with everything needed to send it.
+type WebhookDeliveryJob struct {
+	Delivery WebhookDelivery
+	URL      string
+	Secret   string
+	// Claim identifies the claim, the delivery is completed only with the last one.
+	Claim int
+}
+
+type WebhookDeliveryResult struct {
+	DeliveryId    int64
+	Claim         int
+	Status        WebhookDeliveryStatus
+	StatusCode    int
+	Error         string
+	NextAttemptAt time.Time
+}
@@ -0,0 +1,90 @@
+package dto
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSubscriptionIn_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   WebhookSubscriptionIn
+		err  error
+	}{
+		{
+			name: "ok",
+			in:   WebhookSubscriptionIn{URL: "https://example.com/hook", EventTypes: []WebhookEventType{WebhookEventZoneCreated}},
+		},
+		{
+			name: "not http url",
+			in:   WebhookSubscriptionIn{URL: "ftp://example.com", EventTypes: []WebhookEventType{WebhookEventZoneCreated}},
+			err:  ErrInvalidWebhookURL,
+		},
+		{
+			name: "relative url",
+			in:   WebhookSubscriptionIn{URL: "/hook", EventTypes: []WebhookEventType{WebhookEventZoneCreated}},
+			err:  ErrInvalidWebhookURL,
+		},
+		{
+			name: "localhost url",
+			in:   WebhookSubscriptionIn{URL: "http://localhost:8080/hook", EventTypes: []WebhookEventType{WebhookEventZoneCreated}},
+			err:  ErrPrivateWebhookURL,
+		},
+		{
+			name: "loopback url",
+			in:   WebhookSubscriptionIn{URL: "http://127.0.0.1/hook", EventTypes: []WebhookEventType{WebhookEventZoneCreated}},
+			err:  ErrPrivateWebhookURL,
+		},
+		{
+			name: "ipv6 loopback url",
+			in:   WebhookSubscriptionIn{URL: "http://[::1]/hook", EventTypes: []WebhookEventType{WebhookEventZoneCreated}},
+			err:  ErrPrivateWebhookURL,
+		},
+		{
+			name: "link-local url",
+			in:   WebhookSubscriptionIn{URL: "http://169.254.169.254/latest/meta-data", EventTypes: []WebhookEventType{WebhookEventZoneCreated}},
+			err:  ErrPrivateWebhookURL,
+		},
+		{
+			name: "private url",
+			in:   WebhookSubscriptionIn{URL: "https://10.0.0.5/hook", EventTypes: []WebhookEventType{WebhookEventZoneCreated}},
+			err:  ErrPrivateWebhookURL,
+		},
+		{
+			name: "ipv4-mapped private url",
+			in:   WebhookSubscriptionIn{URL: "http://[::ffff:192.168.1.1]/hook", EventTypes: []WebhookEventType{WebhookEventZoneCreated}},
+			err:  ErrPrivateWebhookURL,
+		},
+		{
+			name: "public ip url",
+			in:   WebhookSubscriptionIn{URL: "https://93.184.216.34/hook", EventTypes: []WebhookEventType{WebhookEventZoneCreated}},
+		},
+		{
+			name: "no event types",
+			in:   WebhookSubscriptionIn{URL: "http://example.com"},
+			err:  ErrInvalidEventType,
+		},
+		{
+			name: "unknown event type",
+			in:   WebhookSubscriptionIn{URL: "http://example.com", EventTypes: []WebhookEventType{"zone.moved"}},
+			err:  ErrInvalidEventType,
+		},
+		{
+			name: "long secret",
+			in: WebhookSubscriptionIn{
+				URL:        "http://example.com",
+				EventTypes: []WebhookEventType{WebhookEventDeviceExited},
+				Secret:     strings.Repeat("s", maxMetadataLength+1),
+			},
+			err: ErrInvalidWebhookSecret,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, tt.in.Validate(), tt.err)
+		})
+	}
+}
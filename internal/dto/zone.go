@@ -16,29 +16,78 @@ func (ids ZoneIds) Validate() error {
 }
 
 var (
-	EmptyIdsErr     = errors.New("ids cannot be empty")
-	ErrInvalidId    = errors.New("invalid id")
-	ErrDuplicateKey = errors.New("duplicate key")
-	ErrEmptyData    = errors.New("empty data")
+	EmptyIdsErr      = errors.New("ids cannot be empty")
+	ErrInvalidId     = errors.New("invalid id")
+	ErrDuplicateKey  = errors.New("duplicate key")
+	ErrEmptyData     = errors.New("empty data")
+	ErrBatchTooLarge = errors.New("too many items in batch")
+	// ErrBatchItemRejected is a storage error caused by the input of a batch item,
+	// the other items of the batch can be checked without it.
+	ErrBatchItemRejected = errors.New("item rejected by storage")
+
+	ErrZoneNotFound    = errors.New("zone not found")
+	ErrPropertiesCount = errors.New("properties count must match features count")
 )
 
+// ZoneContainsPointIn targets zones by ids or all zones of a layer.
 type ZoneContainsPointIn struct {
 	ZoneIds ZoneIds `json:"ids"`
+	Layer   string  `json:"layer,omitempty"`
 	Point   Point   `json:"point"`
+	ContainsOptions
+}
+
+type ZonePropertiesPatchIn struct {
+	Properties []map[string]interface{} `json:"properties"`
+}
+
+func (in ZonePropertiesPatchIn) Validate() error {
+	if len(in.Properties) == 0 {
+		return ErrEmptyData
+	}
+	return nil
+}
+
+type PointIn struct {
+	Point Point `json:"point"`
+}
+
+func (in PointIn) Validate() error {
+	return in.Point.Validate()
 }
 
 type BatchZoneContainsPointIn struct {
 	Key     string  `json:"key"`
 	ZoneIds ZoneIds `json:"ids"`
+	Layer   string  `json:"layer,omitempty"`
 	Point   Point   `json:"point"`
+	ContainsOptions
 }
 
+// MaxBatchSize is the largest number of items of a batch contains request.
+const MaxBatchSize = 1000
+
 type BatchZoneContainsPointInCollection []BatchZoneContainsPointIn
 
+func (in BatchZoneContainsPointIn) Validate() error {
+	if err := validateZonesTarget(in.ZoneIds, in.Layer); err != nil {
+		return err
+	}
+	if err := in.ContainsOptions.Validate(); err != nil {
+		return err
+	}
+	return in.Point.Validate()
+}
+
+// Validate checks the batch as a whole. Items are validated one by one
+// so that a bad item is reported inline without failing the batch.
 func (b BatchZoneContainsPointInCollection) Validate() error {
 	if len(b) == 0 {
 		return ErrEmptyData
 	}
+	if len(b) > MaxBatchSize {
+		return ErrBatchTooLarge
+	}
 	cacheKeys := make(map[string]struct{})
 	for _, v := range b {
 		if _, ok := cacheKeys[v.Key]; ok {
@@ -46,27 +95,45 @@ func (b BatchZoneContainsPointInCollection) Validate() error {
 		} else {
 			cacheKeys[v.Key] = struct{}{}
 		}
-		if err := v.ZoneIds.Validate(); err != nil {
-			return err
-		}
 	}
 	return nil
 }
 
 type ZoneContainsPointOut struct {
-	ZoneId   int  `json:"id"`
-	Contains bool `json:"contains"`
+	ZoneId   int      `json:"id"`
+	Contains bool     `json:"contains"`
+	Position Position `json:"position"`
+}
+
+type AnyContainsPointOut struct {
+	Contains bool     `json:"contains"`
+	Position Position `json:"position"`
 }
 
 type BatchZoneContainsPointOut struct {
-	Key      string `json:"key"`
-	Contains bool   `json:"contains"`
+	Key      string   `json:"key"`
+	Contains bool     `json:"contains"`
+	Position Position `json:"position,omitempty"`
+	Error    string   `json:"error,omitempty"`
 }
 
 func (in ZoneContainsPointIn) Validate() error {
-	if err := in.ZoneIds.Validate(); err != nil {
+	if err := validateZonesTarget(in.ZoneIds, in.Layer); err != nil {
+		return err
+	}
+	if err := in.ContainsOptions.Validate(); err != nil {
 		return err
 	}
 
 	return in.Point.Validate()
 }
+
+func validateZonesTarget(ids ZoneIds, layer string) error {
+	if layer == "" {
+		return ids.Validate()
+	}
+	if len(ids) > 0 {
+		return ErrIdsWithLayer
+	}
+	return ValidateLayerName(layer)
+}
@@ -0,0 +1,195 @@
+package indexed
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/repository/memory"
+	"github.com/maxsnegir/zones_service/internal/repository/psql"
+)
+
+const (
+	// refreshRetryInterval is the pause before a failed catch-up is retried.
+	refreshRetryInterval = time.Second
+	catchUpBatchSize     = 1000
+)
+
+// Storage keeps zones in Postgres and answers point lookups from an in-memory R-tree over
+// them, without a round trip. Everything else, writes included, goes to Postgres. The index
+// is filled by Load and follows the change log of Postgres:
+//   - writes through this Storage refresh the index before they return, so the writer's
+//     follow-up lookups see them;
+//   - writes of other instances are seen once their change arrives through Track.
+//
+// When a write's changes fail to be numbered or read back, lookups miss the write until
+// Run catches up on the next change or retry. Lookups at a past time need the zone history,
+// so they go to Postgres too.
+type Storage struct {
+	*psql.Storage
+	index *memory.Index
+	log   *logrus.Logger
+
+	// mu serializes catch-ups, lastSeq is the last change applied to the index.
+	mu      sync.Mutex
+	lastSeq int64
+	// changed wakes Run up when changes arrive.
+	changed chan struct{}
+}
+
+// New wraps storage and registers the catch-up on its writes, storage must not be used
+// before that.
+func New(log *logrus.Logger, storage *psql.Storage) *Storage {
+	s := &Storage{
+		Storage: storage,
+		index:   memory.NewIndex(),
+		log:     log,
+		changed: make(chan struct{}, 1),
+	}
+	storage.OnZoneChanges(s.catchUp)
+	return s
+}
+
+// Track wakes Run up to apply the change. It does not block, so it can subscribe
+// to psql.Listener. Changes arriving together are applied at once.
+func (s *Storage) Track(change dto.ZoneChange) {
+	s.wake()
+}
+
+// Run applies the changes passed to Track until ctx is done.
+func (s *Storage) Run(ctx context.Context) {
+	const op = "indexed.Run"
+
+	// Changes made between Load and the start of Run did not wake it up.
+	s.wake()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.changed:
+		}
+		if err := s.catchUp(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.log.Error(fmt.Sprintf("%s: retrying in %s: %v", op, refreshRetryInterval, err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(refreshRetryInterval):
+			}
+			s.wake()
+		}
+	}
+}
+
+func (s *Storage) wake() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Load indexes every zone of Postgres, the zones are read one by one. Changes after
+// the last one numbered before the zones are read are applied by the next catch-up.
+func (s *Storage) Load(ctx context.Context) error {
+	const op = "indexed.Load"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lastSeq, err := s.Storage.LastZoneChangeSeq(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	count := 0
+	err = s.Storage.ExportZones(ctx, dto.ZonesFilter{}, func(zone dto.ZoneGeoJSON) error {
+		count++
+		return s.index.LoadZone(zone)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	s.lastSeq = lastSeq
+	s.log.Infof("indexed %d zones", count)
+	return nil
+}
+
+// catchUp reads the zones changed after lastSeq from Postgres again, deleted zones are
+// removed from the index.
+func (s *Storage) catchUp(ctx context.Context) error {
+	const op = "indexed.catchUp"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		changes, err := s.Storage.GetZoneChanges(ctx, s.lastSeq, catchUpBatchSize)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		ids := make([]int, 0, len(changes))
+		for _, change := range changes {
+			ids = append(ids, change.ZoneId)
+		}
+		slices.Sort(ids)
+		if err = s.refresh(ctx, slices.Compact(ids)); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		s.lastSeq = changes[len(changes)-1].Seq
+		if len(changes) < catchUpBatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *Storage) refresh(ctx context.Context, ids []int) error {
+	zones, err := s.Storage.GetZonesByIds(ctx, ids, nil, dto.WGS84)
+	if err != nil {
+		return err
+	}
+	found := make(map[int]struct{}, len(zones))
+	for _, zone := range zones {
+		if err = s.index.LoadZone(zone); err != nil {
+			return err
+		}
+		found[zone.ZoneId] = struct{}{}
+	}
+	for _, id := range ids {
+		if _, ok := found[id]; !ok {
+			s.index.UnloadZone(id)
+		}
+	}
+	return nil
+}
+
+func (s *Storage) ContainsPoint(ctx context.Context, ids []int, point dto.Point, opts dto.ContainsOptions) ([]dto.ZoneContainsPointOut, error) {
+	if opts.At != nil {
+		return s.Storage.ContainsPoint(ctx, ids, point, opts)
+	}
+	return s.index.ContainsPoint(ctx, ids, point, opts)
+}
+
+func (s *Storage) AnyContainsPoint(ctx context.Context, ids []int, point dto.Point, opts dto.ContainsOptions) (dto.AnyContainsPointOut, error) {
+	if opts.At != nil {
+		return s.Storage.AnyContainsPoint(ctx, ids, point, opts)
+	}
+	return s.index.AnyContainsPoint(ctx, ids, point, opts)
+}
+
+func (s *Storage) ButchAnyZoneContainsPoint(ctx context.Context, in dto.BatchZoneContainsPointInCollection) ([]dto.BatchZoneContainsPointOut, error) {
+	for _, v := range in {
+		if v.At != nil {
+			return s.Storage.ButchAnyZoneContainsPoint(ctx, in)
+		}
+	}
+	return s.index.ButchAnyZoneContainsPoint(ctx, in)
+}
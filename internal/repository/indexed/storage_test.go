@@ -0,0 +1,90 @@
+package indexed
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/config"
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/logger"
+	"github.com/maxsnegir/zones_service/internal/repository/psql"
+)
+
+const squareGeoJson = `{"type": "FeatureCollection", "features": [
+	{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 1], [0, 0]]]}}
+]}`
+
+const movedSquareGeoJson = `{"type": "FeatureCollection", "features": [
+	{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[2, 2], [3, 2], [3, 3], [2, 3], [2, 2]]]}}
+]}`
+
+func mustFeatureCollection(t *testing.T, data string) geojson.FeatureCollection {
+	t.Helper()
+
+	var featureCollectionJson dto.FeatureCollectionJSON
+	require.NoError(t, json.Unmarshal([]byte(data), &featureCollectionJson))
+
+	var featureCollection geojson.FeatureCollection
+	require.NoError(t, featureCollection.FromFeatureCollectionJSON(featureCollectionJson))
+	return featureCollection
+}
+
+func TestStorage_ReadsOwnWrites(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	testStorage, err := psql.NewTestStorage(ctx)
+	if err != nil {
+		t.Skipf("postgres is not available: %v", err)
+	}
+	defer testStorage.ShutDown()
+
+	log := logger.New(config.EnvTest)
+	loadedId, err := testStorage.SaveZoneFromFeatureCollection(ctx, mustFeatureCollection(t, squareGeoJson))
+	require.NoError(t, err)
+
+	s := New(log, testStorage.Storage)
+	require.NoError(t, s.Load(ctx))
+	inside := dto.Point{Lon: 0.5, Lat: 0.5}
+	moved := dto.Point{Lon: 2.5, Lat: 2.5}
+
+	contains, err := s.AnyContainsPoint(ctx, []int{loadedId}, inside, dto.ContainsOptions{})
+	require.NoError(t, err)
+	require.True(t, contains.Contains)
+
+	// Writes are seen by the next lookup, without Run.
+	zoneId, err := s.SaveZoneFromFeatureCollection(ctx, mustFeatureCollection(t, squareGeoJson))
+	require.NoError(t, err)
+	contains, err = s.AnyContainsPoint(ctx, []int{zoneId}, inside, dto.ContainsOptions{})
+	require.NoError(t, err)
+	require.True(t, contains.Contains)
+
+	require.NoError(t, s.UpdateZoneFromFeatureCollection(ctx, zoneId, mustFeatureCollection(t, movedSquareGeoJson)))
+	contains, err = s.AnyContainsPoint(ctx, []int{zoneId}, moved, dto.ContainsOptions{})
+	require.NoError(t, err)
+	require.True(t, contains.Contains)
+
+	require.NoError(t, s.DeleteZoneById(ctx, zoneId))
+	result, err := s.ContainsPoint(ctx, []int{zoneId}, moved, dto.ContainsOptions{})
+	require.NoError(t, err)
+	require.Empty(t, result)
+
+	// Writes of another instance are applied by Run once their change is tracked.
+	other := New(log, testStorage.Storage)
+	require.NoError(t, other.DeleteZoneById(ctx, loadedId))
+	contains, err = s.AnyContainsPoint(ctx, []int{loadedId}, inside, dto.ContainsOptions{})
+	require.NoError(t, err)
+	require.True(t, contains.Contains)
+
+	go s.Run(ctx)
+	s.Track(dto.ZoneChange{Type: dto.ZoneChangeDeleted, ZoneId: loadedId})
+	require.Eventually(t, func() bool {
+		contains, err := s.AnyContainsPoint(ctx, []int{loadedId}, inside, dto.ContainsOptions{})
+		return err == nil && !contains.Contains
+	}, 2*time.Second, 10*time.Millisecond)
+}
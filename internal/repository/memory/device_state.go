@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+// DeviceStateStore keeps geofence device states in memory. Updates of a device are
+// serialized by the lock of the device, updates of different devices run in parallel.
+type DeviceStateStore struct {
+	mu     sync.Mutex
+	states map[string]dto.DeviceState
+	locks  map[string]*deviceLock
+}
+
+// deviceLock is dropped by the last update waiting for it.
+type deviceLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func NewDeviceStateStore() *DeviceStateStore {
+	return &DeviceStateStore{
+		states: make(map[string]dto.DeviceState),
+		locks:  make(map[string]*deviceLock),
+	}
+}
+
+func (s *DeviceStateStore) GetDeviceState(ctx context.Context, deviceId string) (dto.DeviceState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[deviceId]
+	if !ok {
+		return dto.DeviceState{}, dto.ErrDeviceNotFound
+	}
+	return copyDeviceState(state), nil
+}
+
+// UpdateDeviceState applies fn to a copy of the state and keeps the result only if fn succeeds.
+func (s *DeviceStateStore) UpdateDeviceState(ctx context.Context, deviceId string, fn func(state *dto.DeviceState) error) error {
+	unlock := s.lockDevice(deviceId)
+	defer unlock()
+
+	s.mu.Lock()
+	state := copyDeviceState(s.states[deviceId])
+	s.mu.Unlock()
+
+	if err := fn(&state); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.states[deviceId] = state
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *DeviceStateStore) lockDevice(deviceId string) func() {
+	s.mu.Lock()
+	lock, ok := s.locks[deviceId]
+	if !ok {
+		lock = &deviceLock{}
+		s.locks[deviceId] = lock
+	}
+	lock.refs++
+	s.mu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+
+		s.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(s.locks, deviceId)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func copyDeviceState(state dto.DeviceState) dto.DeviceState {
+	state.Zones = append([]dto.ZoneStay(nil), state.Zones...)
+	state.Unpublished = append([]dto.GeofenceEvent(nil), state.Unpublished...)
+	return state
+}
@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"math"
+
+	"github.com/twpayne/go-geom"
+	"github.com/twpayne/go-geom/xy"
+	"github.com/twpayne/go-geom/xy/location"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+func boundsRect(g geom.T) rect {
+	b := g.Bounds()
+	return rect{minX: b.Min(0), minY: b.Min(1), maxX: b.Max(0), maxY: b.Max(1)}
+}
+
+// locatePoint finds the topological location of c, treating holes as exterior.
+func locatePoint(g geom.T, c geom.Coord) location.Type {
+	switch g := g.(type) {
+	case *geom.Polygon:
+		return locatePointInPolygon(g, c)
+	case *geom.MultiPolygon:
+		result := location.Exterior
+		for i := 0; i < g.NumPolygons(); i++ {
+			switch locatePointInPolygon(g.Polygon(i), c) {
+			case location.Interior:
+				return location.Interior
+			case location.Boundary:
+				result = location.Boundary
+			}
+		}
+		return result
+	}
+	return location.Exterior
+}
+
+func locatePointInPolygon(p *geom.Polygon, c geom.Coord) location.Type {
+	if p.NumLinearRings() == 0 {
+		return location.Exterior
+	}
+	shell := xy.LocatePointInRing(p.Layout(), c, p.LinearRing(0).FlatCoords())
+	if shell != location.Interior {
+		return shell
+	}
+	for i := 1; i < p.NumLinearRings(); i++ {
+		switch xy.LocatePointInRing(p.Layout(), c, p.LinearRing(i).FlatCoords()) {
+		case location.Interior:
+			return location.Exterior
+		case location.Boundary:
+			return location.Boundary
+		}
+	}
+	return location.Interior
+}
+
+// matchPoint evaluates the predicate the same way the psql storage does and
+// ranks the position: 2 is inside, 1 is on the edge, 0 is outside.
+func matchPoint(g geom.T, c geom.Coord, opts dto.ContainsOptions) (bool, int) {
+	loc := locatePoint(g, c)
+	nearBoundary := false
+	if opts.Tolerance > 0 {
+		if boundaryPoint, ok := closestBoundaryPoint(g, c); ok {
+			nearBoundary = distanceMeters(c, boundaryPoint) <= opts.Tolerance
+		}
+	}
+
+	var matched bool
+	switch opts.PredicateOrDefault() {
+	case dto.PredicateCovers, dto.PredicateIntersects:
+		matched = loc != location.Exterior
+	default:
+		matched = loc == location.Interior
+	}
+	matched = matched || (opts.Tolerance > 0 && (loc != location.Exterior || nearBoundary))
+
+	switch {
+	case loc == location.Boundary || nearBoundary:
+		return matched, 1
+	case loc == location.Interior:
+		return matched, 2
+	default:
+		return matched, 0
+	}
+}
+
+// toleranceRect extends the point by tolerance meters to search the index.
+func toleranceRect(point dto.Point, tolerance float64) rect {
+	r := pointRect(point.Lon, point.Lat)
+	if tolerance <= 0 {
+		return r
+	}
+	dLat := tolerance / (earthRadius * math.Pi / 180)
+	dLon := 180.0
+	if cos := math.Cos(point.Lat * math.Pi / 180); cos > 1e-9 {
+		dLon = math.Min(dLon, dLat/cos)
+	}
+	return rect{minX: r.minX - dLon, minY: r.minY - dLat, maxX: r.maxX + dLon, maxY: r.maxY + dLat}
+}
+
+// closestBoundaryPoint returns the point of the geometry rings closest to c in the lon/lat plane,
+// like ST_ClosestPoint on ST_Boundary does.
+func closestBoundaryPoint(g geom.T, c geom.Coord) (geom.Coord, bool) {
+	var (
+		best     geom.Coord
+		bestDist = math.Inf(1)
+	)
+	visit := func(p *geom.Polygon) {
+		for i := 0; i < p.NumLinearRings(); i++ {
+			ring := p.LinearRing(i)
+			for j := 1; j < ring.NumCoords(); j++ {
+				candidate := closestSegmentPoint(ring.Coord(j-1), ring.Coord(j), c)
+				dx, dy := candidate[0]-c[0], candidate[1]-c[1]
+				if d := dx*dx + dy*dy; d < bestDist {
+					best, bestDist = candidate, d
+				}
+			}
+		}
+	}
+	switch g := g.(type) {
+	case *geom.Polygon:
+		visit(g)
+	case *geom.MultiPolygon:
+		for i := 0; i < g.NumPolygons(); i++ {
+			visit(g.Polygon(i))
+		}
+	}
+	return best, best != nil
+}
+
+func closestSegmentPoint(a, b, c geom.Coord) geom.Coord {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	length := dx*dx + dy*dy
+	if length == 0 {
+		return geom.Coord{a[0], a[1]}
+	}
+	t := ((c[0]-a[0])*dx + (c[1]-a[1])*dy) / length
+	t = math.Max(0, math.Min(1, t))
+	return geom.Coord{a[0] + t*dx, a[1] + t*dy}
+}
+
+const earthRadius = 6371008.8
+
+// distanceMeters is the great-circle distance between two lon/lat coordinates.
+func distanceMeters(a, b geom.Coord) float64 {
+	lat1, lat2 := a[1]*math.Pi/180, b[1]*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b[0] - a[0]) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
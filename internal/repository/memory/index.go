@@ -0,0 +1,219 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/twpayne/go-geom"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+type feature struct {
+	zoneId   int
+	geometry geom.T
+	bbox     rect
+}
+
+type zone struct {
+	id       int
+	schedule *dto.ZoneSchedule
+	features []*feature
+}
+
+// Index answers point lookups over zones loaded from a durable storage through an R-tree
+// over feature bounding boxes, see indexed.Storage. It keeps only what lookups need: the
+// feature geometries and the zone schedule. It keeps no zone history, so opts.At only
+// selects the time schedules are checked at; lookups at a past time go to the durable storage.
+type Index struct {
+	mu    sync.RWMutex
+	zones map[int]*zone
+	tree  *rtree
+}
+
+func NewIndex() *Index {
+	return &Index{
+		zones: make(map[int]*zone),
+		tree:  newRtree(),
+	}
+}
+
+// LoadZone puts a zone read from a durable storage into the index, replacing the zone
+// with its id. The zone was checked when it was saved, so it is not checked again.
+func (s *Index) LoadZone(zoneGeoJson dto.ZoneGeoJSON) error {
+	const op = "memory.LoadZone"
+
+	var featureCollection geojson.FeatureCollection
+	if err := featureCollection.FromFeatureCollectionJSON(zoneGeoJson.GeoJSON); err != nil {
+		return fmt.Errorf("%s: zone %d: %w", op, zoneGeoJson.ZoneId, err)
+	}
+	features := make([]*feature, 0, len(featureCollection.Features))
+	for _, f := range featureCollection.Features {
+		g := f.Geometry.Geom()
+		features = append(features, &feature{zoneId: zoneGeoJson.ZoneId, geometry: g, bbox: boundsRect(g)})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	z, ok := s.zones[zoneGeoJson.ZoneId]
+	if !ok {
+		z = &zone{id: zoneGeoJson.ZoneId}
+		s.zones[z.id] = z
+	}
+	z.schedule = zoneGeoJson.Schedule
+	s.setFeatures(z, features)
+	return nil
+}
+
+// UnloadZone removes the zone from the index.
+func (s *Index) UnloadZone(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	z, ok := s.zones[id]
+	if !ok {
+		return
+	}
+	s.setFeatures(z, nil)
+	delete(s.zones, id)
+}
+
+// ContainsPoint returns the result for every loaded zone of ids, unknown ids are omitted.
+func (s *Index) ContainsPoint(ctx context.Context, ids []int, point dto.Point, opts dto.ContainsOptions) ([]dto.ZoneContainsPointOut, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids = uniqueIds(ids)
+	matches := s.matchIds(point, opts, ids)
+
+	result := make([]dto.ZoneContainsPointOut, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := s.zones[id]; !ok {
+			continue
+		}
+		m := matches[id]
+		result = append(result, dto.ZoneContainsPointOut{ZoneId: id, Contains: m.contains, Position: m.position()})
+	}
+	return result, nil
+}
+
+func (s *Index) AnyContainsPoint(ctx context.Context, ids []int, point dto.Point, opts dto.ContainsOptions) (dto.AnyContainsPointOut, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	m := mergeMatches(s.matchIds(point, opts, ids))
+	return dto.AnyContainsPointOut{Contains: m.contains, Position: m.position()}, nil
+}
+
+func (s *Index) ButchAnyZoneContainsPoint(ctx context.Context, in dto.BatchZoneContainsPointInCollection) ([]dto.BatchZoneContainsPointOut, error) {
+	const op = "memory.ButchAnyZoneContainsPoint"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]dto.BatchZoneContainsPointOut, 0, len(in))
+	for _, v := range in {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		m := mergeMatches(s.matchIds(v.Point, v.ContainsOptions, v.ZoneIds))
+		results = append(results, dto.BatchZoneContainsPointOut{
+			Key:      v.Key,
+			Contains: m.contains,
+			Position: m.position(),
+		})
+	}
+	return results, nil
+}
+
+// setFeatures replaces zone features and keeps the tree in sync. Callers must hold s.mu.
+func (s *Index) setFeatures(z *zone, features []*feature) {
+	for _, f := range z.features {
+		s.tree.remove(f.bbox, f)
+	}
+	for _, f := range features {
+		s.tree.insert(f.bbox, f)
+	}
+	z.features = features
+}
+
+type zoneMatch struct {
+	contains bool
+	rank     int
+}
+
+func (m zoneMatch) position() dto.Position {
+	switch m.rank {
+	case 2:
+		return dto.PositionInside
+	case 1:
+		return dto.PositionOnEdge
+	default:
+		return dto.PositionOutside
+	}
+}
+
+func (m zoneMatch) merge(o zoneMatch) zoneMatch {
+	if o.rank > m.rank {
+		m.rank = o.rank
+	}
+	m.contains = m.contains || o.contains
+	return m
+}
+
+func mergeMatches(matches map[int]zoneMatch) zoneMatch {
+	var result zoneMatch
+	for _, m := range matches {
+		result = result.merge(m)
+	}
+	return result
+}
+
+// matchIds evaluates the point against features of zones with the given ids. Zones whose
+// features are all far from the point and zones inactive by schedule are omitted.
+// Callers must hold s.mu.
+func (s *Index) matchIds(point dto.Point, opts dto.ContainsOptions, ids []int) map[int]zoneMatch {
+	coord := geom.Coord{point.Lon, point.Lat}
+	filter := s.activeIds(ids, opts)
+	result := make(map[int]zoneMatch)
+
+	s.tree.search(toleranceRect(point, opts.Tolerance), func(f *feature) bool {
+		if _, ok := filter[f.zoneId]; !ok {
+			return true
+		}
+		contains, rank := matchPoint(f.geometry, coord, opts)
+		result[f.zoneId] = result[f.zoneId].merge(zoneMatch{contains: contains, rank: rank})
+		return true
+	})
+	return result
+}
+
+// activeIds is the set of ids without the zones whose schedule is inactive at the time
+// of opts. Callers must hold s.mu.
+func (s *Index) activeIds(ids []int, opts dto.ContainsOptions) map[int]struct{} {
+	t := opts.TimeOrNow()
+	active := make(map[int]struct{}, len(ids))
+	for _, id := range ids {
+		if z, ok := s.zones[id]; ok && z.schedule != nil && !z.schedule.ActiveAt(t) {
+			continue
+		}
+		active[id] = struct{}{}
+	}
+	return active
+}
+
+func uniqueIds(ids []int) []int {
+	seen := make(map[int]struct{}, len(ids))
+	result := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+	return result
+}
@@ -0,0 +1,283 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+const polygonGeoJson = `
+	{
+		"type": "FeatureCollection",
+		"features": [
+			{
+				"type": "Feature",
+				"properties": {"color": "#ff0000"},
+				"geometry": {
+					"type": "Polygon",
+					"coordinates": [[[0, 0], [0, 1], [1, 1], [1, 0], [0, 0]]]
+				}
+			},
+			{
+				"type": "Feature",
+				"properties": {"color": "#00ff00"},
+				"geometry": {
+					"type": "Polygon",
+					"coordinates": [[[2, 2], [2, 3], [3, 3], [3, 2], [2, 2]]]
+				}
+			}
+		]
+	}`
+
+const polygonWithHoleGeoJson = `
+	{
+		"type": "FeatureCollection",
+		"features": [
+			{
+				"type": "Feature",
+				"properties": {},
+				"geometry": {
+					"type": "MultiPolygon",
+					"coordinates": [[
+						[[0, 0], [0, 10], [10, 10], [10, 0], [0, 0]],
+						[[4, 4], [4, 6], [6, 6], [6, 4], [4, 4]]
+					]]
+				}
+			}
+		]
+	}`
+
+func loadedZone(t *testing.T, id int, data string) dto.ZoneGeoJSON {
+	t.Helper()
+
+	var featureCollectionJson dto.FeatureCollectionJSON
+	require.NoError(t, json.Unmarshal([]byte(data), &featureCollectionJson))
+	return dto.ZoneGeoJSON{ZoneId: id, GeoJSON: featureCollectionJson}
+}
+
+func TestIndex_LoadZone(t *testing.T) {
+	ctx := context.Background()
+	s := NewIndex()
+
+	require.NoError(t, s.LoadZone(loadedZone(t, 7, polygonGeoJson)))
+	contains, err := s.ContainsPoint(ctx, []int{7}, dto.Point{Lon: 2.5, Lat: 2.5}, dto.ContainsOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []dto.ZoneContainsPointOut{{ZoneId: 7, Contains: true, Position: dto.PositionInside}}, contains)
+
+	// Loading the zone again replaces its geometry.
+	require.NoError(t, s.LoadZone(loadedZone(t, 7, polygonWithHoleGeoJson)))
+	contains, err = s.ContainsPoint(ctx, []int{7}, dto.Point{Lon: 2.5, Lat: 2.5}, dto.ContainsOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []dto.ZoneContainsPointOut{{ZoneId: 7, Contains: true, Position: dto.PositionInside}}, contains)
+	contains, err = s.ContainsPoint(ctx, []int{7}, dto.Point{Lon: 5, Lat: 5}, dto.ContainsOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []dto.ZoneContainsPointOut{{ZoneId: 7, Contains: false, Position: dto.PositionOutside}}, contains)
+
+	s.UnloadZone(7)
+	contains, err = s.ContainsPoint(ctx, []int{7}, dto.Point{Lon: 2.5, Lat: 2.5}, dto.ContainsOptions{})
+	require.NoError(t, err)
+	require.Empty(t, contains)
+	anyContains, err := s.AnyContainsPoint(ctx, []int{7}, dto.Point{Lon: 0.5, Lat: 0.5}, dto.ContainsOptions{})
+	require.NoError(t, err)
+	require.False(t, anyContains.Contains)
+}
+
+func TestIndex_ContainsPoint(t *testing.T) {
+	ctx := context.Background()
+	s := NewIndex()
+
+	const polygonId, holeId = 1, 2
+	require.NoError(t, s.LoadZone(loadedZone(t, polygonId, polygonGeoJson)))
+	require.NoError(t, s.LoadZone(loadedZone(t, holeId, polygonWithHoleGeoJson)))
+
+	tests := []struct {
+		name     string
+		ids      []int
+		point    dto.Point
+		expected []dto.ZoneContainsPointOut
+	}{
+		{
+			name:  "both contain point",
+			ids:   []int{polygonId, holeId},
+			point: dto.Point{Lon: 0.5, Lat: 0.5},
+			expected: []dto.ZoneContainsPointOut{
+				{ZoneId: polygonId, Contains: true, Position: dto.PositionInside},
+				{ZoneId: holeId, Contains: true, Position: dto.PositionInside},
+			},
+		},
+		{
+			name:  "point in second feature",
+			ids:   []int{polygonId},
+			point: dto.Point{Lon: 2.5, Lat: 2.5},
+			expected: []dto.ZoneContainsPointOut{
+				{ZoneId: polygonId, Contains: true, Position: dto.PositionInside},
+			},
+		},
+		{
+			name:  "point in hole",
+			ids:   []int{holeId, polygonId},
+			point: dto.Point{Lon: 5, Lat: 5},
+			expected: []dto.ZoneContainsPointOut{
+				{ZoneId: holeId, Contains: false, Position: dto.PositionOutside},
+				{ZoneId: polygonId, Contains: false, Position: dto.PositionOutside},
+			},
+		},
+		{
+			name:  "point on boundary",
+			ids:   []int{polygonId},
+			point: dto.Point{Lon: 1, Lat: 0.5},
+			expected: []dto.ZoneContainsPointOut{
+				{ZoneId: polygonId, Contains: false, Position: dto.PositionOnEdge},
+			},
+		},
+		{
+			name:     "not existing ids",
+			ids:      []int{100, 1001},
+			point:    dto.Point{Lon: 0.5, Lat: 0.5},
+			expected: []dto.ZoneContainsPointOut{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := s.ContainsPoint(ctx, tt.ids, tt.point, dto.ContainsOptions{})
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+
+	anyContains, err := s.AnyContainsPoint(ctx, []int{polygonId, holeId}, dto.Point{Lon: 8, Lat: 8}, dto.ContainsOptions{})
+	require.NoError(t, err)
+	require.True(t, anyContains.Contains)
+
+	anyContains, err = s.AnyContainsPoint(ctx, []int{polygonId}, dto.Point{Lon: 8, Lat: 8}, dto.ContainsOptions{})
+	require.NoError(t, err)
+	require.False(t, anyContains.Contains)
+
+	batch, err := s.ButchAnyZoneContainsPoint(ctx, dto.BatchZoneContainsPointInCollection{
+		{Key: "a", ZoneIds: []int{polygonId}, Point: dto.Point{Lon: 0.5, Lat: 0.5}},
+		{Key: "b", ZoneIds: []int{holeId}, Point: dto.Point{Lon: 5, Lat: 5}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []dto.BatchZoneContainsPointOut{
+		{Key: "a", Contains: true, Position: dto.PositionInside},
+		{Key: "b", Contains: false, Position: dto.PositionOutside},
+	}, batch)
+}
+
+func TestIndex_ContainsPointOptions(t *testing.T) {
+	ctx := context.Background()
+	s := NewIndex()
+
+	const zoneId = 1
+	require.NoError(t, s.LoadZone(loadedZone(t, zoneId, polygonGeoJson)))
+
+	tests := []struct {
+		name     string
+		point    dto.Point
+		opts     dto.ContainsOptions
+		contains bool
+		position dto.Position
+	}{
+		{
+			name:     "covers point on boundary",
+			point:    dto.Point{Lon: 1, Lat: 0.5},
+			opts:     dto.ContainsOptions{Predicate: dto.PredicateCovers},
+			contains: true,
+			position: dto.PositionOnEdge,
+		},
+		{
+			name:     "intersects point on boundary",
+			point:    dto.Point{Lon: 1, Lat: 0.5},
+			opts:     dto.ContainsOptions{Predicate: dto.PredicateIntersects},
+			contains: true,
+			position: dto.PositionOnEdge,
+		},
+		{
+			name:     "outside point within tolerance",
+			point:    dto.Point{Lon: 1.0005, Lat: 0.5},
+			opts:     dto.ContainsOptions{Tolerance: 100},
+			contains: true,
+			position: dto.PositionOnEdge,
+		},
+		{
+			name:     "outside point beyond tolerance",
+			point:    dto.Point{Lon: 1.0005, Lat: 0.5},
+			opts:     dto.ContainsOptions{Tolerance: 10},
+			contains: false,
+			position: dto.PositionOutside,
+		},
+		{
+			name:     "inside point within tolerance of boundary",
+			point:    dto.Point{Lon: 0.9995, Lat: 0.5},
+			opts:     dto.ContainsOptions{Tolerance: 100},
+			contains: true,
+			position: dto.PositionOnEdge,
+		},
+		{
+			name:     "inside point far from boundary",
+			point:    dto.Point{Lon: 0.5, Lat: 0.5},
+			opts:     dto.ContainsOptions{Predicate: dto.PredicateCovers, Tolerance: 100},
+			contains: true,
+			position: dto.PositionInside,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := s.ContainsPoint(ctx, []int{zoneId}, tt.point, tt.opts)
+			require.NoError(t, err)
+			require.Equal(t, []dto.ZoneContainsPointOut{{ZoneId: zoneId, Contains: tt.contains, Position: tt.position}}, actual)
+
+			anyContains, err := s.AnyContainsPoint(ctx, []int{zoneId}, tt.point, tt.opts)
+			require.NoError(t, err)
+			require.Equal(t, dto.AnyContainsPointOut{Contains: tt.contains, Position: tt.position}, anyContains)
+		})
+	}
+}
+
+func TestIndex_Schedule(t *testing.T) {
+	ctx := context.Background()
+	s := NewIndex()
+
+	const nightZoneId, alwaysZoneId = 1, 2
+	nightZone := loadedZone(t, nightZoneId, polygonGeoJson)
+	nightZone.Schedule = &dto.ZoneSchedule{
+		TimeZone: "Europe/Moscow",
+		Windows:  []dto.ScheduleWindow{{Weekdays: []string{"mon"}, TimeRange: dto.TimeRange{Start: "22:00", End: "06:00"}}},
+	}
+	require.NoError(t, s.LoadZone(nightZone))
+	require.NoError(t, s.LoadZone(loadedZone(t, alwaysZoneId, polygonGeoJson)))
+
+	// Monday 23:00 and Tuesday 12:00 in Moscow.
+	night := time.Date(2030, 3, 4, 20, 0, 0, 0, time.UTC)
+	day := time.Date(2030, 3, 5, 9, 0, 0, 0, time.UTC)
+	point := dto.Point{Lon: 0.5, Lat: 0.5}
+
+	contains, err := s.ContainsPoint(ctx, []int{nightZoneId, alwaysZoneId}, point, dto.ContainsOptions{At: &night})
+	require.NoError(t, err)
+	require.Equal(t, []dto.ZoneContainsPointOut{
+		{ZoneId: nightZoneId, Contains: true, Position: dto.PositionInside},
+		{ZoneId: alwaysZoneId, Contains: true, Position: dto.PositionInside},
+	}, contains)
+
+	contains, err = s.ContainsPoint(ctx, []int{nightZoneId, alwaysZoneId}, point, dto.ContainsOptions{At: &day})
+	require.NoError(t, err)
+	require.Equal(t, []dto.ZoneContainsPointOut{
+		{ZoneId: nightZoneId, Contains: false, Position: dto.PositionOutside},
+		{ZoneId: alwaysZoneId, Contains: true, Position: dto.PositionInside},
+	}, contains)
+
+	batch, err := s.ButchAnyZoneContainsPoint(ctx, dto.BatchZoneContainsPointInCollection{
+		{Key: "night", ZoneIds: []int{nightZoneId}, Point: point, ContainsOptions: dto.ContainsOptions{At: &night}},
+		{Key: "day", ZoneIds: []int{nightZoneId}, Point: point, ContainsOptions: dto.ContainsOptions{At: &day}},
+	})
+	require.NoError(t, err)
+	require.True(t, batch[0].Contains)
+	require.False(t, batch[1].Contains)
+}
@@ -0,0 +1,255 @@
+package memory
+
+import (
+	"math"
+)
+
+const (
+	rtreeMaxEntries = 16
+	rtreeMinEntries = 4
+)
+
+type rect struct {
+	minX, minY, maxX, maxY float64
+}
+
+func pointRect(x, y float64) rect {
+	return rect{minX: x, minY: y, maxX: x, maxY: y}
+}
+
+func (r rect) intersects(o rect) bool {
+	return r.minX <= o.maxX && o.minX <= r.maxX && r.minY <= o.maxY && o.minY <= r.maxY
+}
+
+func (r rect) extend(o rect) rect {
+	return rect{
+		minX: math.Min(r.minX, o.minX),
+		minY: math.Min(r.minY, o.minY),
+		maxX: math.Max(r.maxX, o.maxX),
+		maxY: math.Max(r.maxY, o.maxY),
+	}
+}
+
+func (r rect) area() float64 {
+	return (r.maxX - r.minX) * (r.maxY - r.minY)
+}
+
+func (r rect) enlargement(o rect) float64 {
+	return r.extend(o).area() - r.area()
+}
+
+type rtreeEntry struct {
+	bbox  rect
+	child *rtreeNode
+	item  *feature
+}
+
+type rtreeNode struct {
+	leaf    bool
+	entries []rtreeEntry
+}
+
+// rtree is a Guttman R-tree with quadratic split over feature bounding boxes.
+// It is not safe for concurrent use, callers are expected to guard it.
+type rtree struct {
+	root *rtreeNode
+	size int
+}
+
+func newRtree() *rtree {
+	return &rtree{root: &rtreeNode{leaf: true}}
+}
+
+func (t *rtree) insert(bbox rect, item *feature) {
+	if split := t.root.insert(rtreeEntry{bbox: bbox, item: item}); split != nil {
+		root := t.root
+		t.root = &rtreeNode{entries: []rtreeEntry{
+			{bbox: root.bounds(), child: root},
+			{bbox: split.bounds(), child: split},
+		}}
+	}
+	t.size++
+}
+
+func (t *rtree) remove(bbox rect, item *feature) bool {
+	var orphans []rtreeEntry
+	if !t.root.remove(bbox, item, &orphans) {
+		return false
+	}
+	t.size--
+
+	for !t.root.leaf && len(t.root.entries) == 1 {
+		t.root = t.root.entries[0].child
+	}
+	if !t.root.leaf && len(t.root.entries) == 0 {
+		t.root = &rtreeNode{leaf: true}
+	}
+	for _, orphan := range orphans {
+		t.size--
+		t.insert(orphan.bbox, orphan.item)
+	}
+	return true
+}
+
+// search calls fn for every item whose bounding box intersects r until fn returns false.
+func (t *rtree) search(r rect, fn func(item *feature) bool) {
+	t.root.search(r, fn)
+}
+
+func (n *rtreeNode) bounds() rect {
+	if len(n.entries) == 0 {
+		return rect{}
+	}
+	bounds := n.entries[0].bbox
+	for _, e := range n.entries[1:] {
+		bounds = bounds.extend(e.bbox)
+	}
+	return bounds
+}
+
+func (n *rtreeNode) insert(e rtreeEntry) *rtreeNode {
+	if n.leaf {
+		n.entries = append(n.entries, e)
+	} else {
+		i := n.chooseSubtree(e.bbox)
+		child := n.entries[i].child
+		split := child.insert(e)
+		n.entries[i].bbox = child.bounds()
+		if split != nil {
+			n.entries = append(n.entries, rtreeEntry{bbox: split.bounds(), child: split})
+		}
+	}
+
+	if len(n.entries) > rtreeMaxEntries {
+		return n.split()
+	}
+	return nil
+}
+
+func (n *rtreeNode) chooseSubtree(bbox rect) int {
+	best := 0
+	bestEnlargement, bestArea := math.Inf(1), math.Inf(1)
+	for i, e := range n.entries {
+		enlargement, area := e.bbox.enlargement(bbox), e.bbox.area()
+		if enlargement < bestEnlargement || (enlargement == bestEnlargement && area < bestArea) {
+			best, bestEnlargement, bestArea = i, enlargement, area
+		}
+	}
+	return best
+}
+
+func (n *rtreeNode) split() *rtreeNode {
+	entries := n.entries
+
+	// Pick the pair of seeds that would waste the most area being in one node.
+	seed1, seed2 := 0, 1
+	worst := math.Inf(-1)
+	for i := 0; i < len(entries); i++ {
+		for j := i + 1; j < len(entries); j++ {
+			d := entries[i].bbox.extend(entries[j].bbox).area() - entries[i].bbox.area() - entries[j].bbox.area()
+			if d > worst {
+				seed1, seed2, worst = i, j, d
+			}
+		}
+	}
+
+	left, right := []rtreeEntry{entries[seed1]}, []rtreeEntry{entries[seed2]}
+	leftBounds, rightBounds := entries[seed1].bbox, entries[seed2].bbox
+	remaining := make([]rtreeEntry, 0, len(entries)-2)
+	for i, e := range entries {
+		if i != seed1 && i != seed2 {
+			remaining = append(remaining, e)
+		}
+	}
+
+	for len(remaining) > 0 {
+		if len(left)+len(remaining) == rtreeMinEntries {
+			left = append(left, remaining...)
+			break
+		}
+		if len(right)+len(remaining) == rtreeMinEntries {
+			right = append(right, remaining...)
+			break
+		}
+
+		next, maxDiff := 0, math.Inf(-1)
+		for i, e := range remaining {
+			diff := math.Abs(leftBounds.enlargement(e.bbox) - rightBounds.enlargement(e.bbox))
+			if diff > maxDiff {
+				next, maxDiff = i, diff
+			}
+		}
+		e := remaining[next]
+		remaining = append(remaining[:next], remaining[next+1:]...)
+
+		d1, d2 := leftBounds.enlargement(e.bbox), rightBounds.enlargement(e.bbox)
+		toLeft := d1 < d2
+		if d1 == d2 {
+			toLeft = leftBounds.area() < rightBounds.area() ||
+				(leftBounds.area() == rightBounds.area() && len(left) <= len(right))
+		}
+		if toLeft {
+			left = append(left, e)
+			leftBounds = leftBounds.extend(e.bbox)
+		} else {
+			right = append(right, e)
+			rightBounds = rightBounds.extend(e.bbox)
+		}
+	}
+
+	n.entries = left
+	return &rtreeNode{leaf: n.leaf, entries: right}
+}
+
+func (n *rtreeNode) remove(bbox rect, item *feature, orphans *[]rtreeEntry) bool {
+	if n.leaf {
+		for i, e := range n.entries {
+			if e.item == item {
+				n.entries = append(n.entries[:i], n.entries[i+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := range n.entries {
+		e := &n.entries[i]
+		if !e.bbox.intersects(bbox) || !e.child.remove(bbox, item, orphans) {
+			continue
+		}
+		if len(e.child.entries) < rtreeMinEntries {
+			e.child.collect(orphans)
+			n.entries = append(n.entries[:i], n.entries[i+1:]...)
+		} else {
+			e.bbox = e.child.bounds()
+		}
+		return true
+	}
+	return false
+}
+
+func (n *rtreeNode) collect(dst *[]rtreeEntry) {
+	if n.leaf {
+		*dst = append(*dst, n.entries...)
+		return
+	}
+	for _, e := range n.entries {
+		e.child.collect(dst)
+	}
+}
+
+func (n *rtreeNode) search(r rect, fn func(item *feature) bool) bool {
+	for _, e := range n.entries {
+		if !e.bbox.intersects(r) {
+			continue
+		}
+		if n.leaf {
+			if !fn(e.item) {
+				return false
+			}
+		} else if !e.child.search(r, fn) {
+			return false
+		}
+	}
+	return true
+}
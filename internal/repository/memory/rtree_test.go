@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func searchAll(tree *rtree, r rect) []*feature {
+	var found []*feature
+	tree.search(r, func(item *feature) bool {
+		found = append(found, item)
+		return true
+	})
+	return found
+}
+
+func TestRtree_InsertSearchRemove(t *testing.T) {
+	tree := newRtree()
+	items := make([]*feature, 0, 1000)
+
+	for i := 0; i < 1000; i++ {
+		x, y := float64(i%50), float64(i/50)
+		f := &feature{zoneId: i, bbox: rect{minX: x, minY: y, maxX: x + 0.5, maxY: y + 0.5}}
+		items = append(items, f)
+		tree.insert(f.bbox, f)
+	}
+	require.Equal(t, 1000, tree.size)
+
+	found := searchAll(tree, pointRect(10.25, 3.25))
+	require.Len(t, found, 1)
+	require.Equal(t, 3*50+10, found[0].zoneId)
+
+	require.Empty(t, searchAll(tree, pointRect(10.75, 3.75)))
+	require.Len(t, searchAll(tree, rect{minX: 0, minY: 0, maxX: 4.9, maxY: 1.9}), 10)
+
+	for _, f := range items[:900] {
+		require.True(t, tree.remove(f.bbox, f))
+	}
+	require.False(t, tree.remove(items[0].bbox, items[0]))
+	require.Equal(t, 100, tree.size)
+	require.Empty(t, searchAll(tree, pointRect(10.25, 3.25)))
+	require.Len(t, searchAll(tree, rect{minX: -1, minY: -1, maxX: 100, maxY: 100}), 100)
+
+	for _, f := range items[900:] {
+		require.True(t, tree.remove(f.bbox, f))
+	}
+	require.Equal(t, 0, tree.size)
+	require.True(t, tree.root.leaf)
+}
+
+func TestRtree_SearchStops(t *testing.T) {
+	tree := newRtree()
+	for i := 0; i < 100; i++ {
+		f := &feature{zoneId: i, bbox: rect{minX: 0, minY: 0, maxX: 1, maxY: 1}}
+		tree.insert(f.bbox, f)
+	}
+
+	calls := 0
+	tree.search(pointRect(0.5, 0.5), func(item *feature) bool {
+		calls++
+		return false
+	})
+	require.Equal(t, 1, calls)
+}
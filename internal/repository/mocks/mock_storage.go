@@ -7,6 +7,7 @@ package mock_zone
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	geojson "github.com/maxsnegir/zones_service/internal/domain/geojson"
@@ -36,6 +37,37 @@ func (m *MockSaver) EXPECT() *MockSaverMockRecorder {
 	return m.recorder
 }
 
+// ImportZones mocks base method.
+func (m *MockSaver) ImportZones(ctx context.Context, zones geojson.Source) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportZones", ctx, zones)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportZones indicates an expected call of ImportZones.
+func (mr *MockSaverMockRecorder) ImportZones(ctx, zones interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportZones", reflect.TypeOf((*MockSaver)(nil).ImportZones), ctx, zones)
+}
+
+// RepairGeometries mocks base method.
+func (m *MockSaver) RepairGeometries(ctx context.Context, featureCollection geojson.FeatureCollection) (geojson.FeatureCollection, []dto.GeometryRepair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepairGeometries", ctx, featureCollection)
+	ret0, _ := ret[0].(geojson.FeatureCollection)
+	ret1, _ := ret[1].([]dto.GeometryRepair)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RepairGeometries indicates an expected call of RepairGeometries.
+func (mr *MockSaverMockRecorder) RepairGeometries(ctx, featureCollection interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepairGeometries", reflect.TypeOf((*MockSaver)(nil).RepairGeometries), ctx, featureCollection)
+}
+
 // SaveZoneFromFeatureCollection mocks base method.
 func (m *MockSaver) SaveZoneFromFeatureCollection(ctx context.Context, featureCollection geojson.FeatureCollection) (int, error) {
 	m.ctrl.T.Helper()
@@ -51,6 +83,34 @@ func (mr *MockSaverMockRecorder) SaveZoneFromFeatureCollection(ctx, featureColle
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveZoneFromFeatureCollection", reflect.TypeOf((*MockSaver)(nil).SaveZoneFromFeatureCollection), ctx, featureCollection)
 }
 
+// UpdateZoneFromFeatureCollection mocks base method.
+func (m *MockSaver) UpdateZoneFromFeatureCollection(ctx context.Context, zoneId int, featureCollection geojson.FeatureCollection) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateZoneFromFeatureCollection", ctx, zoneId, featureCollection)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateZoneFromFeatureCollection indicates an expected call of UpdateZoneFromFeatureCollection.
+func (mr *MockSaverMockRecorder) UpdateZoneFromFeatureCollection(ctx, zoneId, featureCollection interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateZoneFromFeatureCollection", reflect.TypeOf((*MockSaver)(nil).UpdateZoneFromFeatureCollection), ctx, zoneId, featureCollection)
+}
+
+// UpdateZoneProperties mocks base method.
+func (m *MockSaver) UpdateZoneProperties(ctx context.Context, zoneId int, properties []map[string]interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateZoneProperties", ctx, zoneId, properties)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateZoneProperties indicates an expected call of UpdateZoneProperties.
+func (mr *MockSaverMockRecorder) UpdateZoneProperties(ctx, zoneId, properties interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateZoneProperties", reflect.TypeOf((*MockSaver)(nil).UpdateZoneProperties), ctx, zoneId, properties)
+}
+
 // MockDeleter is a mock of Deleter interface.
 type MockDeleter struct {
 	ctrl     *gomock.Controller
@@ -112,18 +172,18 @@ func (m *MockProvider) EXPECT() *MockProviderMockRecorder {
 }
 
 // AnyContainsPoint mocks base method.
-func (m *MockProvider) AnyContainsPoint(ctx context.Context, ids []int, point dto.Point) (bool, error) {
+func (m *MockProvider) AnyContainsPoint(ctx context.Context, ids []int, point dto.Point, opts dto.ContainsOptions) (dto.AnyContainsPointOut, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AnyContainsPoint", ctx, ids, point)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "AnyContainsPoint", ctx, ids, point, opts)
+	ret0, _ := ret[0].(dto.AnyContainsPointOut)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AnyContainsPoint indicates an expected call of AnyContainsPoint.
-func (mr *MockProviderMockRecorder) AnyContainsPoint(ctx, ids, point interface{}) *gomock.Call {
+func (mr *MockProviderMockRecorder) AnyContainsPoint(ctx, ids, point, opts interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnyContainsPoint", reflect.TypeOf((*MockProvider)(nil).AnyContainsPoint), ctx, ids, point)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnyContainsPoint", reflect.TypeOf((*MockProvider)(nil).AnyContainsPoint), ctx, ids, point, opts)
 }
 
 // ButchAnyZoneContainsPoint mocks base method.
@@ -142,33 +202,107 @@ func (mr *MockProviderMockRecorder) ButchAnyZoneContainsPoint(ctx, in interface{
 }
 
 // ContainsPoint mocks base method.
-func (m *MockProvider) ContainsPoint(ctx context.Context, ids []int, point dto.Point) ([]dto.ZoneContainsPointOut, error) {
+func (m *MockProvider) ContainsPoint(ctx context.Context, ids []int, point dto.Point, opts dto.ContainsOptions) ([]dto.ZoneContainsPointOut, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ContainsPoint", ctx, ids, point)
+	ret := m.ctrl.Call(m, "ContainsPoint", ctx, ids, point, opts)
 	ret0, _ := ret[0].([]dto.ZoneContainsPointOut)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ContainsPoint indicates an expected call of ContainsPoint.
-func (mr *MockProviderMockRecorder) ContainsPoint(ctx, ids, point interface{}) *gomock.Call {
+func (mr *MockProviderMockRecorder) ContainsPoint(ctx, ids, point, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContainsPoint", reflect.TypeOf((*MockProvider)(nil).ContainsPoint), ctx, ids, point, opts)
+}
+
+// ExportZones mocks base method.
+func (m *MockProvider) ExportZones(ctx context.Context, filter dto.ZonesFilter, fn func(dto.ZoneGeoJSON) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportZones", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportZones indicates an expected call of ExportZones.
+func (mr *MockProviderMockRecorder) ExportZones(ctx, filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportZones", reflect.TypeOf((*MockProvider)(nil).ExportZones), ctx, filter, fn)
+}
+
+// FindZonesContainingPoint mocks base method.
+func (m *MockProvider) FindZonesContainingPoint(ctx context.Context, point dto.Point) ([]dto.ZoneGeoJSON, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindZonesContainingPoint", ctx, point)
+	ret0, _ := ret[0].([]dto.ZoneGeoJSON)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindZonesContainingPoint indicates an expected call of FindZonesContainingPoint.
+func (mr *MockProviderMockRecorder) FindZonesContainingPoint(ctx, point interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindZonesContainingPoint", reflect.TypeOf((*MockProvider)(nil).FindZonesContainingPoint), ctx, point)
+}
+
+// GetLayerZoneIds mocks base method.
+func (m *MockProvider) GetLayerZoneIds(ctx context.Context, layer string) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLayerZoneIds", ctx, layer)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLayerZoneIds indicates an expected call of GetLayerZoneIds.
+func (mr *MockProviderMockRecorder) GetLayerZoneIds(ctx, layer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLayerZoneIds", reflect.TypeOf((*MockProvider)(nil).GetLayerZoneIds), ctx, layer)
+}
+
+// GetZoneByExternalKey mocks base method.
+func (m *MockProvider) GetZoneByExternalKey(ctx context.Context, key string) (dto.ZoneGeoJSON, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetZoneByExternalKey", ctx, key)
+	ret0, _ := ret[0].(dto.ZoneGeoJSON)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetZoneByExternalKey indicates an expected call of GetZoneByExternalKey.
+func (mr *MockProviderMockRecorder) GetZoneByExternalKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetZoneByExternalKey", reflect.TypeOf((*MockProvider)(nil).GetZoneByExternalKey), ctx, key)
+}
+
+// GetZoneVersions mocks base method.
+func (m *MockProvider) GetZoneVersions(ctx context.Context, zoneId int) ([]dto.ZoneVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetZoneVersions", ctx, zoneId)
+	ret0, _ := ret[0].([]dto.ZoneVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetZoneVersions indicates an expected call of GetZoneVersions.
+func (mr *MockProviderMockRecorder) GetZoneVersions(ctx, zoneId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContainsPoint", reflect.TypeOf((*MockProvider)(nil).ContainsPoint), ctx, ids, point)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetZoneVersions", reflect.TypeOf((*MockProvider)(nil).GetZoneVersions), ctx, zoneId)
 }
 
 // GetZonesByIds mocks base method.
-func (m *MockProvider) GetZonesByIds(ctx context.Context, ids []int) ([]dto.ZoneGeoJSON, error) {
+func (m *MockProvider) GetZonesByIds(ctx context.Context, ids []int, at *time.Time, srid int) ([]dto.ZoneGeoJSON, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetZonesByIds", ctx, ids)
+	ret := m.ctrl.Call(m, "GetZonesByIds", ctx, ids, at, srid)
 	ret0, _ := ret[0].([]dto.ZoneGeoJSON)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetZonesByIds indicates an expected call of GetZonesByIds.
-func (mr *MockProviderMockRecorder) GetZonesByIds(ctx, ids interface{}) *gomock.Call {
+func (mr *MockProviderMockRecorder) GetZonesByIds(ctx, ids, at, srid interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetZonesByIds", reflect.TypeOf((*MockProvider)(nil).GetZonesByIds), ctx, ids)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetZonesByIds", reflect.TypeOf((*MockProvider)(nil).GetZonesByIds), ctx, ids, at, srid)
 }
 
 // GetZonesCount mocks base method.
@@ -185,3 +319,63 @@ func (mr *MockProviderMockRecorder) GetZonesCount(ctx interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetZonesCount", reflect.TypeOf((*MockProvider)(nil).GetZonesCount), ctx)
 }
+
+// GetZonesTile mocks base method.
+func (m *MockProvider) GetZonesTile(ctx context.Context, tile dto.TileIn) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetZonesTile", ctx, tile)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetZonesTile indicates an expected call of GetZonesTile.
+func (mr *MockProviderMockRecorder) GetZonesTile(ctx, tile interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetZonesTile", reflect.TypeOf((*MockProvider)(nil).GetZonesTile), ctx, tile)
+}
+
+// ListZones mocks base method.
+func (m *MockProvider) ListZones(ctx context.Context, in dto.ZonesListIn) ([]dto.ZoneGeoJSON, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListZones", ctx, in)
+	ret0, _ := ret[0].([]dto.ZoneGeoJSON)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListZones indicates an expected call of ListZones.
+func (mr *MockProviderMockRecorder) ListZones(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListZones", reflect.TypeOf((*MockProvider)(nil).ListZones), ctx, in)
+}
+
+// NearestZones mocks base method.
+func (m *MockProvider) NearestZones(ctx context.Context, ids []int, point dto.Point, limit int) ([]dto.NearestZoneOut, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NearestZones", ctx, ids, point, limit)
+	ret0, _ := ret[0].([]dto.NearestZoneOut)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NearestZones indicates an expected call of NearestZones.
+func (mr *MockProviderMockRecorder) NearestZones(ctx, ids, point, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NearestZones", reflect.TypeOf((*MockProvider)(nil).NearestZones), ctx, ids, point, limit)
+}
+
+// RouteCrossings mocks base method.
+func (m *MockProvider) RouteCrossings(ctx context.Context, ids []int, track []dto.Point) ([]dto.ZoneCrossingOut, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RouteCrossings", ctx, ids, track)
+	ret0, _ := ret[0].([]dto.ZoneCrossingOut)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RouteCrossings indicates an expected call of RouteCrossings.
+func (mr *MockProviderMockRecorder) RouteCrossings(ctx, ids, track interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RouteCrossings", reflect.TypeOf((*MockProvider)(nil).RouteCrossings), ctx, ids, track)
+}
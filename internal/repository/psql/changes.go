@@ -0,0 +1,173 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+// zoneChangeLockId serializes sequencing of the change log, see sequenceZoneChanges.
+const zoneChangeLockId = 7310001
+
+func (s *Storage) GetZoneChanges(ctx context.Context, afterSeq int64, limit int) ([]dto.ZoneChange, error) {
+	const op = "storage.GetZoneChanges"
+	const query = `
+		SELECT seq, type, zone_id, created_at
+		FROM zone_change
+		WHERE seq > $1
+		ORDER BY seq
+		LIMIT $2;`
+
+	rows, err := s.db.Query(ctx, query, afterSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get changes: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := make([]dto.ZoneChange, 0)
+	for rows.Next() {
+		var change dto.ZoneChange
+		if err = rows.Scan(&change.Seq, &change.Type, &change.ZoneId, &change.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan change: %w", op, err)
+		}
+		result = append(result, change)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read changes: %w", op, err)
+	}
+	return result, nil
+}
+
+// LastZoneChangeSeq returns the sequence number of the last numbered change, 0 when there is none.
+func (s *Storage) LastZoneChangeSeq(ctx context.Context) (int64, error) {
+	const op = "storage.LastZoneChangeSeq"
+	const query = `SELECT COALESCE(max(seq), 0) FROM zone_change;`
+
+	var seq int64
+	if err := s.db.QueryRow(ctx, query).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("%s: failed to get last change: %w", op, err)
+	}
+	return seq, nil
+}
+
+// OnZoneChanges registers fn to be called by every write of zones once its changes are
+// committed and numbered, before the write returns. It lets in-process caches see the
+// writes of their own instance at once. fn must be registered before the storage is used,
+// its errors are logged and do not fail the write.
+func (s *Storage) OnZoneChanges(fn func(ctx context.Context) error) {
+	s.onZoneChanges = fn
+}
+
+// recordZoneChange writes the change log entry and the webhook event in tx. It takes
+// no lock, so writes of zones do not wait for each other: the change gets no sequence
+// number yet. The writer commits tx with commitZoneChanges, which numbers the committed
+// changes and notifies listeners.
+func recordZoneChange(ctx context.Context, tx pgx.Tx, changeType dto.ZoneChangeType, zoneId int) error {
+	return recordZoneChanges(ctx, tx, []dto.ZoneChange{{Type: changeType, ZoneId: zoneId}})
+}
+
+// recordZoneChanges is recordZoneChange for many changes in one statement, they are
+// numbered in the given order. Only types and zone ids of changes are used.
+func recordZoneChanges(ctx context.Context, tx pgx.Tx, changes []dto.ZoneChange) error {
+	const op = "storage.recordZoneChanges"
+	const query = `
+		WITH change AS (
+			INSERT INTO zone_change (type, zone_id)
+			SELECT c.type, c.zone_id FROM unnest($1::text[], $2::int[]) WITH ORDINALITY c(type, zone_id, n) ORDER BY c.n
+		)
+		INSERT INTO webhook_event (type, payload)
+		SELECT e.type, jsonb_build_object('zone_id', e.zone_id)
+		FROM unnest($3::text[], $2::int[]) WITH ORDINALITY e(type, zone_id, n)
+		ORDER BY e.n;`
+
+	if len(changes) == 0 {
+		return nil
+	}
+	types := make([]string, 0, len(changes))
+	zoneIds := make([]int, 0, len(changes))
+	eventTypes := make([]string, 0, len(changes))
+	for _, change := range changes {
+		types = append(types, string(change.Type))
+		zoneIds = append(zoneIds, change.ZoneId)
+		eventTypes = append(eventTypes, string(change.Type.WebhookEventType()))
+	}
+	if _, err := tx.Exec(ctx, query, types, zoneIds, eventTypes); err != nil {
+		return fmt.Errorf("%s: failed to insert changes: %w", op, err)
+	}
+	return nil
+}
+
+// commitZoneChanges commits tx that recorded zone changes, sequences them and calls the
+// OnZoneChanges hook. The write is done once tx commits; when sequencing fails, the changes
+// are sequenced by the next write.
+func (s *Storage) commitZoneChanges(ctx context.Context, tx pgx.Tx) error {
+	const op = "storage.commitZoneChanges"
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	if err := s.sequenceZoneChanges(ctx); err != nil {
+		s.log.Error(fmt.Sprintf("%s: %v", op, err))
+		return nil
+	}
+	if s.onZoneChanges != nil {
+		if err := s.onZoneChanges(ctx); err != nil {
+			s.log.Error(fmt.Sprintf("%s: %v", op, err))
+		}
+	}
+	return nil
+}
+
+// sequenceZoneChanges numbers committed changes without a sequence number in the order
+// they were written and notifies listeners. Sequencing runs in its own short transaction
+// under an advisory lock, so numbers become visible in order and readers never skip a
+// change, while transactions writing zones never hold the lock.
+func (s *Storage) sequenceZoneChanges(ctx context.Context) (err error) {
+	const op = "storage.sequenceZoneChanges"
+	const lockQuery = `SELECT pg_advisory_xact_lock($1);`
+	const query = `
+		WITH pending AS (
+			SELECT id, row_number() OVER (ORDER BY id) AS n
+			FROM zone_change
+			WHERE seq IS NULL
+		), last AS (
+			SELECT COALESCE(max(seq), 0) AS seq FROM zone_change
+		), change AS (
+			UPDATE zone_change c
+			SET seq = last.seq + p.n
+			FROM pending p, last
+			WHERE c.id = p.id
+			RETURNING c.seq, c.type, c.zone_id, c.created_at
+		)
+		SELECT pg_notify($1, json_build_object(
+			'seq', c.seq, 'type', c.type, 'zone_id', c.zone_id, 'created_at', c.created_at
+		)::text)
+		FROM (SELECT * FROM change ORDER BY seq) c;`
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("%s: failed to start transaction: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, lockQuery, zoneChangeLockId); err != nil {
+		return fmt.Errorf("%s: failed to lock change log: %w", op, err)
+	}
+	if _, err = tx.Exec(ctx, query, zoneChangesChannel); err != nil {
+		return fmt.Errorf("%s: failed to sequence changes: %w", op, err)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+func TestZoneChanges_SequencedOnCommit(t *testing.T) {
+	ctx := context.Background()
+
+	storage, err := NewTestStorage(ctx)
+	if err != nil {
+		t.Skipf("postgres is not available: %v", err)
+	}
+	defer storage.ShutDown()
+
+	var featureCollectionJson dto.FeatureCollectionJSON
+	require.NoError(t, json.Unmarshal([]byte(listenerPolygonGeoJson), &featureCollectionJson))
+	var featureCollection geojson.FeatureCollection
+	require.NoError(t, featureCollection.FromFeatureCollectionJSON(featureCollectionJson))
+
+	tx, err := storage.db.BeginTx(ctx, pgx.TxOptions{})
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback(ctx) }()
+	require.NoError(t, recordZoneChange(ctx, tx, dto.ZoneChangeDeleted, 100))
+
+	// Another write does not wait for the open transaction.
+	writeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	zoneId, err := storage.SaveZoneFromFeatureCollection(writeCtx, featureCollection)
+	require.NoError(t, err)
+
+	changes, err := storage.GetZoneChanges(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, int64(1), changes[0].Seq)
+	require.Equal(t, zoneId, changes[0].ZoneId)
+
+	// The change written first is numbered when it commits, after the committed one.
+	require.NoError(t, storage.commitZoneChanges(ctx, tx))
+	changes, err = storage.GetZoneChanges(ctx, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, int64(2), changes[0].Seq)
+	require.Equal(t, 100, changes[0].ZoneId)
+	require.Equal(t, dto.ZoneChangeDeleted, changes[0].Type)
+}
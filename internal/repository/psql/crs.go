@@ -0,0 +1,45 @@
+package psql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+// knownSRIDs returns the srids defined in spatial_ref_sys, the ones ST_Transform can reproject.
+func (s *Storage) knownSRIDs(ctx context.Context, srids []int) (map[int]struct{}, error) {
+	const op = "storage.knownSRIDs"
+	const query = `SELECT srid FROM spatial_ref_sys WHERE srid = any($1);`
+
+	rows, err := s.db.Query(ctx, query, srids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get srids: %w", op, err)
+	}
+	known, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get srids: %w", op, err)
+	}
+	result := make(map[int]struct{}, len(known))
+	for _, srid := range known {
+		result[srid] = struct{}{}
+	}
+	return result, nil
+}
+
+// checkSRID returns dto.ErrUnknownCRS when spatial_ref_sys does not define the srid.
+func (s *Storage) checkSRID(ctx context.Context, srid int) error {
+	if srid == dto.WGS84 {
+		return nil
+	}
+	known, err := s.knownSRIDs(ctx, []int{srid})
+	if err != nil {
+		return err
+	}
+	if _, ok := known[srid]; !ok {
+		return dto.ErrUnknownCRS
+	}
+	return nil
+}
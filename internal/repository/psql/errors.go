@@ -2,12 +2,27 @@ package psql
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
-	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
 )
 
-const internalPostgresErrorCode = "XX000"
+const (
+	internalPostgresErrorCode = "XX000"
+	dataExceptionErrorClass   = "22"
+	uniqueViolationErrorCode  = "23505"
+	foreignKeyErrorCode       = "23503"
+
+	zoneExternalKeyConstraint = "zone_external_key_key"
+	zoneLayerConstraint       = "zone_layer_fkey"
+	layerPrimaryKeyConstraint = "layer_pkey"
+)
 
+// PostgisValidationErr is a geometry PostGIS fails to build. Geometries are validated
+// before they are stored, so it is left for geometries that pass the validation.
 type PostgisValidationErr struct {
 	Message string
 }
@@ -22,6 +37,34 @@ func parsePostgisError(err error) error {
 	if !ok || e.Code != internalPostgresErrorCode {
 		return err
 	}
-	// ToDo find a way to define postgis validation errors
 	return PostgisValidationErr{Message: e.Message}
 }
+
+func parseZoneError(err error) error {
+	var e *pgconn.PgError
+	if !errors.As(err, &e) {
+		return err
+	}
+	switch {
+	case e.Code == uniqueViolationErrorCode && e.ConstraintName == zoneExternalKeyConstraint:
+		return dto.ErrExternalKeyExists
+	case e.Code == foreignKeyErrorCode && e.ConstraintName == zoneLayerConstraint:
+		return dto.ErrLayerNotFound
+	case e.Code == uniqueViolationErrorCode && e.ConstraintName == layerPrimaryKeyConstraint:
+		return dto.ErrLayerExists
+	}
+	return err
+}
+
+// parseBatchItemError marks the errors the input values cause, data exceptions and PostGIS
+// errors, with dto.ErrBatchItemRejected. Other errors are not about an item.
+func parseBatchItemError(err error) error {
+	var e *pgconn.PgError
+	if !errors.As(err, &e) {
+		return err
+	}
+	if e.Code == internalPostgresErrorCode || strings.HasPrefix(e.Code, dataExceptionErrorClass) {
+		return fmt.Errorf("%w: %w", dto.ErrBatchItemRejected, err)
+	}
+	return err
+}
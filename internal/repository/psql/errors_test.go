@@ -1,10 +1,14 @@
 package psql
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
-	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
 )
 
 type someErr string
@@ -30,6 +34,11 @@ func TestParsePostgisError(t *testing.T) {
 			err:  &pgconn.PgError{Code: "23505", Message: "unique_violation"},
 			want: &pgconn.PgError{Code: "23505", Message: "unique_violation"},
 		},
+		{
+			name: "wrapped postgres internal error",
+			err:  fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: internalPostgresErrorCode, Message: "geometry contains non-closed rings"}),
+			want: PostgisValidationErr{Message: "geometry contains non-closed rings"},
+		},
 		{
 			name: "postgres internal error",
 			err:  &pgconn.PgError{Code: internalPostgresErrorCode, Message: "self-intersection"},
@@ -44,3 +53,69 @@ func TestParsePostgisError(t *testing.T) {
 		})
 	}
 }
+
+func TestParseZoneError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "any error",
+			err:  someErr("some error"),
+			want: someErr("some error"),
+		},
+		{
+			name: "other unique violation",
+			err:  &pgconn.PgError{Code: uniqueViolationErrorCode, ConstraintName: "zone_pkey"},
+			want: &pgconn.PgError{Code: uniqueViolationErrorCode, ConstraintName: "zone_pkey"},
+		},
+		{
+			name: "external key unique violation",
+			err:  fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: uniqueViolationErrorCode, ConstraintName: zoneExternalKeyConstraint}),
+			want: dto.ErrExternalKeyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseZoneError(tt.err)
+			require.Equal(t, tt.want, err)
+		})
+	}
+}
+
+func TestParseBatchItemError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		rejected bool
+	}{
+		{
+			name: "any error",
+			err:  someErr("connection refused"),
+		},
+		{
+			name: "unique violation",
+			err:  &pgconn.PgError{Code: uniqueViolationErrorCode},
+		},
+		{
+			name:     "data exception",
+			err:      &pgconn.PgError{Code: "22003", Message: "value out of range"},
+			rejected: true,
+		},
+		{
+			name:     "postgis error",
+			err:      fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: internalPostgresErrorCode, Message: "Coordinate values are out of range"}),
+			rejected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseBatchItemError(tt.err)
+			require.Equal(t, tt.rejected, errors.Is(err, dto.ErrBatchItemRejected))
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package psql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+// zonesFilterCondition applies dto.ZonesFilter passed as $1 from id, $2 to id,
+// $3 after id, $4 layer and $5 tag to zone z.
+const zonesFilterCondition = `
+		WHERE z.id >= $1 AND ($2 = 0 OR z.id <= $2) AND z.id > $3
+		  AND ($4 = '' OR z.layer = $4)
+		  AND ($5 = '' OR $5 = any(z.tags))`
+
+// ExportZones calls fn for every zone passing the filter in id order. Rows are read
+// as fn consumes them, so the zones are never held in memory at once.
+func (s *Storage) ExportZones(ctx context.Context, filter dto.ZonesFilter, fn func(dto.ZoneGeoJSON) error) error {
+	const op = "storage.ExportZones"
+	const query = selectZonesQuery + zonesFilterCondition + `
+		GROUP BY z.id
+		ORDER BY z.id;`
+
+	rows, err := s.db.Query(ctx, query, filter.FromId, filter.ToId, filter.After, filter.Layer, filter.Tag)
+	if err != nil {
+		return fmt.Errorf("%s: failed to get zones: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		zone, err := scanZone(rows)
+		if err != nil {
+			return fmt.Errorf("%s: failed to scan zone: %w", op, err)
+		}
+		if err = fn(zone); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("%s: failed to read zones: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package psql
+
+import (
+	"context"
+	baseErr "errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+// ImportZones loads the zones in a single transaction. They are copied with COPY into a
+// temporary table as the source yields them, so they are not held at once, and created
+// from it. Zones with invalid geometries, unknown crs, taken external keys or unknown
+// layers are rejected with dto.ImportZonesErr by their index in the source, nothing is
+// imported then or when the source fails. Geometries are reprojected from their crs to EPSG:4326.
+func (s *Storage) ImportZones(ctx context.Context, zones geojson.Source) ([]int, error) {
+	const op = "storage.ImportZones"
+	// Every zone gets a row without a position, and a row per feature.
+	const createImportTableQuery = `
+		CREATE TEMP TABLE zone_import
+		(
+			idx             INT,
+			position        INT,
+			name            TEXT,
+			external_key    TEXT,
+			tags            TEXT[],
+			schedule        JSONB,
+			layer           TEXT,
+			geom            BYTEA,
+			radius          DOUBLE PRECISION,
+			srid            INT,
+			properties      JSON,
+			feature_id      JSONB,
+			foreign_members JSONB
+		) ON COMMIT DROP;`
+	const reserveIdsQuery = `SELECT nextval(pg_get_serial_sequence('zone', 'id')) FROM generate_series(1, $1);`
+	const insertZonesQuery = `
+		INSERT INTO zone (id, name, external_key, tags, schedule, layer, foreign_members)
+		SELECT ($1::int[])[i.idx + 1], i.name, i.external_key, i.tags, i.schedule, i.layer, i.foreign_members
+		FROM zone_import i
+		WHERE i.position IS NULL
+		ORDER BY i.idx;`
+	const insertGeometriesQuery = `
+		INSERT INTO zone_geometry (zone_id, geom, source_geom, radius, properties, feature_id, foreign_members)
+		SELECT ($1::int[])[i.idx + 1], ` + arealGeometry + `, ` + sourceGeometry + `, g.radius, i.properties, i.feature_id, i.foreign_members
+		FROM zone_import i
+		CROSS JOIN LATERAL (SELECT ST_Transform(ST_SetSRID(ST_GeomFromEWKB(i.geom), i.srid), 4326) AS geom, i.radius) g
+		WHERE i.position IS NOT NULL
+		ORDER BY i.idx, i.position;`
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to start transaction: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			rollbackErr := tx.Rollback(ctx)
+			if rollbackErr != nil {
+				err = baseErr.Join(err, rollbackErr)
+			}
+			return
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, createImportTableQuery); err != nil {
+		return nil, fmt.Errorf("%s: failed to create import table: %w", op, err)
+	}
+
+	copySource := &importCopySource{zones: zones, geometryErrors: make(map[int][]dto.GeometryError)}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"zone_import"},
+		[]string{
+			"idx", "position", "name", "external_key", "tags", "schedule", "layer",
+			"geom", "radius", "srid", "properties", "feature_id", "foreign_members",
+		},
+		copySource,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to copy zones: %w", op, err)
+	}
+	if err = zones.Err(); err != nil {
+		return nil, err
+	}
+	count := copySource.count
+	if count == 0 {
+		if err = tx.Rollback(ctx); err != nil {
+			return nil, fmt.Errorf("%s: failed to rollback transaction: %w", op, err)
+		}
+		return []int{}, nil
+	}
+
+	if err = importRejections(ctx, tx, copySource.geometryErrors); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, reserveIdsQuery, count)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to reserve ids: %w", op, err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to reserve ids: %w", op, err)
+	}
+	if _, err = tx.Exec(ctx, insertZonesQuery, ids); err != nil {
+		return nil, parseZoneError(fmt.Errorf("%s: failed to insert zones: %w", op, err))
+	}
+	if _, err = tx.Exec(ctx, insertGeometriesQuery, ids); err != nil {
+		return nil, parsePostgisError(err)
+	}
+
+	if err = addFirstZoneVersions(ctx, tx, ids); err != nil {
+		return nil, err
+	}
+	changes := make([]dto.ZoneChange, 0, len(ids))
+	for _, id := range ids {
+		changes = append(changes, dto.ZoneChange{Type: dto.ZoneChangeCreated, ZoneId: id})
+	}
+	if err = recordZoneChanges(ctx, tx, changes); err != nil {
+		return nil, err
+	}
+	if err = s.commitZoneChanges(ctx, tx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return ids, nil
+}
+
+// importCopySource copies the rows of zone_import as the zones are yielded. Only the rows
+// of the zone being copied and the errors of invalid geometries are held.
+type importCopySource struct {
+	zones          geojson.Source
+	count          int
+	geometryErrors map[int][]dto.GeometryError
+	pending        [][]any
+	row            []any
+	err            error
+}
+
+func (s *importCopySource) Next() bool {
+	for len(s.pending) == 0 {
+		if !s.zones.Next() {
+			return false
+		}
+		rows, errs, err := importRows(s.count, s.zones.FeatureCollection())
+		if err != nil {
+			s.err = err
+			return false
+		}
+		if len(errs) > 0 {
+			s.geometryErrors[s.count] = errs
+		}
+		s.pending = rows
+		s.count++
+	}
+	s.row, s.pending = s.pending[0], s.pending[1:]
+	return true
+}
+
+func (s *importCopySource) Values() ([]any, error) {
+	return s.row, nil
+}
+
+func (s *importCopySource) Err() error {
+	return s.err
+}
+
+// importRows returns the rows of zone_import of the zone with index idx and the errors of
+// its geometries found without PostGIS. Invalid geometries are not copied.
+func importRows(idx int, featureCollection geojson.FeatureCollection) ([][]any, []dto.GeometryError, error) {
+	metadata := dto.ZoneMetadata{Layer: dto.DefaultLayer}
+	if featureCollection.Metadata != nil {
+		metadata = *featureCollection.Metadata
+		if metadata.Layer == "" {
+			metadata.Layer = dto.DefaultLayer
+		}
+	}
+	members, err := foreignMembersOrNull(featureCollection.ForeignMembers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode foreign members: %w", err)
+	}
+	srid := featureCollection.CRS()
+	rows := make([][]any, 0, len(featureCollection.Features)+1)
+	rows = append(rows, []any{
+		idx, nil, metadata.Name, metadata.ExternalKey, tagsOrEmpty(metadata.Tags), metadata.Schedule, metadata.Layer,
+		nil, nil, srid, nil, nil, members,
+	})
+
+	geometryErrors := featureCollection.Validate()
+	invalid := make(map[int]struct{}, len(geometryErrors))
+	for _, geometryErr := range geometryErrors {
+		invalid[geometryErr.Feature] = struct{}{}
+	}
+	for position, feature := range featureCollection.Features {
+		var geometry any
+		if _, ok := invalid[position]; !ok {
+			geometry = feature.Geometry.ToEwkb()
+		}
+		if members, err = foreignMembersOrNull(feature.ForeignMembers); err != nil {
+			return nil, nil, fmt.Errorf("failed to encode foreign members: %w", err)
+		}
+		rows = append(rows, []any{
+			idx, position, nil, nil, nil, nil, nil,
+			geometry, radiusOrNull(feature.Radius), srid, feature.Properties, []byte(feature.Id), members,
+		})
+	}
+	return rows, geometryErrors, nil
+}
+
+// importRejections rejects the zones of zone_import with unknown crs, invalid geometries,
+// external keys taken by stored zones or unknown layers. geometryErrors are the errors
+// found before copying.
+func importRejections(ctx context.Context, tx pgx.Tx, geometryErrors map[int][]dto.GeometryError) error {
+	const op = "storage.importRejections"
+	const unknownCRSQuery = `
+		SELECT i.idx
+		FROM zone_import i
+		WHERE i.position IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM spatial_ref_sys r WHERE r.srid = i.srid);`
+	const invalidGeometriesQuery = `
+		SELECT i.idx, i.position, d.reason, ST_X(d.location), ST_Y(d.location)
+		FROM zone_import i
+		CROSS JOIN LATERAL ST_IsValidDetail(ST_GeomFromEWKB(i.geom)) d
+		WHERE i.geom IS NOT NULL AND NOT d.valid;`
+	const conflictsQuery = `
+		SELECT c.idx, c.key_taken
+		FROM (
+			SELECT i.idx,
+				   EXISTS (SELECT 1 FROM zone z WHERE z.external_key = i.external_key) AS key_taken,
+				   NOT EXISTS (SELECT 1 FROM layer l WHERE l.name = i.layer) AS unknown_layer
+			FROM zone_import i
+			WHERE i.position IS NULL
+		) c
+		WHERE c.key_taken OR c.unknown_layer
+		ORDER BY c.idx;`
+
+	rows, err := tx.Query(ctx, unknownCRSQuery)
+	if err != nil {
+		return fmt.Errorf("%s: failed to check crs: %w", op, err)
+	}
+	unknownCRS, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return fmt.Errorf("%s: failed to check crs: %w", op, err)
+	}
+
+	rows, err = tx.Query(ctx, invalidGeometriesQuery)
+	if err != nil {
+		return fmt.Errorf("%s: failed to validate geometries: %w", op, err)
+	}
+	type invalidGeometry struct {
+		Zone    int
+		Feature int
+		Reason  string
+		X       *float64
+		Y       *float64
+	}
+	invalid, err := pgx.CollectRows(rows, pgx.RowToStructByPos[invalidGeometry])
+	if err != nil {
+		return fmt.Errorf("%s: failed to validate geometries: %w", op, err)
+	}
+	for _, g := range invalid {
+		geometryErrors[g.Zone] = append(geometryErrors[g.Zone], newGeometryError(g.Feature, g.Reason, g.X, g.Y))
+	}
+
+	rows, err = tx.Query(ctx, conflictsQuery)
+	if err != nil {
+		return fmt.Errorf("%s: failed to check conflicts: %w", op, err)
+	}
+	type conflict struct {
+		Zone     int
+		KeyTaken bool
+	}
+	conflicts, err := pgx.CollectRows(rows, pgx.RowToStructByPos[conflict])
+	if err != nil {
+		return fmt.Errorf("%s: failed to check conflicts: %w", op, err)
+	}
+
+	var rejected dto.ImportZonesErr
+	crsRejected := make(map[int]struct{}, len(unknownCRS))
+	for _, i := range unknownCRS {
+		crsRejected[i] = struct{}{}
+		rejected = append(rejected, dto.ImportZoneErr{Index: i, Err: dto.ErrUnknownCRS})
+	}
+	for i, errs := range geometryErrors {
+		if _, ok := crsRejected[i]; ok {
+			continue
+		}
+		sortGeometryErrors(errs)
+		rejected = append(rejected, dto.ImportZoneErr{Index: i, Err: dto.GeometryValidationErr{Errors: errs}})
+	}
+	for _, c := range conflicts {
+		if c.KeyTaken {
+			rejected = append(rejected, dto.ImportZoneErr{Index: c.Zone, Err: dto.ErrExternalKeyExists})
+			continue
+		}
+		rejected = append(rejected, dto.ImportZoneErr{Index: c.Zone, Err: dto.ErrLayerNotFound})
+	}
+	if len(rejected) > 0 {
+		return rejected
+	}
+	return nil
+}
@@ -0,0 +1,589 @@
+package psql
+
+import (
+	"context"
+	baseErr "errors"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+const layerColumns = `name, published_version, created_at, updated_at`
+
+const layerVersionColumns = `layer, version, status, jsonb_array_length(dataset), errors, created_at, published_at`
+
+func (s *Storage) CreateLayer(ctx context.Context, name string) (dto.Layer, error) {
+	const op = "storage.CreateLayer"
+	const query = `INSERT INTO layer (name) VALUES ($1) RETURNING ` + layerColumns + `;`
+
+	layer, err := scanLayer(s.db.QueryRow(ctx, query, name))
+	if err != nil {
+		return dto.Layer{}, parseZoneError(fmt.Errorf("%s: failed to create layer: %w", op, err))
+	}
+	return layer, nil
+}
+
+func (s *Storage) GetLayers(ctx context.Context) ([]dto.Layer, error) {
+	const op = "storage.GetLayers"
+	const query = `SELECT ` + layerColumns + ` FROM layer ORDER BY name;`
+
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get layers: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := make([]dto.Layer, 0)
+	for rows.Next() {
+		layer, err := scanLayer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan layer: %w", op, err)
+		}
+		result = append(result, layer)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read layers: %w", op, err)
+	}
+	return result, nil
+}
+
+func (s *Storage) GetLayer(ctx context.Context, name string) (dto.Layer, error) {
+	const op = "storage.GetLayer"
+	const query = `SELECT ` + layerColumns + ` FROM layer WHERE name = $1;`
+
+	layer, err := scanLayer(s.db.QueryRow(ctx, query, name))
+	if baseErr.Is(err, pgx.ErrNoRows) {
+		return dto.Layer{}, dto.ErrLayerNotFound
+	}
+	if err != nil {
+		return dto.Layer{}, fmt.Errorf("%s: failed to get layer: %w", op, err)
+	}
+	return layer, nil
+}
+
+// GetLayerZoneIds returns the ids of the zones in the layer ordered by id.
+func (s *Storage) GetLayerZoneIds(ctx context.Context, name string) ([]int, error) {
+	const op = "storage.GetLayerZoneIds"
+	const query = `
+		SELECT z.id
+		FROM layer l
+		LEFT JOIN zone z ON z.layer = l.name
+		WHERE l.name = $1
+		ORDER BY z.id;`
+
+	rows, err := s.db.Query(ctx, query, name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get layer zones: %w", op, err)
+	}
+	defer rows.Close()
+
+	found := false
+	ids := make([]int, 0)
+	for rows.Next() {
+		found = true
+		var zoneId *int
+		if err = rows.Scan(&zoneId); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan zone id: %w", op, err)
+		}
+		if zoneId != nil {
+			ids = append(ids, *zoneId)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read layer zones: %w", op, err)
+	}
+	if !found {
+		return nil, dto.ErrLayerNotFound
+	}
+	return ids, nil
+}
+
+// CreateLayerDraft stores the dataset as the next version of the layer.
+func (s *Storage) CreateLayerDraft(ctx context.Context, name string, zones []dto.FeatureCollectionJSON) (dto.LayerVersion, error) {
+	const op = "storage.CreateLayerDraft"
+	const query = `
+		INSERT INTO layer_version (layer, version, dataset)
+		SELECT $1, COALESCE(max(version), 0) + 1, $2 FROM layer_version WHERE layer = $1
+		RETURNING ` + layerVersionColumns + `;`
+
+	if zones == nil {
+		zones = []dto.FeatureCollectionJSON{}
+	}
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return dto.LayerVersion{}, fmt.Errorf("%s: failed to start transaction: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			rollbackErr := tx.Rollback(ctx)
+			if rollbackErr != nil {
+				err = baseErr.Join(err, rollbackErr)
+			}
+			return
+		}
+	}()
+
+	// The layer lock serialises version numbers.
+	if _, err = lockLayer(ctx, tx, name); err != nil {
+		return dto.LayerVersion{}, err
+	}
+	version, err := scanLayerVersion(tx.QueryRow(ctx, query, name, zones))
+	if err != nil {
+		return dto.LayerVersion{}, fmt.Errorf("%s: failed to create draft: %w", op, err)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return dto.LayerVersion{}, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+	return version, nil
+}
+
+func (s *Storage) GetLayerVersions(ctx context.Context, name string) ([]dto.LayerVersion, error) {
+	const op = "storage.GetLayerVersions"
+	const query = `SELECT ` + layerVersionColumns + ` FROM layer_version WHERE layer = $1 ORDER BY version;`
+
+	if _, err := s.GetLayer(ctx, name); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(ctx, query, name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get layer versions: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := make([]dto.LayerVersion, 0)
+	for rows.Next() {
+		version, err := scanLayerVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan layer version: %w", op, err)
+		}
+		result = append(result, version)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read layer versions: %w", op, err)
+	}
+	return result, nil
+}
+
+// ValidateLayerVersion checks a draft with layerDatasetErrors, the draft becomes
+// validated or invalid with the errors found.
+func (s *Storage) ValidateLayerVersion(ctx context.Context, name string, version int) (dto.LayerVersion, error) {
+	const op = "storage.ValidateLayerVersion"
+	// A draft published meanwhile keeps its status.
+	const updateStatus = `
+		UPDATE layer_version SET status = $3, errors = $4
+		WHERE layer = $1 AND version = $2 AND status = any($5)
+		RETURNING ` + layerVersionColumns + `;`
+
+	status, dataset, err := getLayerDataset(ctx, s.db, name, version)
+	if err != nil {
+		return dto.LayerVersion{}, err
+	}
+	if !status.IsDraft() {
+		return dto.LayerVersion{}, dto.ErrLayerVersionNotDraft
+	}
+
+	validationErrors, err := s.layerDatasetErrors(ctx, s.db, name, version, dataset)
+	if err != nil {
+		return dto.LayerVersion{}, err
+	}
+
+	status = dto.LayerVersionValidated
+	var errorsData interface{}
+	if len(validationErrors) > 0 {
+		status = dto.LayerVersionInvalid
+		errorsData = validationErrors
+	}
+	drafts := []string{string(dto.LayerVersionDraft), string(dto.LayerVersionValidated), string(dto.LayerVersionInvalid)}
+	result, err := scanLayerVersion(s.db.QueryRow(ctx, updateStatus, name, version, status, errorsData, drafts))
+	if baseErr.Is(err, pgx.ErrNoRows) {
+		return dto.LayerVersion{}, dto.ErrLayerVersionNotDraft
+	}
+	if err != nil {
+		return dto.LayerVersion{}, fmt.Errorf("%s: failed to update layer version: %w", op, err)
+	}
+	return result, nil
+}
+
+// PublishLayerVersion replaces the zones of the layer with a validated draft in one transaction.
+func (s *Storage) PublishLayerVersion(ctx context.Context, name string, version int) (dto.LayerVersion, error) {
+	const op = "storage.PublishLayerVersion"
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return dto.LayerVersion{}, fmt.Errorf("%s: failed to start transaction: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			rollbackErr := tx.Rollback(ctx)
+			if rollbackErr != nil {
+				err = baseErr.Join(err, rollbackErr)
+			}
+			return
+		}
+	}()
+
+	if _, err = lockLayer(ctx, tx, name); err != nil {
+		return dto.LayerVersion{}, err
+	}
+	status, dataset, err := getLayerDataset(ctx, tx, name, version)
+	if err != nil {
+		return dto.LayerVersion{}, err
+	}
+	if status != dto.LayerVersionValidated {
+		err = dto.ErrLayerVersionNotValidated
+		return dto.LayerVersion{}, err
+	}
+	result, err := s.publishLayerDataset(ctx, tx, name, version, dataset, dto.LayerVersionSuperseded)
+	if err != nil {
+		return dto.LayerVersion{}, err
+	}
+	if err = s.commitZoneChanges(ctx, tx); err != nil {
+		return dto.LayerVersion{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+// RollbackLayer publishes the version superseded last again, the rolled back version
+// is not a rollback target anymore. The version is validated again, e.g. a zone of
+// another layer may have taken one of its external keys since it was superseded.
+func (s *Storage) RollbackLayer(ctx context.Context, name string) (dto.LayerVersion, error) {
+	const op = "storage.RollbackLayer"
+	const selectPrevious = `
+		SELECT version FROM layer_version
+		WHERE layer = $1 AND status = $2
+		ORDER BY published_at DESC
+		LIMIT 1;`
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return dto.LayerVersion{}, fmt.Errorf("%s: failed to start transaction: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			rollbackErr := tx.Rollback(ctx)
+			if rollbackErr != nil {
+				err = baseErr.Join(err, rollbackErr)
+			}
+			return
+		}
+	}()
+
+	publishedVersion, err := lockLayer(ctx, tx, name)
+	if err != nil {
+		return dto.LayerVersion{}, err
+	}
+	var version int
+	if publishedVersion != nil {
+		err = tx.QueryRow(ctx, selectPrevious, name, dto.LayerVersionSuperseded).Scan(&version)
+	}
+	if publishedVersion == nil || baseErr.Is(err, pgx.ErrNoRows) {
+		err = dto.ErrNoPreviousLayerVersion
+		return dto.LayerVersion{}, err
+	}
+	if err != nil {
+		return dto.LayerVersion{}, fmt.Errorf("%s: failed to get previous version: %w", op, err)
+	}
+	_, dataset, err := getLayerDataset(ctx, tx, name, version)
+	if err != nil {
+		return dto.LayerVersion{}, err
+	}
+	validationErrors, err := s.layerDatasetErrors(ctx, tx, name, version, dataset)
+	if err != nil {
+		return dto.LayerVersion{}, err
+	}
+	if len(validationErrors) > 0 {
+		first := validationErrors[0]
+		err = fmt.Errorf("%w: version %d: zone %d: %s", dto.ErrLayerVersionNotValid, version, first.Zone, first.Error)
+		return dto.LayerVersion{}, err
+	}
+	result, err := s.publishLayerDataset(ctx, tx, name, version, dataset, dto.LayerVersionRolledBack)
+	if err != nil {
+		return dto.LayerVersion{}, err
+	}
+	if err = s.commitZoneChanges(ctx, tx); err != nil {
+		return dto.LayerVersion{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+// layerDatasetErrors checks the geometries of the dataset of the layer version, external
+// keys taken by zones of other layers and external keys repeated within the dataset.
+func (s *Storage) layerDatasetErrors(
+	ctx context.Context,
+	q querier,
+	name string,
+	version int,
+	dataset []dto.FeatureCollectionJSON,
+) ([]dto.LayerValidationError, error) {
+	const op = "storage.layerDatasetErrors"
+	// Every zone repeating the key of an earlier zone of the dataset is a duplicate.
+	const selectKeyConflicts = `
+		WITH d AS (
+			SELECT d.ord - 1 AS idx, d.zone -> 'metadata' ->> 'external_key' AS key, lv.layer
+			FROM layer_version lv
+			CROSS JOIN LATERAL jsonb_array_elements(lv.dataset) WITH ORDINALITY d(zone, ord)
+			WHERE lv.layer = $1 AND lv.version = $2
+		)
+		SELECT d.idx, false
+		FROM d
+		JOIN zone z ON z.external_key = d.key AND z.layer <> d.layer
+		UNION ALL
+		SELECT k.idx, true
+		FROM (
+			SELECT idx, row_number() OVER (PARTITION BY key ORDER BY idx) AS n
+			FROM d
+			WHERE key IS NOT NULL
+		) k
+		WHERE k.n > 1
+		ORDER BY 1;`
+
+	validationErrors := make([]dto.LayerValidationError, 0)
+	for i, zone := range dataset {
+		zoneErrors, err := s.validateLayerZone(ctx, i, zone)
+		if err != nil {
+			return nil, err
+		}
+		validationErrors = append(validationErrors, zoneErrors...)
+	}
+
+	rows, err := q.Query(ctx, selectKeyConflicts, name, version)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to check external keys: %w", op, err)
+	}
+	for rows.Next() {
+		var validationError dto.LayerValidationError
+		var duplicate bool
+		if err = rows.Scan(&validationError.Zone, &duplicate); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("%s: failed to scan external key: %w", op, err)
+		}
+		validationError.Error = dto.ErrExternalKeyExists.Error()
+		if duplicate {
+			validationError.Error = dto.ErrDuplicateLayerExternalKey.Error()
+		}
+		validationErrors = append(validationErrors, validationError)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read external keys: %w", op, err)
+	}
+	sort.SliceStable(validationErrors, func(i, j int) bool { return validationErrors[i].Zone < validationErrors[j].Zone })
+	return validationErrors, nil
+}
+
+// validateLayerZone checks the geometries of the i-th zone of a dataset like they are
+// checked when a zone is saved.
+func (s *Storage) validateLayerZone(ctx context.Context, i int, zone dto.FeatureCollectionJSON) ([]dto.LayerValidationError, error) {
+	var featureCollection geojson.FeatureCollection
+	if err := featureCollection.FromFeatureCollectionJSON(zone); err != nil {
+		return []dto.LayerValidationError{{Zone: i, Error: err.Error()}}, nil
+	}
+	err := s.checkSRID(ctx, featureCollection.CRS())
+	if baseErr.Is(err, dto.ErrUnknownCRS) {
+		return []dto.LayerValidationError{{Zone: i, Error: err.Error()}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	geometryErrors, err := s.zoneGeometryErrors(ctx, []geojson.FeatureCollection{featureCollection})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]dto.LayerValidationError, 0, len(geometryErrors[0]))
+	for _, geometryErr := range geometryErrors[0] {
+		featureIndex := geometryErr.Feature
+		result = append(result, dto.LayerValidationError{Zone: i, Feature: &featureIndex, Error: geometryErr.Message})
+	}
+	return result, nil
+}
+
+// publishLayerDataset applies the dataset to the layer and makes the version the
+// published one, the previously published version gets the previous status. The zone
+// changes are recorded at once as the last statement.
+func (s *Storage) publishLayerDataset(
+	ctx context.Context,
+	tx pgx.Tx,
+	name string,
+	version int,
+	dataset []dto.FeatureCollectionJSON,
+	previous dto.LayerVersionStatus,
+) (dto.LayerVersion, error) {
+	const op = "storage.publishLayerDataset"
+	const updatePrevious = `UPDATE layer_version SET status = $2 WHERE layer = $1 AND status = $3;`
+	const updateVersion = `
+		UPDATE layer_version SET status = $3, published_at = now()
+		WHERE layer = $1 AND version = $2
+		RETURNING ` + layerVersionColumns + `;`
+	const updateLayer = `UPDATE layer SET published_version = $2, updated_at = now() WHERE name = $1;`
+
+	changes, err := s.applyLayerDataset(ctx, tx, name, dataset)
+	if err != nil {
+		return dto.LayerVersion{}, err
+	}
+	if _, err = tx.Exec(ctx, updatePrevious, name, previous, dto.LayerVersionPublished); err != nil {
+		return dto.LayerVersion{}, fmt.Errorf("%s: failed to update previous version: %w", op, err)
+	}
+	result, err := scanLayerVersion(tx.QueryRow(ctx, updateVersion, name, version, dto.LayerVersionPublished))
+	if err != nil {
+		return dto.LayerVersion{}, fmt.Errorf("%s: failed to update layer version: %w", op, err)
+	}
+	if _, err = tx.Exec(ctx, updateLayer, name, version); err != nil {
+		return dto.LayerVersion{}, fmt.Errorf("%s: failed to update layer: %w", op, err)
+	}
+	if err = recordZoneChanges(ctx, tx, changes); err != nil {
+		return dto.LayerVersion{}, err
+	}
+	return result, nil
+}
+
+// applyLayerDataset makes the zones of the layer match the dataset and returns the zone
+// changes for the caller to record. Zones are matched by external key and replaced in
+// place, so they keep ids and history. Unmatched zones are created and the remaining
+// zones of the layer are deleted.
+func (s *Storage) applyLayerDataset(
+	ctx context.Context,
+	tx pgx.Tx,
+	name string,
+	dataset []dto.FeatureCollectionJSON,
+) ([]dto.ZoneChange, error) {
+	const op = "storage.applyLayerDataset"
+	const selectZones = `SELECT id, external_key FROM zone WHERE layer = $1 ORDER BY id FOR UPDATE;`
+
+	rows, err := tx.Query(ctx, selectZones, name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get layer zones: %w", op, err)
+	}
+	zoneIds := make([]int, 0)
+	keys := make(map[string]int)
+	for rows.Next() {
+		var zoneId int
+		var key *string
+		if err = rows.Scan(&zoneId, &key); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("%s: failed to scan zone: %w", op, err)
+		}
+		zoneIds = append(zoneIds, zoneId)
+		if key != nil {
+			keys[*key] = zoneId
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read layer zones: %w", op, err)
+	}
+
+	changes := make([]dto.ZoneChange, 0, len(dataset))
+	kept := make(map[int]struct{}, len(dataset))
+	for i, zone := range dataset {
+		var featureCollection geojson.FeatureCollection
+		if err = featureCollection.FromFeatureCollectionJSON(zone); err != nil {
+			return nil, fmt.Errorf("%s: zone %d: %w", op, i, err)
+		}
+		metadata := dto.ZoneMetadata{}
+		if featureCollection.Metadata != nil {
+			metadata = *featureCollection.Metadata
+		}
+		metadata.Layer = name
+		featureCollection.Metadata = &metadata
+
+		if metadata.ExternalKey != nil {
+			if zoneId, ok := keys[*metadata.ExternalKey]; ok {
+				if err = s.overwriteZone(ctx, tx, zoneId, featureCollection); err != nil {
+					return nil, err
+				}
+				kept[zoneId] = struct{}{}
+				changes = append(changes, dto.ZoneChange{Type: dto.ZoneChangeUpdated, ZoneId: zoneId})
+				continue
+			}
+		}
+		zoneId, err := s.insertZone(ctx, tx, featureCollection)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, dto.ZoneChange{Type: dto.ZoneChangeCreated, ZoneId: zoneId})
+	}
+	for _, zoneId := range zoneIds {
+		if _, ok := kept[zoneId]; ok {
+			continue
+		}
+		deleted, err := removeZone(ctx, tx, zoneId)
+		if err != nil {
+			return nil, err
+		}
+		if deleted {
+			changes = append(changes, dto.ZoneChange{Type: dto.ZoneChangeDeleted, ZoneId: zoneId})
+		}
+	}
+	return changes, nil
+}
+
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// getLayerDataset returns the status and the zones of the layer version.
+func getLayerDataset(
+	ctx context.Context,
+	q queryRower,
+	name string,
+	version int,
+) (dto.LayerVersionStatus, []dto.FeatureCollectionJSON, error) {
+	const op = "storage.getLayerDataset"
+	const query = `SELECT status, dataset FROM layer_version WHERE layer = $1 AND version = $2;`
+
+	var status dto.LayerVersionStatus
+	var dataset []dto.FeatureCollectionJSON
+	err := q.QueryRow(ctx, query, name, version).Scan(&status, &dataset)
+	if baseErr.Is(err, pgx.ErrNoRows) {
+		return "", nil, dto.ErrLayerVersionNotFound
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: failed to get layer version: %w", op, err)
+	}
+	return status, dataset, nil
+}
+
+// lockLayer locks the layer row for publishing and returns its published version.
+func lockLayer(ctx context.Context, tx pgx.Tx, name string) (*int, error) {
+	const op = "storage.lockLayer"
+	const query = `SELECT published_version FROM layer WHERE name = $1 FOR UPDATE;`
+
+	var publishedVersion *int
+	err := tx.QueryRow(ctx, query, name).Scan(&publishedVersion)
+	if baseErr.Is(err, pgx.ErrNoRows) {
+		return nil, dto.ErrLayerNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to lock layer: %w", op, err)
+	}
+	return publishedVersion, nil
+}
+
+func scanLayer(row pgx.Row) (dto.Layer, error) {
+	var layer dto.Layer
+	err := row.Scan(&layer.Name, &layer.PublishedVersion, &layer.CreatedAt, &layer.UpdatedAt)
+	return layer, err
+}
+
+func scanLayerVersion(row pgx.Row) (dto.LayerVersion, error) {
+	var version dto.LayerVersion
+	err := row.Scan(
+		&version.Layer,
+		&version.Version,
+		&version.Status,
+		&version.ZonesCount,
+		&version.Errors,
+		&version.CreatedAt,
+		&version.PublishedAt,
+	)
+	return version, err
+}
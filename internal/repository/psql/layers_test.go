@@ -0,0 +1,84 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+func layerZoneJSON(t *testing.T, key string) dto.FeatureCollectionJSON {
+	t.Helper()
+
+	data := fmt.Sprintf(`{
+		"type": "FeatureCollection",
+		"metadata": {"external_key": %q},
+		"features": [{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [0, 1], [1, 1], [1, 0], [0, 0]]]}}]
+	}`, key)
+	var zone dto.FeatureCollectionJSON
+	require.NoError(t, json.Unmarshal([]byte(data), &zone))
+	return zone
+}
+
+func TestRollbackLayer(t *testing.T) {
+	ctx := context.Background()
+
+	storage, err := NewTestStorage(ctx)
+	if err != nil {
+		t.Skipf("postgres is not available: %v", err)
+	}
+	defer storage.ShutDown()
+
+	const layerName = "delivery"
+	publish := func(keys ...string) {
+		t.Helper()
+
+		zones := make([]dto.FeatureCollectionJSON, 0, len(keys))
+		for _, key := range keys {
+			zones = append(zones, layerZoneJSON(t, key))
+		}
+		draft, err := storage.CreateLayerDraft(ctx, layerName, zones)
+		require.NoError(t, err)
+		draft, err = storage.ValidateLayerVersion(ctx, layerName, draft.Version)
+		require.NoError(t, err)
+		require.Equal(t, dto.LayerVersionValidated, draft.Status)
+		_, err = storage.PublishLayerVersion(ctx, layerName, draft.Version)
+		require.NoError(t, err)
+	}
+
+	_, err = storage.CreateLayer(ctx, layerName)
+	require.NoError(t, err)
+	publish("a", "b")
+	publish("a", "c")
+
+	// Changes of a publish are recorded together, in the order they were applied.
+	changes, err := storage.GetZoneChanges(ctx, 0, 10)
+	require.NoError(t, err)
+	changeTypes := make([]dto.ZoneChangeType, 0, len(changes))
+	for _, change := range changes {
+		changeTypes = append(changeTypes, change.Type)
+	}
+	require.Equal(t, []dto.ZoneChangeType{
+		dto.ZoneChangeCreated, dto.ZoneChangeCreated,
+		dto.ZoneChangeUpdated, dto.ZoneChangeCreated, dto.ZoneChangeDeleted,
+	}, changeTypes)
+
+	// A zone of another layer takes the key of the superseded version meanwhile.
+	var featureCollection geojson.FeatureCollection
+	require.NoError(t, featureCollection.FromFeatureCollectionJSON(layerZoneJSON(t, "b")))
+	_, err = storage.SaveZoneFromFeatureCollection(ctx, featureCollection)
+	require.NoError(t, err)
+
+	_, err = storage.RollbackLayer(ctx, layerName)
+	require.ErrorIs(t, err, dto.ErrLayerVersionNotValid)
+	require.ErrorContains(t, err, dto.ErrExternalKeyExists.Error())
+
+	layer, err := storage.GetLayer(ctx, layerName)
+	require.NoError(t, err)
+	require.Equal(t, 2, *layer.PublishedVersion)
+}
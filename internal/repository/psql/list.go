@@ -0,0 +1,76 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+// selectZoneMetadataQuery selects zones like selectZonesQuery without their geometries.
+const selectZoneMetadataQuery = `
+		SELECT z.id, z.name, z.external_key, z.tags, z.schedule, z.layer, z.created_at, z.updated_at,
+			   '{}'::jsonb AS geojson
+		FROM zone z`
+
+// zonesListCondition extends zonesFilterCondition with $6 properties a feature contains,
+// $7 whether the $8-$11 bbox is set and the $12 created at, $13 id cursor of listings
+// sorted by creation time.
+const zonesListCondition = zonesFilterCondition + `
+		  AND ($6::jsonb IS NULL OR EXISTS (
+				SELECT 1 FROM zone_geometry p WHERE p.zone_id = z.id AND p.properties::jsonb @> $6::jsonb
+			  ))
+		  AND (NOT $7 OR EXISTS (
+				SELECT 1 FROM zone_geometry b
+				WHERE b.zone_id = z.id AND ST_Intersects(b.geom, ST_MakeEnvelope($8, $9, $10, $11, 4326))
+			  ))
+		  AND ($12::timestamptz IS NULL OR (z.created_at, z.id) > ($12::timestamptz, $13))`
+
+var zonesListOrder = map[dto.ZonesSort]string{
+	dto.ZonesSortId:        ` ORDER BY z.id LIMIT $14;`,
+	dto.ZonesSortCreatedAt: ` ORDER BY z.created_at, z.id LIMIT $14;`,
+}
+
+// ListZones returns up to in.Limit zones passing the filter that follow the cursor.
+func (s *Storage) ListZones(ctx context.Context, in dto.ZonesListIn) ([]dto.ZoneGeoJSON, error) {
+	const op = "storage.ListZones"
+
+	query := selectZonesQuery + zonesListCondition + ` GROUP BY z.id` + zonesListOrder[in.Sort]
+	if in.WithoutGeometry {
+		query = selectZoneMetadataQuery + zonesListCondition + zonesListOrder[in.Sort]
+	}
+
+	var properties []byte
+	if len(in.Properties) > 0 {
+		var err error
+		if properties, err = json.Marshal(in.Properties); err != nil {
+			return nil, fmt.Errorf("%s: failed to marshal properties: %w", op, err)
+		}
+	}
+	var bbox dto.BBox
+	if in.BBox != nil {
+		bbox = *in.BBox
+	}
+	after, createdAt, cursorId := in.After, (*time.Time)(nil), 0
+	if in.Cursor != nil {
+		if in.Sort == dto.ZonesSortCreatedAt {
+			createdAt, cursorId = &in.Cursor.CreatedAt, in.Cursor.ZoneId
+		} else if in.Cursor.ZoneId > after {
+			after = in.Cursor.ZoneId
+		}
+	}
+
+	rows, err := s.db.Query(ctx, query,
+		in.FromId, in.ToId, after, in.Layer, in.Tag,
+		properties,
+		in.BBox != nil, bbox.MinLon, bbox.MinLat, bbox.MaxLon, bbox.MaxLat,
+		createdAt, cursorId,
+		in.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get zones: %w", op, err)
+	}
+	return scanZones(rows, op)
+}
@@ -0,0 +1,173 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+const (
+	zoneChangesChannel = "zone_changes"
+
+	listenerMinBackoff = 100 * time.Millisecond
+	listenerMaxBackoff = 30 * time.Second
+	catchUpBatchSize   = 1000
+)
+
+// Listener receives zone changes made by every instance through LISTEN/NOTIFY
+// and dispatches them to subscribers, e.g. to invalidate in-process caches.
+type Listener struct {
+	log         *logrus.Logger
+	storage     *Storage
+	mu          sync.RWMutex
+	subscribers map[int]func(change dto.ZoneChange)
+	lastSubId   int
+	started     bool
+	lastSeq     int64
+	ready       chan struct{}
+}
+
+func NewListener(log *logrus.Logger, storage *Storage) *Listener {
+	return &Listener{
+		log:         log,
+		storage:     storage,
+		subscribers: make(map[int]func(change dto.ZoneChange)),
+		ready:       make(chan struct{}),
+	}
+}
+
+// Ready is closed once the listener is listening, later changes are dispatched.
+func (l *Listener) Ready() <-chan struct{} {
+	return l.ready
+}
+
+// Subscribe registers fn and returns a function removing it. Subscribers are called
+// one by one from the listener goroutine, so they must not block.
+func (l *Listener) Subscribe(fn func(change dto.ZoneChange)) func() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lastSubId++
+	id := l.lastSubId
+	l.subscribers[id] = fn
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.subscribers, id)
+	}
+}
+
+// Run listens until ctx is done and reconnects with backoff when the connection
+// is lost. Notifications missed while disconnected are read from the change log.
+func (l *Listener) Run(ctx context.Context) {
+	const op = "psql.Listener.Run"
+
+	backoff := listenerMinBackoff
+	for {
+		connected, err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff = listenerMinBackoff
+		}
+		l.log.Error(fmt.Sprintf("%s: reconnecting in %s: %v", op, backoff, err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, listenerMaxBackoff)
+	}
+}
+
+// listen holds a dedicated connection until an error. It reports whether LISTEN succeeded.
+func (l *Listener) listen(ctx context.Context) (bool, error) {
+	conn, err := l.storage.db.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	// The connection keeps listening, it must not return to the pool.
+	pgConn := conn.Hijack()
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = pgConn.Close(closeCtx)
+	}()
+
+	if _, err = pgConn.Exec(ctx, "LISTEN "+zoneChangesChannel); err != nil {
+		return false, fmt.Errorf("failed to listen: %w", err)
+	}
+	// Changes committed before LISTEN are not notified.
+	if err = l.catchUp(ctx); err != nil {
+		return true, err
+	}
+
+	for {
+		notification, err := pgConn.WaitForNotification(ctx)
+		if err != nil {
+			return true, fmt.Errorf("failed to wait for notification: %w", err)
+		}
+		var change dto.ZoneChange
+		if err = json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+			l.log.Error(fmt.Sprintf("psql.Listener: invalid notification %q: %v", notification.Payload, err))
+			continue
+		}
+		if change.Seq > l.lastSeq+1 {
+			// A notification was lost, e.g. the queue overflowed.
+			if err = l.catchUp(ctx); err != nil {
+				return true, err
+			}
+			continue
+		}
+		l.dispatch(change)
+	}
+}
+
+// catchUp dispatches logged changes after the last dispatched one. The first call
+// only remembers the current position, there is nothing to invalidate at start.
+func (l *Listener) catchUp(ctx context.Context) error {
+	if !l.started {
+		lastSeq, err := l.storage.LastZoneChangeSeq(ctx)
+		if err != nil {
+			return err
+		}
+		l.lastSeq = lastSeq
+		l.started = true
+		close(l.ready)
+		return nil
+	}
+
+	for {
+		changes, err := l.storage.GetZoneChanges(ctx, l.lastSeq, catchUpBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, change := range changes {
+			l.dispatch(change)
+		}
+		if len(changes) < catchUpBatchSize {
+			return nil
+		}
+	}
+}
+
+func (l *Listener) dispatch(change dto.ZoneChange) {
+	if change.Seq <= l.lastSeq {
+		return
+	}
+	l.lastSeq = change.Seq
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, fn := range l.subscribers {
+		fn(change)
+	}
+}
@@ -0,0 +1,89 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/config"
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/logger"
+)
+
+const listenerPolygonGeoJson = `
+	{
+		"type": "FeatureCollection",
+		"features": [
+			{
+				"type": "Feature",
+				"properties": {},
+				"geometry": {
+					"type": "Polygon",
+					"coordinates": [[[0, 0], [0, 1], [1, 1], [1, 0], [0, 0]]]
+				}
+			}
+		]
+	}`
+
+func TestListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	storage, err := NewTestStorage(ctx)
+	if err != nil {
+		t.Skipf("postgres is not available: %v", err)
+	}
+	defer storage.ShutDown()
+
+	var featureCollectionJson dto.FeatureCollectionJSON
+	require.NoError(t, json.Unmarshal([]byte(listenerPolygonGeoJson), &featureCollectionJson))
+	var featureCollection geojson.FeatureCollection
+	require.NoError(t, featureCollection.FromFeatureCollectionJSON(featureCollectionJson))
+
+	// Written before the listener starts, must not be dispatched.
+	_, err = storage.SaveZoneFromFeatureCollection(ctx, featureCollection)
+	require.NoError(t, err)
+
+	received := make(chan dto.ZoneChange, 10)
+	listener := NewListener(logger.New(config.EnvTest), storage.Storage)
+	unsubscribe := listener.Subscribe(func(change dto.ZoneChange) { received <- change })
+	defer unsubscribe()
+	go listener.Run(ctx)
+
+	next := func() dto.ZoneChange {
+		select {
+		case change := <-received:
+			return change
+		case <-time.After(5 * time.Second):
+			t.Fatal("no notification")
+			return dto.ZoneChange{}
+		}
+	}
+
+	select {
+	case <-listener.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener is not ready")
+	}
+
+	zoneId, err := storage.SaveZoneFromFeatureCollection(ctx, featureCollection)
+	require.NoError(t, err)
+	require.NoError(t, storage.DeleteZoneById(ctx, zoneId))
+
+	created := next()
+	require.Equal(t, dto.ZoneChange{Seq: 2, Type: dto.ZoneChangeCreated, ZoneId: zoneId, CreatedAt: created.CreatedAt}, created)
+	deleted := next()
+	require.Equal(t, int64(3), deleted.Seq)
+	require.Equal(t, dto.ZoneChangeDeleted, deleted.Type)
+
+	// Kill the listening connection, the listener reconnects and reads the missed change.
+	_, err = storage.db.Exec(ctx, `SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE query = 'LISTEN `+zoneChangesChannel+`';`)
+	require.NoError(t, err)
+	_, err = storage.SaveZoneFromFeatureCollection(ctx, featureCollection)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), next().Seq)
+}
@@ -0,0 +1,14 @@
+package psql
+
+import (
+	"time"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+// isActive reports whether a zone with the schedule is active at t. Zones without a schedule
+// are always active. Schedules are not versioned: lookups at a past time evaluate the current
+// schedule of the zone at that time, and deleted zones have none.
+func isActive(schedule *dto.ZoneSchedule, t time.Time) bool {
+	return schedule == nil || schedule.ActiveAt(t)
+}
@@ -2,9 +2,11 @@ package psql
 
 import (
 	"context"
+	"encoding/json"
 	baseErr "errors"
 	"fmt"
-	"sync"
+	"sort"
+	"time"
 
 	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v5"
@@ -18,6 +20,8 @@ import (
 type Storage struct {
 	db  *pgxpool.Pool
 	log *logrus.Logger
+	// onZoneChanges is called after zone changes are committed, see OnZoneChanges.
+	onZoneChanges func(ctx context.Context) error
 }
 
 func New(ctx context.Context, log *logrus.Logger, DbConnString string) (*Storage, error) {
@@ -42,10 +46,10 @@ func (s *Storage) ShutDown() {
 }
 
 func (s *Storage) SaveZoneFromFeatureCollection(ctx context.Context, featureCollection geojson.FeatureCollection) (int, error) {
-	const createZoneQuery = `INSERT INTO zone DEFAULT VALUES RETURNING id;`
-	const createGeometry = `INSERT INTO zone_geometry (zone_id, geom, properties) VALUES ($1, ST_GeomFromEWKB($2), $3)`
-
 	var zoneId int
+	if err := s.validateZoneGeometries(ctx, featureCollection); err != nil {
+		return zoneId, err
+	}
 	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return zoneId, fmt.Errorf("failed to start transaction: %w", err)
@@ -60,61 +64,504 @@ func (s *Storage) SaveZoneFromFeatureCollection(ctx context.Context, featureColl
 		}
 	}()
 
-	err = tx.QueryRow(ctx, createZoneQuery).Scan(&zoneId)
+	if zoneId, err = s.createZone(ctx, tx, featureCollection); err != nil {
+		return zoneId, err
+	}
+	if err = s.commitZoneChanges(ctx, tx); err != nil {
+		return zoneId, err
+	}
+	return zoneId, nil
+}
+
+func (s *Storage) UpdateZoneFromFeatureCollection(ctx context.Context, zoneId int, featureCollection geojson.FeatureCollection) error {
+	const op = "storage.UpdateZoneFromFeatureCollection"
+
+	if err := s.validateZoneGeometries(ctx, featureCollection); err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
-		return zoneId, fmt.Errorf("failed to create zone: %w", err)
+		return fmt.Errorf("%s: failed to start transaction: %w", op, err)
 	}
-	for _, feature := range featureCollection.Features {
-		_, err = tx.Exec(ctx, createGeometry, zoneId, feature.Geometry.ToEwkb(), feature.Properties)
+	defer func() {
 		if err != nil {
-			return zoneId, parsePostgisError(err)
+			rollbackErr := tx.Rollback(ctx)
+			if rollbackErr != nil {
+				err = baseErr.Join(err, rollbackErr)
+			}
+			return
 		}
+	}()
+
+	if err = s.lockZone(ctx, tx, zoneId); err != nil {
+		return err
 	}
-	if err = tx.Commit(ctx); err != nil {
-		return zoneId, fmt.Errorf("failed to commit transaction: %w", err)
+	if err = s.replaceZone(ctx, tx, zoneId, featureCollection); err != nil {
+		return err
 	}
-	return zoneId, nil
+	if err = s.commitZoneChanges(ctx, tx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
 }
 
-func (s *Storage) GetZonesByIds(ctx context.Context, ids []int) ([]dto.ZoneGeoJSON, error) {
-	const query = `
-		SELECT zg.zone_id,
-			   jsonb_build_object(
+func (s *Storage) UpdateZoneProperties(ctx context.Context, zoneId int, properties []map[string]interface{}) error {
+	const op = "storage.UpdateZoneProperties"
+	const selectGeometryIds = `SELECT id FROM zone_geometry WHERE zone_id = $1 ORDER BY id;`
+	const updateProperties = `UPDATE zone_geometry SET properties = $1 WHERE id = $2;`
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("%s: failed to start transaction: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			rollbackErr := tx.Rollback(ctx)
+			if rollbackErr != nil {
+				err = baseErr.Join(err, rollbackErr)
+			}
+			return
+		}
+	}()
+
+	if err = s.lockZone(ctx, tx, zoneId); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, selectGeometryIds, zoneId)
+	if err != nil {
+		return fmt.Errorf("%s: failed to get zone geometry: %w", op, err)
+	}
+	geometryIds, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return fmt.Errorf("%s: failed to scan zone geometry: %w", op, err)
+	}
+	if len(geometryIds) != len(properties) {
+		err = dto.ErrPropertiesCount
+		return err
+	}
+
+	for i, geometryId := range geometryIds {
+		if properties[i] == nil {
+			continue
+		}
+		if _, err = tx.Exec(ctx, updateProperties, properties[i], geometryId); err != nil {
+			return fmt.Errorf("%s: failed to update properties: %w", op, err)
+		}
+	}
+	if err = addZoneVersion(ctx, tx, zoneId); err != nil {
+		return err
+	}
+	if err = recordZoneChange(ctx, tx, dto.ZoneChangeUpdated, zoneId); err != nil {
+		return err
+	}
+	if err = s.commitZoneChanges(ctx, tx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// selectZonesQuery selects zones with metadata and their geometries aggregated into a FeatureCollection.
+// Callers append the filter, GROUP BY z.id and ordering.
+const selectZonesQuery = zonesColumns + `
+		FROM zone z
+		JOIN zone_geometry zg ON zg.zone_id = z.id`
+
+// zonesColumns is the select list of selectZonesQuery over zone z and its geometries zg.
+const zonesColumns = `
+		SELECT z.id, z.name, z.external_key, z.tags, z.schedule, z.layer, z.created_at, z.updated_at,
+			   COALESCE(z.foreign_members, '{}'::jsonb) || jsonb_build_object(
 					   'type', 'FeatureCollection',
-					   'features', jsonb_agg(
-							   jsonb_build_object(
+					   'bbox', jsonb_build_array(
+							   min(ST_XMin(zg.geom)), min(ST_YMin(zg.geom)), max(ST_XMax(zg.geom)), max(ST_YMax(zg.geom))
+						   ),
+					   'features', jsonb_agg(` + featureObject + ` ORDER BY zg.id
+								   )
+			   )as geojson`
+
+// featureObject builds the Feature of geometry zg with its id, bbox, source geometry and foreign members.
+const featureObject = `
+							   COALESCE(zg.foreign_members, '{}'::jsonb) || jsonb_build_object(
 									   'type', 'Feature',
 									   'geometry', ST_AsGeoJSON(zg.geom)::jsonb,
-									   'properties', zg.properties
-							   )
-								   )
-			   )as geojson
-		FROM zone_geometry zg
-		WHERE zg.zone_id = any($1)
-		GROUP BY zg.zone_id;`
+									   'properties', zg.properties,
+									   'bbox', jsonb_build_array(ST_XMin(zg.geom), ST_YMin(zg.geom), ST_XMax(zg.geom), ST_YMax(zg.geom))
+							   ) || jsonb_strip_nulls(jsonb_build_object(
+									   'id', zg.feature_id,
+									   'radius', zg.radius,
+									   'source_geometry', ST_AsGeoJSON(zg.source_geom)::jsonb
+							   ))`
+
+// pointCandidateCondition is the bounding box test of geometry zg against point p widened
+// by the tolerance, the spatial index answers it. The tolerance is widened to degrees
+// generously: a degree is at least 110 km along meridians and shrinks with the cosine
+// of the latitude along parallels.
+const pointCandidateCondition = `zg.geom && ST_Expand(p.geom, LEAST(
+			p.tolerance / (110000 * GREATEST(cos(radians(ST_Y(p.geom))), 0.0001)), 360
+		))`
 
+// pointMatchCondition applies the requested predicate of point p to geometry zg.
+// Callers provide p with geom, predicate and tolerance (meters) columns. Geometries
+// failing pointCandidateCondition do not match, the predicates are not evaluated for them.
+const pointMatchCondition = `
+			CASE
+				WHEN NOT (` + pointCandidateCondition + `) THEN false
+				WHEN p.tolerance > 0 AND ST_DWithin(zg.geom::geography, p.geom::geography, p.tolerance) THEN true
+				WHEN p.predicate = 'covers' THEN st_covers(zg.geom, p.geom)
+				WHEN p.predicate = 'intersects' THEN st_intersects(zg.geom, p.geom)
+				ELSE st_contains(zg.geom, p.geom)
+			END`
+
+// pointPositionRank ranks the position of point p against areal geometry zg:
+// 2 is inside, 1 is on the edge (within tolerance of the boundary), 0 is outside.
+// The boundary is built only with a tolerance, a point intersecting a zone it is
+// not contained by is on its boundary.
+const pointPositionRank = `
+			CASE
+				WHEN NOT (` + pointCandidateCondition + `) THEN 0
+				WHEN p.tolerance > 0 AND ST_DWithin(
+					ST_Boundary(zg.geom)::geography, p.geom::geography, p.tolerance
+				) THEN 1
+				WHEN st_contains(zg.geom, p.geom) THEN 2
+				WHEN st_intersects(zg.geom, p.geom) THEN 1
+				ELSE 0
+			END`
+
+// pointInsideCondition holds when pointPositionRank of point p against zg is 2. Every
+// predicate matches such a point.
+const pointInsideCondition = `st_contains(zg.geom, p.geom) AND NOT (p.tolerance > 0 AND ST_DWithin(
+				ST_Boundary(zg.geom)::geography, p.geom::geography, p.tolerance
+			))`
+
+// selectZonesInCRSQuery selects zones like GetZonesByIds with their geometries reprojected
+// to srid $2. Every geometry is transformed once in the lateral subquery.
+const selectZonesInCRSQuery = zonesColumns + `
+		FROM zone z
+		JOIN LATERAL (
+			SELECT g.id, g.feature_id, g.properties, g.foreign_members,
+				   ST_Transform(g.geom, $2::int) AS geom, ST_Transform(g.source_geom, $2::int) AS source_geom
+			FROM zone_geometry g
+			WHERE g.zone_id = z.id
+		) zg ON true
+		WHERE z.id = any($1)
+		GROUP BY z.id;`
+
+// GetZonesByIds returns the current zones, or the versions valid at at when it is set, with
+// geometries in srid. Zones outside EPSG:4326 carry the legacy GeoJSON crs member naming it.
+func (s *Storage) GetZonesByIds(ctx context.Context, ids []int, at *time.Time, srid int) ([]dto.ZoneGeoJSON, error) {
+	const op = "storage.GetZonesByIds"
+	const query = selectZonesQuery + `
+		WHERE z.id = any($1)
+		GROUP BY z.id;`
+
+	if err := s.checkSRID(ctx, srid); err != nil {
+		return nil, err
+	}
 	zoneIds := &pgtype.Int4Array{}
 	if err := zoneIds.Set(ids); err != nil {
 		return nil, fmt.Errorf("failed to set zone ids: %w", err)
 	}
 
-	rows, err := s.db.Query(ctx, query, zoneIds)
+	var rows pgx.Rows
+	var err error
+	switch {
+	case at == nil && srid == dto.WGS84:
+		rows, err = s.db.Query(ctx, query, zoneIds)
+	case at == nil:
+		rows, err = s.db.Query(ctx, selectZonesInCRSQuery, zoneIds, srid)
+	case srid == dto.WGS84:
+		rows, err = s.db.Query(ctx, selectZonesAtQuery, zoneIds, *at)
+	default:
+		rows, err = s.db.Query(ctx, selectZonesAtInCRSQuery, zoneIds, *at, srid)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get zones: %w", err)
+		return nil, fmt.Errorf("%s: failed to get zones: %w", op, err)
+	}
+	zones, err := scanZones(rows, op)
+	if err != nil || srid == dto.WGS84 {
+		return zones, err
+	}
+	for i := range zones {
+		members, err := zones[i].GeoJSON.ForeignMembers.With(dto.CRSMemberName, dto.NewCRSMember(srid))
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to encode crs: %w", op, err)
+		}
+		zones[i].GeoJSON.ForeignMembers = members
+	}
+	return zones, nil
+}
+
+func (s *Storage) GetZoneByExternalKey(ctx context.Context, key string) (dto.ZoneGeoJSON, error) {
+	const op = "storage.GetZoneByExternalKey"
+	const query = selectZonesQuery + `
+		WHERE z.external_key = $1
+		GROUP BY z.id;`
+
+	rows, err := s.db.Query(ctx, query, key)
+	if err != nil {
+		return dto.ZoneGeoJSON{}, fmt.Errorf("%s: failed to get zone: %w", op, err)
+	}
+	zones, err := scanZones(rows, op)
+	if err != nil {
+		return dto.ZoneGeoJSON{}, err
+	}
+	if len(zones) == 0 {
+		return dto.ZoneGeoJSON{}, dto.ErrZoneNotFound
+	}
+	return zones[0], nil
+}
+
+func (s *Storage) FindZonesContainingPoint(ctx context.Context, point dto.Point) ([]dto.ZoneGeoJSON, error) {
+	const op = "storage.FindZonesContainingPoint"
+	const query = selectZonesQuery + `
+		WHERE st_contains(zg.geom, ST_SetSRID(st_point($1, $2), 4326))
+		GROUP BY z.id
+		ORDER BY z.id;`
+
+	rows, err := s.db.Query(ctx, query, point.Lon, point.Lat)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to find zones: %w", op, err)
+	}
+	return scanZones(rows, op)
+}
+
+// NearestZones orders zones by signed geodesic distance from the point to their boundary,
+// the boundary point is the closest one in the lon/lat plane. Candidates are preselected
+// with the GIST index KNN operator, so for huge catalogs the planar preselection may
+// differ from the geodesic order at the tail. The KNN candidates are geometries, a zone
+// with many features near the point takes many of them, so the candidate set is widened
+// until it holds limit zones or every geometry.
+func (s *Storage) NearestZones(ctx context.Context, ids []int, point dto.Point, limit int) ([]dto.NearestZoneOut, error) {
+	const op = "storage.NearestZones"
+	const candidatesFactor = 10
+	const query = `
+		WITH candidates AS (
+			SELECT zg.zone_id
+			FROM zone_geometry zg
+			WHERE COALESCE(cardinality($3::int[]), 0) = 0 OR zg.zone_id = any($3)
+			ORDER BY zg.geom <-> ST_SetSRID(st_point($1, $2), 4326)
+			LIMIT $5
+		), nearest AS (
+			SELECT DISTINCT zone_id FROM candidates
+		), zones AS (
+			SELECT zg.zone_id, ST_Union(zg.geom) AS geom
+			FROM zone_geometry zg
+			JOIN nearest n ON n.zone_id = zg.zone_id
+			GROUP BY zg.zone_id
+		), measured AS (
+			SELECT z.zone_id,
+				   ST_Contains(z.geom, pt.geom) AS inside,
+				   ST_Distance(b.geom::geography, pt.geom::geography) AS distance,
+				   ST_ClosestPoint(b.geom, pt.geom) AS point
+			FROM zones z
+			CROSS JOIN (SELECT ST_SetSRID(st_point($1, $2), 4326) AS geom) pt
+			CROSS JOIN LATERAL (SELECT ST_Boundary(z.geom) AS geom) b
+		)
+		SELECT zone_id,
+			   CASE WHEN inside THEN -distance ELSE distance END AS signed_distance,
+			   inside,
+			   ST_X(point),
+			   ST_Y(point),
+			   (SELECT count(*) FROM candidates) = $5 AS saturated
+		FROM measured
+		ORDER BY signed_distance, zone_id
+		LIMIT $4;`
+
+	zoneIds := &pgtype.Int4Array{}
+	if err := zoneIds.Set(ids); err != nil {
+		return nil, fmt.Errorf("failed to set zone ids: %w", err)
+	}
+
+	for candidates := limit * candidatesFactor; ; candidates *= candidatesFactor {
+		rows, err := s.db.Query(ctx, query, point.Lon, point.Lat, zoneIds, limit, candidates)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to find nearest zones: %w", op, err)
+		}
+		result := make([]dto.NearestZoneOut, 0, limit)
+		saturated := false
+		for rows.Next() {
+			var out dto.NearestZoneOut
+			err = rows.Scan(&out.ZoneId, &out.Distance, &out.Inside, &out.BoundaryPoint.Lon, &out.BoundaryPoint.Lat, &saturated)
+			if err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("%s: failed to scan zone: %w", op, err)
+			}
+			result = append(result, out)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return nil, fmt.Errorf("%s: failed to read zones: %w", op, err)
+		}
+		// An unsaturated candidate set holds every geometry, there are no more zones.
+		if len(result) == limit || !saturated {
+			return result, nil
+		}
+	}
+}
+
+// RouteCrossings clips the route by every zone it crosses. Fractions are measured along
+// the route in the lon/lat plane like ST_LineLocatePoint does.
+func (s *Storage) RouteCrossings(ctx context.Context, ids []int, track []dto.Point) ([]dto.ZoneCrossingOut, error) {
+	const op = "storage.RouteCrossings"
+	// The track is clipped segment by segment and pieces are located on their own segment,
+	// so a route passing a place twice, e.g. out and back, gets both passes. start is the
+	// planar length of the track before the segment, like ST_LineLocatePoint measures it.
+	const query = `
+		WITH points AS (
+			SELECT ST_SetSRID(st_point(t.lon, t.lat), 4326) AS geom, t.idx
+			FROM unnest($1::float8[], $2::float8[]) WITH ORDINALITY AS t(lon, lat, idx)
+		), track AS (
+			SELECT ST_MakeLine(geom ORDER BY idx) AS geom FROM points
+		), segments AS (
+			SELECT s.idx, s.geom, sum(ST_Length(s.geom)) OVER (ORDER BY s.idx) - ST_Length(s.geom) AS start
+			FROM (
+				SELECT idx, ST_MakeLine(lag(geom) OVER (ORDER BY idx), geom) AS geom FROM points
+			) s
+			WHERE s.geom IS NOT NULL
+		), zones AS (
+			SELECT zg.zone_id, ST_Union(zg.geom) AS geom
+			FROM zone_geometry zg
+			CROSS JOIN track t
+			WHERE (COALESCE(cardinality($3::int[]), 0) = 0 OR zg.zone_id = any($3))
+			  AND zg.geom && t.geom
+			GROUP BY zg.zone_id
+		), pieces AS (
+			SELECT z.zone_id, s.geom AS segment, s.start, d.geom
+			FROM zones z
+			JOIN segments s ON z.geom && s.geom
+			CROSS JOIN LATERAL ST_Dump(ST_CollectionExtract(ST_Intersection(z.geom, s.geom), 2)) d
+		)
+		SELECT p.zone_id,
+			   ST_X(ST_StartPoint(p.geom)),
+			   ST_Y(ST_StartPoint(p.geom)),
+			   ST_X(ST_EndPoint(p.geom)),
+			   ST_Y(ST_EndPoint(p.geom)),
+			   COALESCE((p.start + ST_LineLocatePoint(p.segment, ST_StartPoint(p.geom)) * ST_Length(p.segment))
+				   / NULLIF(ST_Length(t.geom), 0), 0),
+			   COALESCE((p.start + ST_LineLocatePoint(p.segment, ST_EndPoint(p.geom)) * ST_Length(p.segment))
+				   / NULLIF(ST_Length(t.geom), 0), 0),
+			   COALESCE(
+				   ST_Length(p.geom::geography) / NULLIF(ST_Length(t.geom::geography), 0),
+				   0
+			   )
+		FROM pieces p
+		CROSS JOIN track t
+		ORDER BY p.zone_id;`
+
+	lons := make([]float64, 0, len(track))
+	lats := make([]float64, 0, len(track))
+	for _, p := range track {
+		lons = append(lons, p.Lon)
+		lats = append(lats, p.Lat)
+	}
+	zoneIds := &pgtype.Int4Array{}
+	if err := zoneIds.Set(ids); err != nil {
+		return nil, fmt.Errorf("failed to set zone ids: %w", err)
+	}
+
+	rows, err := s.db.Query(ctx, query, lons, lats, zoneIds)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to clip route: %w", op, err)
 	}
 	defer rows.Close()
-	result := make([]dto.ZoneGeoJSON, 0, len(ids))
+
+	result := make([]dto.ZoneCrossingOut, 0)
 	for rows.Next() {
-		var zoneGeoJson dto.ZoneGeoJSON
-		err = rows.Scan(&zoneGeoJson.ZoneId, &zoneGeoJson.GeoJSON)
+		var (
+			zoneId   int
+			fraction float64
+			segment  dto.RouteSegment
+		)
+		err = rows.Scan(
+			&zoneId,
+			&segment.Entry.Lon,
+			&segment.Entry.Lat,
+			&segment.Exit.Lon,
+			&segment.Exit.Lat,
+			&segment.EntryFraction,
+			&segment.ExitFraction,
+			&fraction,
+		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan zone: %w", err)
+			return nil, fmt.Errorf("%s: failed to scan route segment: %w", op, err)
 		}
-		result = append(result, zoneGeoJson)
+		if segment.EntryFraction > segment.ExitFraction {
+			segment.Entry, segment.Exit = segment.Exit, segment.Entry
+			segment.EntryFraction, segment.ExitFraction = segment.ExitFraction, segment.EntryFraction
+		}
+		if n := len(result); n == 0 || result[n-1].ZoneId != zoneId {
+			result = append(result, dto.ZoneCrossingOut{ZoneId: zoneId})
+		}
+		crossing := &result[len(result)-1]
+		crossing.Fraction += fraction
+		crossing.Segments = append(crossing.Segments, segment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read route segments: %w", op, err)
+	}
+
+	for i := range result {
+		result[i].Segments = mergeRouteSegments(result[i].Segments)
 	}
 	return result, nil
 }
 
+// routeFractionEpsilon is how close pieces of consecutive track segments must be to join.
+const routeFractionEpsilon = 1e-9
+
+// mergeRouteSegments sorts the pieces of the track segments along the route and joins
+// the ones continuing each other at a track point.
+func mergeRouteSegments(pieces []dto.RouteSegment) []dto.RouteSegment {
+	sort.Slice(pieces, func(a, b int) bool { return pieces[a].EntryFraction < pieces[b].EntryFraction })
+
+	segments := make([]dto.RouteSegment, 0, len(pieces))
+	for _, piece := range pieces {
+		if n := len(segments); n > 0 && piece.EntryFraction-segments[n-1].ExitFraction <= routeFractionEpsilon {
+			if piece.ExitFraction > segments[n-1].ExitFraction {
+				segments[n-1].Exit = piece.Exit
+				segments[n-1].ExitFraction = piece.ExitFraction
+			}
+			continue
+		}
+		segments = append(segments, piece)
+	}
+	return segments
+}
+
+func (s *Storage) GetZonesTile(ctx context.Context, tile dto.TileIn) ([]byte, error) {
+	const op = "storage.GetZonesTile"
+	const query = `
+		WITH bounds AS (
+			SELECT ST_MakeEnvelope($1, $2, $3, $4, 3857) AS geom
+		), mvtgeom AS (
+			SELECT ST_AsMVTGeom(ST_Transform(zg.geom, 3857), bounds.geom) AS geom,
+				   zg.zone_id,
+				   z.name,
+				   COALESCE(zg.properties::jsonb, '{}'::jsonb) AS properties
+			FROM zone_geometry zg
+			JOIN zone z ON z.id = zg.zone_id
+			CROSS JOIN bounds
+			WHERE zg.geom && ST_Transform(bounds.geom, 4326)
+			  AND (COALESCE(cardinality($5::int[]), 0) = 0 OR zg.zone_id = any($5))
+			  AND ($6::text = '' OR $6::text = any(z.tags))
+		)
+		SELECT COALESCE(ST_AsMVT(mvtgeom.*, 'zones', 4096, 'geom'), ''::bytea)
+		FROM mvtgeom;`
+
+	zoneIds := &pgtype.Int4Array{}
+	if err := zoneIds.Set([]int(tile.ZoneIds)); err != nil {
+		return nil, fmt.Errorf("failed to set zone ids: %w", err)
+	}
+
+	minX, minY, maxX, maxY := tile.Envelope()
+	var mvt []byte
+	err := s.db.QueryRow(ctx, query, minX, minY, maxX, maxY, zoneIds, tile.Tag).Scan(&mvt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to build tile: %w", op, err)
+	}
+	return mvt, nil
+}
+
 func (s *Storage) GetZonesCount(ctx context.Context) (int, error) {
 	const query = `SELECT COUNT(*) FROM zone;`
 	var count int
@@ -126,179 +573,459 @@ func (s *Storage) GetZonesCount(ctx context.Context) (int, error) {
 	return count, nil
 }
 
-func (s *Storage) ContainsPoint(ctx context.Context, ids []int, point dto.Point) ([]dto.ZoneContainsPointOut, error) {
+func (s *Storage) ContainsPoint(ctx context.Context, ids []int, point dto.Point, opts dto.ContainsOptions) ([]dto.ZoneContainsPointOut, error) {
 	const op = "storage.ZonesContainsPoint"
 	const query = `
-		SELECT zg.zone_id,
-			   bool_or(st_contains(zg.geom, st_point($1, $2))) as res
-		FROM zone_geometry zg
+		WITH point AS (
+			SELECT ST_SetSRID(st_point($1, $2), 4326) AS geom, $4::text AS predicate, $5::float8 AS tolerance, $6::timestamptz AS at
+		)
+		SELECT zg.zone_id, zg.schedule,
+			   bool_or(` + pointMatchCondition + `) as res,
+			   max(` + pointPositionRank + `) as position
+		FROM point p
+		CROSS JOIN LATERAL ` + zoneGeometriesAt + ` zg
 		WHERE zone_id = any($3)
-		GROUP BY zg.zone_id;`
+		GROUP BY zg.zone_id, zg.schedule;`
 
 	zoneIds := &pgtype.Int4Array{}
 	if err := zoneIds.Set(ids); err != nil {
 		return nil, fmt.Errorf("failed to set zone ids: %w", err)
 	}
 
-	rows, err := s.db.Query(ctx, query, point.Lon, point.Lat, zoneIds)
+	rows, err := s.db.Query(ctx, query, point.Lon, point.Lat, zoneIds, opts.PredicateOrDefault(), opts.Tolerance, opts.At)
 	if err != nil {
 		return nil, fmt.Errorf("%s: failed to check contains point: %w", op, err)
 	}
 	defer rows.Close()
 
+	evaluatedAt := opts.TimeOrNow()
+
 	result := make([]dto.ZoneContainsPointOut, 0, len(ids))
 	for rows.Next() {
 		var zoneContainsPointOut dto.ZoneContainsPointOut
-		err = rows.Scan(&zoneContainsPointOut.ZoneId, &zoneContainsPointOut.Contains)
+		var schedule *dto.ZoneSchedule
+		var rank int
+		err = rows.Scan(&zoneContainsPointOut.ZoneId, &schedule, &zoneContainsPointOut.Contains, &rank)
 		if err != nil {
 			return nil, fmt.Errorf("%s: failed to scan zone: %w", op, err)
 		}
+		zoneContainsPointOut.Position = positionFromRank(rank)
+		if !isActive(schedule, evaluatedAt) {
+			zoneContainsPointOut.Contains = false
+			zoneContainsPointOut.Position = dto.PositionOutside
+		}
 		result = append(result, zoneContainsPointOut)
 	}
-
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read zones: %w", op, err)
+	}
 	return result, nil
 }
 
-func (s *Storage) AnyContainsPoint(ctx context.Context, ids []int, point dto.Point) (bool, error) {
-	con, err := s.db.Acquire(ctx)
+func (s *Storage) AnyContainsPoint(ctx context.Context, ids []int, point dto.Point, opts dto.ContainsOptions) (dto.AnyContainsPointOut, error) {
+	const op = "storage.AnyContainsPoint"
+	const query = `
+		WITH point AS (
+			SELECT ST_SetSRID(st_point($2, $3), 4326) AS geom, $4::text AS predicate, $5::float8 AS tolerance, $6::timestamptz AS at
+		), candidates AS (
+			SELECT zg.zone_id, zg.geom, zg.schedule
+			FROM point p
+			CROSS JOIN LATERAL ` + zoneGeometriesAt + ` zg
+			WHERE zg.zone_id = any($1) AND ` + pointCandidateCondition + `
+		), inside AS (
+			-- Like EXISTS, the first unscheduled zone the point is inside of answers alone,
+			-- the other zones are not ranked then.
+			SELECT EXISTS (
+				SELECT 1 FROM point p, candidates zg WHERE zg.schedule IS NULL AND ` + pointInsideCondition + `
+			) AS found
+		)
+		SELECT NULL::jsonb, true, 2 FROM inside WHERE found
+		UNION ALL
+		SELECT zg.schedule,
+			   bool_or(` + pointMatchCondition + `),
+			   max(` + pointPositionRank + `)
+		FROM point p, candidates zg
+		WHERE NOT (SELECT found FROM inside)
+		GROUP BY zg.zone_id, zg.schedule;`
+
+	var out dto.AnyContainsPointOut
+	zoneIds := &pgtype.Int4Array{}
+	if err := zoneIds.Set(ids); err != nil {
+		return out, fmt.Errorf("failed to set zone ids: %w", err)
+	}
+	rows, err := s.db.Query(ctx, query, zoneIds, point.Lon, point.Lat, opts.PredicateOrDefault(), opts.Tolerance, opts.At)
 	if err != nil {
-		return false, fmt.Errorf("failed to acquire connection: %w", err)
-	}
-
-	return s.anyContains(ctx, con, ids, point)
-	//const op = "storage.AnyContainsPoint"
-	//const query = `
-	//	SELECT  CASE WHEN count(*) > 0 THEN true ELSE false END as contains
-	//	FROM zone_geometry zg
-	//	WHERE zg.zone_id = any($1) and st_contains(zg.geom, st_point($2, $3));`
-	//
-	//var contains bool
-	//zoneIds := &pgtype.Int4Array{}
-	//if err := zoneIds.Set(ids); err != nil {
-	//	return contains, fmt.Errorf("failed to set zone ids: %w", err)
-	//}
-	//err := s.db.QueryRow(ctx, query, zoneIds, point.Lon, point.Lat).Scan(&contains)
-	//if err != nil {
-	//	return contains, fmt.Errorf("%s: failed to check contains point: %w", op, err)
-	//}
-	//return contains, nil
+		return out, fmt.Errorf("%s: failed to check contains point: %w", op, err)
+	}
+	defer rows.Close()
+
+	evaluatedAt := opts.TimeOrNow()
+
+	maxRank := 0
+	for rows.Next() {
+		var schedule *dto.ZoneSchedule
+		var contains bool
+		var rank int
+		if err = rows.Scan(&schedule, &contains, &rank); err != nil {
+			return out, fmt.Errorf("%s: failed to scan zone: %w", op, err)
+		}
+		if !isActive(schedule, evaluatedAt) {
+			continue
+		}
+		out.Contains = out.Contains || contains
+		maxRank = max(maxRank, rank)
+	}
+	if err = rows.Err(); err != nil {
+		return out, fmt.Errorf("%s: failed to check contains point: %w", op, err)
+	}
+	out.Position = positionFromRank(maxRank)
+	return out, nil
 }
 
 func (s *Storage) DeleteZoneById(ctx context.Context, id int) error {
 	const op = "storage.DeleteZoneById"
-	const deleteZoneQuery = `DELETE FROM zone WHERE id = $1;`
 
-	_, err := s.db.Exec(ctx, deleteZoneQuery, id)
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
-		return fmt.Errorf("%s: failed to delete zone: %w", op, err)
+		return fmt.Errorf("%s: failed to start transaction: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			rollbackErr := tx.Rollback(ctx)
+			if rollbackErr != nil {
+				err = baseErr.Join(err, rollbackErr)
+			}
+			return
+		}
+	}()
+
+	if err = deleteZone(ctx, tx, id); err != nil {
+		return err
+	}
+	if err = s.commitZoneChanges(ctx, tx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
 }
 
 ///////
 
-func (s *Storage) anyContains(ctx context.Context, conn *pgxpool.Conn, ids []int, point dto.Point) (bool, error) {
-	const op = "storage.AnyContainsPoint"
-	const query = `
-		SELECT  CASE WHEN count(*) > 0 THEN true ELSE false END as contains
-		FROM zone_geometry zg
-		WHERE zg.zone_id = any($1) and st_contains(zg.geom, st_point($2, $3));`
+func scanZones(rows pgx.Rows, op string) ([]dto.ZoneGeoJSON, error) {
+	defer rows.Close()
 
-	var contains bool
-	zoneIds := &pgtype.Int4Array{}
-	if err := zoneIds.Set(ids); err != nil {
-		return contains, fmt.Errorf("failed to set zone ids: %w", err)
+	result := make([]dto.ZoneGeoJSON, 0)
+	for rows.Next() {
+		zoneGeoJson, err := scanZone(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan zone: %w", op, err)
+		}
+		result = append(result, zoneGeoJson)
 	}
-	err := conn.QueryRow(ctx, query, zoneIds, point.Lon, point.Lat).Scan(&contains)
-	if err != nil {
-		return contains, fmt.Errorf("%s: failed to check contains point: %w", op, err)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read zones: %w", op, err)
 	}
-	return contains, nil
+	return result, nil
 }
 
-type BatchZoneContainsPointOutWithError struct {
-	dto.BatchZoneContainsPointOut
-	Error error
+// scanZone scans a row of selectZonesQuery.
+func scanZone(rows pgx.Rows) (dto.ZoneGeoJSON, error) {
+	var zoneGeoJson dto.ZoneGeoJSON
+	err := rows.Scan(
+		&zoneGeoJson.ZoneId,
+		&zoneGeoJson.Name,
+		&zoneGeoJson.ExternalKey,
+		&zoneGeoJson.Tags,
+		&zoneGeoJson.Schedule,
+		&zoneGeoJson.Layer,
+		&zoneGeoJson.CreatedAt,
+		&zoneGeoJson.UpdatedAt,
+		&zoneGeoJson.GeoJSON,
+	)
+	return zoneGeoJson, err
 }
 
-func (s *Storage) ButchAnyZoneContainsPoint(ctx context.Context, in dto.BatchZoneContainsPointInCollection) ([]dto.BatchZoneContainsPointOut, error) {
-	const op = "service.ButchAnyZoneContainsPoint"
-	var maxWorkers = 50
+func positionFromRank(rank int) dto.Position {
+	switch rank {
+	case 2:
+		return dto.PositionInside
+	case 1:
+		return dto.PositionOnEdge
+	default:
+		return dto.PositionOutside
+	}
+}
+
+func tagsOrEmpty(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
 
-	workersCnt := len(in)
-	if workersCnt > maxWorkers {
-		workersCnt = maxWorkers
+// foreignMembersOrNull encodes the members as a jsonb object, NULL when there are none.
+func foreignMembersOrNull(members dto.ForeignMembers) ([]byte, error) {
+	if len(members) == 0 {
+		return nil, nil
 	}
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
+	return json.Marshal(members)
+}
+
+// arealGeometry and sourceGeometry store geometry g: points and lines are buffered by radius
+// into the zone geometry and kept as its source, polygons are stored as they are.
+const (
+	arealGeometry  = `zone_areal_geometry(g.geom, g.radius)`
+	sourceGeometry = `CASE WHEN GeometryType(g.geom) IN ('POLYGON', 'MULTIPOLYGON') THEN NULL ELSE g.geom END`
+)
 
-	resultChan := make(chan BatchZoneContainsPointOutWithError, len(in))
-	jobs := make(chan dto.BatchZoneContainsPointIn, len(in))
+// radiusOrNull is NULL for areal geometries, they have no radius.
+func radiusOrNull(radius float64) *float64 {
+	if radius == 0 {
+		return nil
+	}
+	return &radius
+}
 
-	go func() {
-		defer close(jobs)
+// insertFeatures stores the features declared in srid reprojected to EPSG:4326.
+func (s *Storage) insertFeatures(ctx context.Context, tx pgx.Tx, zoneId int, features []*geojson.Feature, srid int) error {
+	const createGeometry = `
+		INSERT INTO zone_geometry (zone_id, geom, source_geom, radius, properties, feature_id, foreign_members)
+		SELECT $1::int, ` + arealGeometry + `, ` + sourceGeometry + `, g.radius, $4::json, $5::jsonb, $6::jsonb
+		FROM (SELECT ST_Transform(ST_SetSRID(ST_GeomFromEWKB($2), $7::int), 4326) AS geom, $3::float8 AS radius) g`
 
-		for _, v := range in {
-			jobs <- v
+	for _, feature := range features {
+		members, err := foreignMembersOrNull(feature.ForeignMembers)
+		if err != nil {
+			return fmt.Errorf("failed to encode foreign members: %w", err)
 		}
-	}()
+		_, err = tx.Exec(ctx, createGeometry,
+			zoneId, feature.Geometry.ToEwkb(), radiusOrNull(feature.Radius), feature.Properties, feature.Id, members, srid,
+		)
+		if err != nil {
+			return parsePostgisError(err)
+		}
+	}
+	return nil
+}
 
-	wg := &sync.WaitGroup{}
+// createZone inserts the zone into its layer, DefaultLayer when the metadata has none,
+// and records the change.
+func (s *Storage) createZone(ctx context.Context, tx pgx.Tx, featureCollection geojson.FeatureCollection) (int, error) {
+	zoneId, err := s.insertZone(ctx, tx, featureCollection)
+	if err != nil {
+		return zoneId, err
+	}
+	if err = recordZoneChange(ctx, tx, dto.ZoneChangeCreated, zoneId); err != nil {
+		return zoneId, err
+	}
+	return zoneId, nil
+}
 
-	for i := 0; i < workersCnt; i++ {
-		wg.Add(1)
+// insertZone is createZone without recording the change, for callers recording many.
+func (s *Storage) insertZone(ctx context.Context, tx pgx.Tx, featureCollection geojson.FeatureCollection) (int, error) {
+	const createZoneQuery = `
+		INSERT INTO zone (name, external_key, tags, schedule, layer, foreign_members)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;`
 
-		go func() {
-			defer wg.Done()
+	var metadata dto.ZoneMetadata
+	if featureCollection.Metadata != nil {
+		metadata = *featureCollection.Metadata
+	}
+	if metadata.Layer == "" {
+		metadata.Layer = dto.DefaultLayer
+	}
 
-			conn, err := s.db.Acquire(ctx)
-			if err != nil {
-				resultChan <- BatchZoneContainsPointOutWithError{
-					Error: fmt.Errorf("%s: failed to acquire connection: %w", op, err),
-				}
-				return
-			}
-			defer conn.Release()
-			s.batchAnyZoneWorker(ctx, conn, jobs, resultChan)
-		}()
+	var zoneId int
+	members, err := foreignMembersOrNull(featureCollection.ForeignMembers)
+	if err != nil {
+		return zoneId, fmt.Errorf("failed to encode foreign members: %w", err)
+	}
+	err = tx.QueryRow(ctx, createZoneQuery,
+		metadata.Name, metadata.ExternalKey, tagsOrEmpty(metadata.Tags), metadata.Schedule, metadata.Layer, members,
+	).Scan(&zoneId)
+	if err != nil {
+		return zoneId, parseZoneError(fmt.Errorf("failed to create zone: %w", err))
+	}
+	if err = s.insertFeatures(ctx, tx, zoneId, featureCollection.Features, featureCollection.CRS()); err != nil {
+		return zoneId, err
+	}
+	if err = addZoneVersion(ctx, tx, zoneId); err != nil {
+		return zoneId, err
 	}
+	return zoneId, nil
+}
 
-	go func() {
-		defer close(resultChan)
-		wg.Wait()
-	}()
+// replaceZone replaces the features and foreign members of a locked zone, and its metadata
+// when it is set, and records the change. A metadata without a layer keeps the zone in its layer.
+func (s *Storage) replaceZone(ctx context.Context, tx pgx.Tx, zoneId int, featureCollection geojson.FeatureCollection) error {
+	if err := s.overwriteZone(ctx, tx, zoneId, featureCollection); err != nil {
+		return err
+	}
+	return recordZoneChange(ctx, tx, dto.ZoneChangeUpdated, zoneId)
+}
+
+// overwriteZone is replaceZone without recording the change, for callers recording many.
+func (s *Storage) overwriteZone(ctx context.Context, tx pgx.Tx, zoneId int, featureCollection geojson.FeatureCollection) error {
+	const op = "storage.overwriteZone"
+	const deleteGeometry = `DELETE FROM zone_geometry WHERE zone_id = $1;`
+	const updateForeignMembers = `UPDATE zone SET foreign_members = $1 WHERE id = $2;`
+	const updateMetadata = `
+		UPDATE zone
+		SET name = $1, external_key = $2, tags = $3, schedule = $4, layer = COALESCE(NULLIF($5, ''), layer),
+			updated_at = now()
+		WHERE id = $6;`
 
-	results := make([]dto.BatchZoneContainsPointOut, 0, len(in))
-	for res := range resultChan {
-		if res.Error != nil {
-			return nil, fmt.Errorf("%s: %w", op, res.Error)
+	if metadata := featureCollection.Metadata; metadata != nil {
+		_, err := tx.Exec(ctx, updateMetadata,
+			metadata.Name, metadata.ExternalKey, tagsOrEmpty(metadata.Tags), metadata.Schedule, metadata.Layer, zoneId,
+		)
+		if err != nil {
+			return parseZoneError(fmt.Errorf("%s: failed to update zone: %w", op, err))
 		}
-		results = append(results, res.BatchZoneContainsPointOut)
 	}
-	return results, nil
+	members, err := foreignMembersOrNull(featureCollection.ForeignMembers)
+	if err != nil {
+		return fmt.Errorf("%s: failed to encode foreign members: %w", op, err)
+	}
+	if _, err = tx.Exec(ctx, updateForeignMembers, members, zoneId); err != nil {
+		return fmt.Errorf("%s: failed to update zone: %w", op, err)
+	}
+	if _, err := tx.Exec(ctx, deleteGeometry, zoneId); err != nil {
+		return fmt.Errorf("%s: failed to delete zone geometry: %w", op, err)
+	}
+	if err := s.insertFeatures(ctx, tx, zoneId, featureCollection.Features, featureCollection.CRS()); err != nil {
+		return err
+	}
+	return addZoneVersion(ctx, tx, zoneId)
 }
 
-func (s *Storage) batchAnyZoneWorker(
-	ctx context.Context,
-	conn *pgxpool.Conn,
-	jobs <-chan dto.BatchZoneContainsPointIn,
-	results chan<- BatchZoneContainsPointOutWithError,
-) {
+// deleteZone deletes the zone, closes its history and records the change, deleting
+// a missing zone is a no-op.
+func deleteZone(ctx context.Context, tx pgx.Tx, zoneId int) error {
+	deleted, err := removeZone(ctx, tx, zoneId)
+	if err != nil || !deleted {
+		return err
+	}
+	return recordZoneChange(ctx, tx, dto.ZoneChangeDeleted, zoneId)
+}
 
-	for job := range jobs {
-		select {
-		case <-ctx.Done():
-			results <- BatchZoneContainsPointOutWithError{Error: ctx.Err()}
-			return
-		default:
-			contains, err := s.anyContains(ctx, conn, job.ZoneIds, job.Point)
-			results <- BatchZoneContainsPointOutWithError{
-				BatchZoneContainsPointOut: dto.BatchZoneContainsPointOut{
-					Key:      job.Key,
-					Contains: contains,
-				},
-				Error: err,
-			}
+// removeZone is deleteZone without recording the change, it reports whether the zone existed.
+func removeZone(ctx context.Context, tx pgx.Tx, zoneId int) (bool, error) {
+	const op = "storage.removeZone"
+	const deleteZoneQuery = `DELETE FROM zone WHERE id = $1;`
+
+	tag, err := tx.Exec(ctx, deleteZoneQuery, zoneId)
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to delete zone: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	if err = closeZoneVersion(ctx, tx, zoneId); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *Storage) lockZone(ctx context.Context, tx pgx.Tx, zoneId int) error {
+	const op = "storage.lockZone"
+	const query = `UPDATE zone SET updated_at = now() WHERE id = $1 RETURNING id;`
+
+	var id int
+	err := tx.QueryRow(ctx, query, zoneId).Scan(&id)
+	if baseErr.Is(err, pgx.ErrNoRows) {
+		return dto.ErrZoneNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%s: failed to lock zone: %w", op, err)
+	}
+	return nil
+}
+
+// ButchAnyZoneContainsPoint checks the whole batch in a single query. Points and
+// (point, zone) pairs are passed as flat arrays and expanded with unnest. Errors the
+// input of an item may cause are marked with dto.ErrBatchItemRejected.
+func (s *Storage) ButchAnyZoneContainsPoint(ctx context.Context, in dto.BatchZoneContainsPointInCollection) ([]dto.BatchZoneContainsPointOut, error) {
+	const op = "storage.ButchAnyZoneContainsPoint"
+	const query = `
+		WITH points AS (
+			SELECT key, ST_SetSRID(st_point(lon, lat), 4326) AS geom, predicate, tolerance, at, idx
+			FROM unnest($1::text[], $2::float8[], $3::float8[], $6::text[], $7::float8[], $8::timestamptz[])
+				WITH ORDINALITY AS u(key, lon, lat, predicate, tolerance, at, idx)
+		), pairs AS (
+			SELECT * FROM unnest($4::int[], $5::int[]) AS c(idx, zone_id)
+		)
+		SELECT p.idx, m.schedule,
+			   COALESCE(m.contains, false),
+			   COALESCE(m.position, 0)
+		FROM points p
+		LEFT JOIN LATERAL (
+			SELECT zg.schedule,
+				   bool_or(` + pointMatchCondition + `) as contains,
+				   max(` + pointPositionRank + `) as position
+			FROM pairs c
+			JOIN ` + zoneGeometriesAt + ` zg ON zg.zone_id = c.zone_id
+			WHERE c.idx = p.idx AND ` + pointCandidateCondition + `
+			GROUP BY zg.zone_id, zg.schedule
+		) m ON true
+		ORDER BY p.idx;`
+
+	keys := make([]string, 0, len(in))
+	lons := make([]float64, 0, len(in))
+	lats := make([]float64, 0, len(in))
+	predicates := make([]string, 0, len(in))
+	tolerances := make([]float64, 0, len(in))
+	ats := make([]*time.Time, 0, len(in))
+	pairIdx := make([]int32, 0, len(in))
+	pairZoneIds := make([]int32, 0, len(in))
+	for i, v := range in {
+		keys = append(keys, v.Key)
+		lons = append(lons, v.Point.Lon)
+		lats = append(lats, v.Point.Lat)
+		predicates = append(predicates, string(v.PredicateOrDefault()))
+		tolerances = append(tolerances, v.Tolerance)
+		ats = append(ats, v.At)
+		for _, zoneId := range v.ZoneIds {
+			// ordinality is 1-based
+			pairIdx = append(pairIdx, int32(i+1))
+			pairZoneIds = append(pairZoneIds, int32(zoneId))
+		}
+	}
+
+	rows, err := s.db.Query(ctx, query, keys, lons, lats, pairIdx, pairZoneIds, predicates, tolerances, ats)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to check contains point: %w", op, parseBatchItemError(err))
+	}
+	defer rows.Close()
+
+	// Points get a row per candidate zone, or a single row without a schedule when they have none.
+	evaluatedAt := make([]time.Time, len(in))
+	ranks := make([]int, len(in))
+	results := make([]dto.BatchZoneContainsPointOut, len(in))
+	for i, v := range in {
+		evaluatedAt[i] = v.TimeOrNow()
+		results[i].Key = v.Key
+	}
+	for rows.Next() {
+		var idx int
+		var schedule *dto.ZoneSchedule
+		var contains bool
+		var rank int
+		if err = rows.Scan(&idx, &schedule, &contains, &rank); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan result: %w", op, err)
 		}
+		// ordinality is 1-based
+		i := idx - 1
+		if !isActive(schedule, evaluatedAt[i]) {
+			continue
+		}
+		results[i].Contains = results[i].Contains || contains
+		ranks[i] = max(ranks[i], rank)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read results: %w", op, parseBatchItemError(err))
 	}
+	for i := range results {
+		results[i].Position = positionFromRank(ranks[i])
+	}
+	return results, nil
 }
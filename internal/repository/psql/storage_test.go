@@ -0,0 +1,22 @@
+package psql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+func TestMergeRouteSegments(t *testing.T) {
+	pieces := []dto.RouteSegment{
+		{Entry: dto.Point{Lon: 1}, Exit: dto.Point{Lon: 0}, EntryFraction: 0.6, ExitFraction: 0.7},
+		{Entry: dto.Point{Lon: 0.5}, Exit: dto.Point{Lon: 1}, EntryFraction: 0.25, ExitFraction: 0.3},
+		{Entry: dto.Point{Lon: 0}, Exit: dto.Point{Lon: 0.5}, EntryFraction: 0.2, ExitFraction: 0.25},
+	}
+
+	require.Equal(t, []dto.RouteSegment{
+		{Entry: dto.Point{Lon: 0}, Exit: dto.Point{Lon: 1}, EntryFraction: 0.2, ExitFraction: 0.3},
+		{Entry: dto.Point{Lon: 1}, Exit: dto.Point{Lon: 0}, EntryFraction: 0.6, ExitFraction: 0.7},
+	}, mergeRouteSegments(pieces))
+}
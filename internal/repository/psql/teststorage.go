@@ -37,12 +37,17 @@ func NewTestStorage(ctx context.Context) (*TestStorage, error) {
 
 func (t *TestStorage) CleanDB(ctx context.Context) {
 	const op = "psql.CleanDB"
-	const deleteZoneData = `TRUNCATE zone, zone_geometry RESTART IDENTITY CASCADE;`
+	const deleteZoneData = `TRUNCATE zone, zone_geometry, zone_version, zone_geometry_version, zone_change, webhook_subscription, webhook_event, webhook_delivery, layer_version RESTART IDENTITY CASCADE;`
+	const deleteLayers = `DELETE FROM layer WHERE name <> 'default';`
 
 	_, err := t.Storage.db.Exec(ctx, deleteZoneData)
 	if err != nil {
 		t.log.Errorf("%s: failed to clean up test database: %s", op, err.Error())
 	}
+	_, err = t.Storage.db.Exec(ctx, deleteLayers)
+	if err != nil {
+		t.log.Errorf("%s: failed to clean up test layers: %s", op, err.Error())
+	}
 }
 
 func (t *TestStorage) ShutDown() {
@@ -0,0 +1,189 @@
+package psql
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/twpayne/go-geom/encoding/ewkb"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+// zoneGeometryErrors validates the feature geometries of the zones, the errors are returned
+// by zone index. Geometries failing geojson validation are not sent to PostGIS, the others are
+// checked with ST_IsValidDetail in a single query.
+func (s *Storage) zoneGeometryErrors(ctx context.Context, zones []geojson.FeatureCollection) (map[int][]dto.GeometryError, error) {
+	const op = "storage.zoneGeometryErrors"
+	const query = `
+		SELECT g.zone, g.feature, d.reason, ST_X(d.location), ST_Y(d.location)
+		FROM unnest($1::int[], $2::int[], $3::bytea[]) AS g(zone, feature, geom)
+		CROSS JOIN LATERAL ST_IsValidDetail(ST_GeomFromEWKB(g.geom)) d
+		WHERE NOT d.valid
+		ORDER BY g.zone, g.feature;`
+
+	result := make(map[int][]dto.GeometryError)
+	zoneIndexes, featureIndexes, geometries := make([]int, 0), make([]int, 0), make([][]byte, 0)
+	for i, featureCollection := range zones {
+		invalid := make(map[int]struct{})
+		for _, geometryErr := range featureCollection.Validate() {
+			invalid[geometryErr.Feature] = struct{}{}
+			result[i] = append(result[i], geometryErr)
+		}
+		for j, feature := range featureCollection.Features {
+			if _, ok := invalid[j]; ok {
+				continue
+			}
+			data, err := ewkb.Marshal(feature.Geometry.Geom(), binary.LittleEndian)
+			if err != nil {
+				return nil, fmt.Errorf("%s: failed to encode geometry: %w", op, err)
+			}
+			zoneIndexes, featureIndexes, geometries = append(zoneIndexes, i), append(featureIndexes, j), append(geometries, data)
+		}
+	}
+	if len(geometries) == 0 {
+		return result, nil
+	}
+
+	rows, err := s.db.Query(ctx, query, zoneIndexes, featureIndexes, geometries)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to validate geometries: %w", op, err)
+	}
+	type invalidGeometry struct {
+		Zone    int
+		Feature int
+		Reason  string
+		X       *float64
+		Y       *float64
+	}
+	invalid, err := pgx.CollectRows(rows, pgx.RowToStructByPos[invalidGeometry])
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to validate geometries: %w", op, err)
+	}
+	for _, g := range invalid {
+		result[g.Zone] = append(result[g.Zone], newGeometryError(g.Feature, g.Reason, g.X, g.Y))
+	}
+	for _, errs := range result {
+		sortGeometryErrors(errs)
+	}
+	return result, nil
+}
+
+// validateZoneGeometries rejects the zone with dto.GeometryValidationErr when any of its geometries is invalid,
+// and with dto.ErrUnknownCRS when its crs cannot be reprojected from.
+func (s *Storage) validateZoneGeometries(ctx context.Context, featureCollection geojson.FeatureCollection) error {
+	if err := s.checkSRID(ctx, featureCollection.CRS()); err != nil {
+		return err
+	}
+	errs, err := s.zoneGeometryErrors(ctx, []geojson.FeatureCollection{featureCollection})
+	if err != nil {
+		return err
+	}
+	if len(errs[0]) > 0 {
+		return dto.GeometryValidationErr{Errors: errs[0]}
+	}
+	return nil
+}
+
+// RepairGeometries fixes invalid geometries with ST_MakeValid keeping the polygonal parts of
+// polygons, the repairs describe what changed. Geometries with broken rings or coordinates cannot be repaired,
+// as well as geometries with nothing polygonal left, the zone is rejected with dto.GeometryValidationErr then.
+func (s *Storage) RepairGeometries(ctx context.Context, featureCollection geojson.FeatureCollection) (geojson.FeatureCollection, []dto.GeometryRepair, error) {
+	const op = "storage.RepairGeometries"
+	const query = `
+		SELECT g.feature, d.reason, ST_X(d.location), ST_Y(d.location),
+			   CASE WHEN ST_IsEmpty(r.geom) THEN NULL ELSE ST_AsEWKB(r.geom) END
+		FROM unnest($1::int[], $2::bytea[]) AS g(feature, geom)
+		CROSS JOIN LATERAL (SELECT ST_GeomFromEWKB(g.geom) AS geom) s
+		CROSS JOIN LATERAL ST_IsValidDetail(s.geom) d
+		CROSS JOIN LATERAL (
+			SELECT CASE WHEN GeometryType(s.geom) IN ('POLYGON', 'MULTIPOLYGON')
+						THEN ST_CollectionExtract(ST_MakeValid(s.geom), 3)
+						ELSE ST_MakeValid(s.geom) END AS geom
+		) r
+		WHERE NOT d.valid
+		ORDER BY g.feature;`
+
+	repairs := make([]dto.GeometryRepair, 0)
+	if errs := dto.UnrepairableGeometryErrors(featureCollection.Validate()); len(errs) > 0 {
+		return featureCollection, repairs, dto.GeometryValidationErr{Errors: errs}
+	}
+
+	featureIndexes, geometries := make([]int, 0, len(featureCollection.Features)), make([][]byte, 0, len(featureCollection.Features))
+	for i, feature := range featureCollection.Features {
+		data, err := ewkb.Marshal(feature.Geometry.Geom(), binary.LittleEndian)
+		if err != nil {
+			return featureCollection, repairs, fmt.Errorf("%s: failed to encode geometry: %w", op, err)
+		}
+		featureIndexes, geometries = append(featureIndexes, i), append(geometries, data)
+	}
+
+	rows, err := s.db.Query(ctx, query, featureIndexes, geometries)
+	if err != nil {
+		return featureCollection, repairs, fmt.Errorf("%s: failed to repair geometries: %w", op, err)
+	}
+	type repairedGeometry struct {
+		Feature  int
+		Reason   string
+		X        *float64
+		Y        *float64
+		Repaired []byte
+	}
+	repaired, err := pgx.CollectRows(rows, pgx.RowToStructByPos[repairedGeometry])
+	if err != nil {
+		return featureCollection, repairs, fmt.Errorf("%s: failed to repair geometries: %w", op, err)
+	}
+
+	result := featureCollection
+	result.Features = append([]*geojson.Feature(nil), featureCollection.Features...)
+	var unrepaired []dto.GeometryError
+	for _, g := range repaired {
+		geometryErr := newGeometryError(g.Feature, g.Reason, g.X, g.Y)
+		if g.Repaired == nil {
+			unrepaired = append(unrepaired, geometryErr)
+			continue
+		}
+		decoded, err := ewkb.Unmarshal(g.Repaired)
+		if err != nil {
+			return featureCollection, repairs, fmt.Errorf("%s: failed to decode repaired geometry: %w", op, err)
+		}
+		geometry, err := geojson.NewPostgisGeometry(decoded)
+		if err != nil {
+			return featureCollection, repairs, fmt.Errorf("%s: %w", op, err)
+		}
+
+		original := featureCollection.Features[g.Feature]
+		feature := *original
+		feature.Geometry = geometry
+		result.Features[g.Feature] = &feature
+		repairs = append(repairs, dto.GeometryRepair{
+			GeometryError: geometryErr,
+			FromType:      geojson.GeometryType(original.Geometry.Geom()),
+			ToType:        geojson.GeometryType(decoded),
+		})
+	}
+	if len(unrepaired) > 0 {
+		return featureCollection, repairs, dto.GeometryValidationErr{Errors: unrepaired}
+	}
+	return result, repairs, nil
+}
+
+func newGeometryError(feature int, reason string, x, y *float64) dto.GeometryError {
+	geometryErr := dto.GeometryError{
+		Feature: feature,
+		Reason:  dto.GeometryErrorReasonOf(reason),
+		Message: reason,
+		Path:    geojson.GeometryPointer(feature),
+	}
+	if x != nil && y != nil {
+		geometryErr.Location = &dto.Point{Lon: *x, Lat: *y}
+	}
+	return geometryErr
+}
+
+func sortGeometryErrors(errs []dto.GeometryError) {
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Feature < errs[j].Feature })
+}
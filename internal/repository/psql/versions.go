@@ -0,0 +1,182 @@
+package psql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+// featureCollectionAgg aggregates geometries gv into a FeatureCollection, zones without geometries
+// get no features and no bbox.
+const featureCollectionAgg = `
+			   jsonb_strip_nulls(jsonb_build_object(
+					   'type', 'FeatureCollection',
+					   'bbox', CASE WHEN count(gv.id) > 0 THEN jsonb_build_array(
+							   min(ST_XMin(gv.geom)), min(ST_YMin(gv.geom)), max(ST_XMax(gv.geom)), max(ST_YMax(gv.geom))
+						   ) END
+			   )) || jsonb_build_object(
+					   'features', COALESCE(jsonb_agg(
+							   COALESCE(gv.foreign_members, '{}'::jsonb) || jsonb_build_object(
+									   'type', 'Feature',
+									   'geometry', ST_AsGeoJSON(gv.geom)::jsonb,
+									   'properties', gv.properties,
+									   'bbox', jsonb_build_array(ST_XMin(gv.geom), ST_YMin(gv.geom), ST_XMax(gv.geom), ST_YMax(gv.geom))
+							   ) || jsonb_strip_nulls(jsonb_build_object(
+									   'id', gv.feature_id,
+									   'radius', gv.radius,
+									   'source_geometry', ST_AsGeoJSON(gv.source_geom)::jsonb
+							   )) ORDER BY gv.id
+								   ) FILTER (WHERE gv.id IS NOT NULL), '[]'::jsonb)
+			   )`
+
+// selectZonesAtQuery selects zones as they were at $2. Metadata is not versioned,
+// it is the current one, and empty for deleted zones.
+const selectZonesAtQuery = zonesAtColumns + `
+		FROM zone_version v
+		LEFT JOIN zone z ON z.id = v.zone_id
+		LEFT JOIN zone_geometry_version gv ON gv.zone_id = v.zone_id AND gv.version = v.version
+		WHERE v.zone_id = any($1)
+		  AND v.valid_from <= $2 AND (v.valid_to IS NULL OR v.valid_to > $2)
+		GROUP BY v.zone_id, v.version, z.id;`
+
+// selectZonesAtInCRSQuery is selectZonesAtQuery with the geometries reprojected to srid $3.
+// Every geometry is transformed once in the lateral subquery.
+const selectZonesAtInCRSQuery = zonesAtColumns + `
+		FROM zone_version v
+		LEFT JOIN zone z ON z.id = v.zone_id
+		LEFT JOIN LATERAL (
+			SELECT g.id, g.feature_id, g.properties, g.foreign_members,
+				   ST_Transform(g.geom, $3::int) AS geom, ST_Transform(g.source_geom, $3::int) AS source_geom
+			FROM zone_geometry_version g
+			WHERE g.zone_id = v.zone_id AND g.version = v.version
+		) gv ON true
+		WHERE v.zone_id = any($1)
+		  AND v.valid_from <= $2 AND (v.valid_to IS NULL OR v.valid_to > $2)
+		GROUP BY v.zone_id, v.version, z.id;`
+
+// zonesAtColumns is the select list of selectZonesAtQuery over version v, zone z and
+// geometries gv of the version.
+const zonesAtColumns = `
+		SELECT v.zone_id, COALESCE(z.name, ''), z.external_key, COALESCE(z.tags, '{}'), z.schedule, COALESCE(z.layer, ''),
+			   COALESCE(z.created_at, v.valid_from), v.valid_from,` + featureCollectionAgg + ` as geojson`
+
+// zoneGeometriesAt selects zone_id, geom of the zones valid at p.at, or of the current
+// zones when it is NULL, with the schedule of the zone. Like the other metadata the schedule
+// is not versioned, it is the current one. Callers provide p with an at column and join the
+// subquery laterally.
+const zoneGeometriesAt = `(
+			SELECT g.zone_id, g.geom, z.schedule
+			FROM zone_geometry g
+			JOIN zone z ON z.id = g.zone_id
+			WHERE p.at IS NULL
+			UNION ALL
+			SELECT gv.zone_id, gv.geom, z.schedule
+			FROM zone_geometry_version gv
+			JOIN zone_version v ON v.zone_id = gv.zone_id AND v.version = gv.version
+			LEFT JOIN zone z ON z.id = gv.zone_id
+			WHERE p.at IS NOT NULL
+			  AND v.valid_from <= p.at AND (v.valid_to IS NULL OR v.valid_to > p.at)
+		)`
+
+func (s *Storage) GetZoneVersions(ctx context.Context, zoneId int) ([]dto.ZoneVersion, error) {
+	const op = "storage.GetZoneVersions"
+	const query = `
+		SELECT v.zone_id, v.version, v.valid_from, v.valid_to,` + featureCollectionAgg + ` as geojson
+		FROM zone_version v
+		LEFT JOIN zone_geometry_version gv ON gv.zone_id = v.zone_id AND gv.version = v.version
+		WHERE v.zone_id = $1
+		GROUP BY v.zone_id, v.version
+		ORDER BY v.version;`
+
+	rows, err := s.db.Query(ctx, query, zoneId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get versions: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := make([]dto.ZoneVersion, 0)
+	for rows.Next() {
+		var version dto.ZoneVersion
+		err = rows.Scan(&version.ZoneId, &version.Version, &version.ValidFrom, &version.ValidTo, &version.GeoJSON)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan version: %w", op, err)
+		}
+		result = append(result, version)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read versions: %w", op, err)
+	}
+	if len(result) == 0 {
+		return nil, dto.ErrZoneNotFound
+	}
+	return result, nil
+}
+
+// addZoneVersion closes the current version of the zone and copies its geometries into
+// a new one. Callers must hold the zone lock or create the zone in tx. Versions change at
+// the time the lock is held rather than at the start of tx, which may precede the version
+// committed last, so they never overlap.
+func addZoneVersion(ctx context.Context, tx pgx.Tx, zoneId int) error {
+	const op = "storage.addZoneVersion"
+	const query = `
+		WITH ts AS (
+			SELECT GREATEST(clock_timestamp(), max(valid_from)) AS at FROM zone_version WHERE zone_id = $1
+		), closed AS (
+			UPDATE zone_version SET valid_to = ts.at FROM ts WHERE zone_id = $1 AND valid_to IS NULL
+		), version AS (
+			INSERT INTO zone_version (zone_id, version, valid_from)
+			SELECT $1, COALESCE(max(version), 0) + 1, (SELECT at FROM ts) FROM zone_version WHERE zone_id = $1
+			RETURNING version
+		)
+		INSERT INTO zone_geometry_version (zone_id, version, geom, source_geom, radius, properties, feature_id, foreign_members)
+		SELECT zg.zone_id, v.version, zg.geom, zg.source_geom, zg.radius, zg.properties, zg.feature_id, zg.foreign_members
+		FROM zone_geometry zg
+		CROSS JOIN version v
+		WHERE zg.zone_id = $1
+		ORDER BY zg.id;`
+
+	if _, err := tx.Exec(ctx, query, zoneId); err != nil {
+		return fmt.Errorf("%s: failed to add version: %w", op, err)
+	}
+	return nil
+}
+
+// closeZoneVersion ends the current version of a deleted zone, its history is kept.
+// Like addZoneVersion, callers must hold the zone lock, deleting the zone row takes it.
+func closeZoneVersion(ctx context.Context, tx pgx.Tx, zoneId int) error {
+	const op = "storage.closeZoneVersion"
+	const query = `
+		UPDATE zone_version SET valid_to = GREATEST(clock_timestamp(), valid_from)
+		WHERE zone_id = $1 AND valid_to IS NULL;`
+
+	if _, err := tx.Exec(ctx, query, zoneId); err != nil {
+		return fmt.Errorf("%s: failed to close version: %w", op, err)
+	}
+	return nil
+}
+
+// addFirstZoneVersions adds the first versions of the zones created in tx. They start
+// when tx does, like the zones, no other version of them can be committed before.
+func addFirstZoneVersions(ctx context.Context, tx pgx.Tx, zoneIds []int) error {
+	const op = "storage.addFirstZoneVersions"
+	const versionsQuery = `
+		INSERT INTO zone_version (zone_id, version, valid_from)
+		SELECT id, 1, now() FROM unnest($1::int[]) id;`
+	const geometriesQuery = `
+		INSERT INTO zone_geometry_version (zone_id, version, geom, source_geom, radius, properties, feature_id, foreign_members)
+		SELECT zone_id, 1, geom, source_geom, radius, properties, feature_id, foreign_members
+		FROM zone_geometry
+		WHERE zone_id = any($1)
+		ORDER BY id;`
+
+	if _, err := tx.Exec(ctx, versionsQuery, zoneIds); err != nil {
+		return fmt.Errorf("%s: failed to add versions: %w", op, err)
+	}
+	if _, err := tx.Exec(ctx, geometriesQuery, zoneIds); err != nil {
+		return fmt.Errorf("%s: failed to add geometry versions: %w", op, err)
+	}
+	return nil
+}
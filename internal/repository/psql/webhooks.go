@@ -0,0 +1,320 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+const selectWebhookDeliveriesQuery = `
+		SELECT d.id, d.subscription_id, d.status, d.attempts, d.next_attempt_at,
+			   COALESCE(d.last_status_code, 0), COALESCE(d.last_error, ''), d.updated_at,
+			   e.id, e.type, e.payload, e.created_at
+		FROM webhook_delivery d
+		JOIN webhook_event e ON e.id = d.event_id`
+
+func (s *Storage) CreateWebhookSubscription(ctx context.Context, in dto.WebhookSubscriptionIn) (dto.WebhookSubscription, error) {
+	const op = "storage.CreateWebhookSubscription"
+	const query = `
+		INSERT INTO webhook_subscription (url, event_types, secret)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at;`
+
+	subscription := dto.WebhookSubscription{URL: in.URL, EventTypes: in.EventTypes, Secret: in.Secret}
+	err := s.db.QueryRow(ctx, query, in.URL, eventTypesToStrings(in.EventTypes), in.Secret).
+		Scan(&subscription.Id, &subscription.CreatedAt)
+	if err != nil {
+		return dto.WebhookSubscription{}, fmt.Errorf("%s: failed to create subscription: %w", op, err)
+	}
+	return subscription, nil
+}
+
+func (s *Storage) GetWebhookSubscriptions(ctx context.Context) ([]dto.WebhookSubscription, error) {
+	const op = "storage.GetWebhookSubscriptions"
+	const query = `SELECT id, url, event_types, secret, created_at FROM webhook_subscription ORDER BY id;`
+
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get subscriptions: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := make([]dto.WebhookSubscription, 0)
+	for rows.Next() {
+		var subscription dto.WebhookSubscription
+		var eventTypes []string
+		err = rows.Scan(&subscription.Id, &subscription.URL, &eventTypes, &subscription.Secret, &subscription.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan subscription: %w", op, err)
+		}
+		for _, eventType := range eventTypes {
+			subscription.EventTypes = append(subscription.EventTypes, dto.WebhookEventType(eventType))
+		}
+		result = append(result, subscription)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read subscriptions: %w", op, err)
+	}
+	return result, nil
+}
+
+func (s *Storage) DeleteWebhookSubscription(ctx context.Context, id int) error {
+	const op = "storage.DeleteWebhookSubscription"
+	const query = `DELETE FROM webhook_subscription WHERE id = $1;`
+
+	tag, err := s.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("%s: failed to delete subscription: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return dto.ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// EnqueueWebhookEvents enqueues all events in one transaction, skipping the events
+// whose idempotency key is already enqueued.
+func (s *Storage) EnqueueWebhookEvents(ctx context.Context, events []dto.WebhookEventIn) (err error) {
+	const op = "storage.EnqueueWebhookEvents"
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("%s: failed to start transaction: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	for _, event := range events {
+		if err = enqueueWebhookEvent(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) FanOutWebhookEvents(ctx context.Context, limit int) (int, error) {
+	const op = "storage.FanOutWebhookEvents"
+	const query = `
+		WITH events AS (
+			SELECT id, type
+			FROM webhook_event
+			WHERE dispatched_at IS NULL
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), deliveries AS (
+			INSERT INTO webhook_delivery (event_id, subscription_id)
+			SELECT e.id, ws.id
+			FROM events e
+			JOIN webhook_subscription ws ON e.type = any(ws.event_types)
+		)
+		UPDATE webhook_event we
+		SET dispatched_at = now()
+		FROM events e
+		WHERE we.id = e.id;`
+
+	tag, err := s.db.Exec(ctx, query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to fan out events: %w", op, err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func (s *Storage) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]dto.WebhookDeliveryJob, error) {
+	const op = "storage.ClaimWebhookDeliveries"
+	const query = `
+		WITH due AS (
+			SELECT id
+			FROM webhook_delivery
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_delivery d
+		SET next_attempt_at = now() + make_interval(secs => $2),
+			claim = d.claim + 1
+		FROM due, webhook_event e, webhook_subscription ws
+		WHERE d.id = due.id AND e.id = d.event_id AND ws.id = d.subscription_id
+		RETURNING d.id, d.subscription_id, d.attempts, d.claim, e.id, e.type, e.payload, e.created_at, ws.url, ws.secret;`
+
+	rows, err := s.db.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to claim deliveries: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := make([]dto.WebhookDeliveryJob, 0)
+	for rows.Next() {
+		job := dto.WebhookDeliveryJob{Delivery: dto.WebhookDelivery{Status: dto.WebhookDeliveryPending}}
+		err = rows.Scan(
+			&job.Delivery.Id,
+			&job.Delivery.SubscriptionId,
+			&job.Delivery.Attempts,
+			&job.Claim,
+			&job.Delivery.Event.Id,
+			&job.Delivery.Event.Type,
+			&job.Delivery.Event.Data,
+			&job.Delivery.Event.CreatedAt,
+			&job.URL,
+			&job.Secret,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan delivery: %w", op, err)
+		}
+		result = append(result, job)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read deliveries: %w", op, err)
+	}
+	return result, nil
+}
+
+func (s *Storage) CompleteWebhookDelivery(ctx context.Context, result dto.WebhookDeliveryResult) error {
+	const op = "storage.CompleteWebhookDelivery"
+	const query = `
+		UPDATE webhook_delivery
+		SET status = $2,
+			attempts = attempts + 1,
+			last_status_code = NULLIF($3, 0),
+			last_error = NULLIF($4, ''),
+			next_attempt_at = COALESCE($5, next_attempt_at),
+			updated_at = now()
+		WHERE id = $1 AND claim = $6;`
+
+	var nextAttemptAt *time.Time
+	if !result.NextAttemptAt.IsZero() {
+		nextAttemptAt = &result.NextAttemptAt
+	}
+	tag, err := s.db.Exec(ctx, query, result.DeliveryId, result.Status, result.StatusCode, result.Error, nextAttemptAt, result.Claim)
+	if err != nil {
+		return fmt.Errorf("%s: failed to complete delivery: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, dto.ErrWebhookClaimExpired)
+	}
+	return nil
+}
+
+func (s *Storage) GetWebhookDeliveries(ctx context.Context, subscriptionId int, limit int) ([]dto.WebhookDelivery, error) {
+	const op = "storage.GetWebhookDeliveries"
+	const query = selectWebhookDeliveriesQuery + `
+		WHERE d.subscription_id = $1
+		ORDER BY d.id DESC
+		LIMIT $2;`
+
+	rows, err := s.db.Query(ctx, query, subscriptionId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get deliveries: %w", op, err)
+	}
+	return scanWebhookDeliveries(rows, op)
+}
+
+func (s *Storage) GetDeadWebhookDeliveries(ctx context.Context, limit int) ([]dto.WebhookDelivery, error) {
+	const op = "storage.GetDeadWebhookDeliveries"
+	const query = selectWebhookDeliveriesQuery + `
+		WHERE d.status = 'dead'
+		ORDER BY d.id DESC
+		LIMIT $1;`
+
+	rows, err := s.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get dead deliveries: %w", op, err)
+	}
+	return scanWebhookDeliveries(rows, op)
+}
+
+func (s *Storage) RetryWebhookDelivery(ctx context.Context, id int64) error {
+	const op = "storage.RetryWebhookDelivery"
+	const query = `
+		UPDATE webhook_delivery
+		SET status = 'pending', attempts = 0, next_attempt_at = now(), updated_at = now()
+		WHERE id = $1 AND status = 'dead';`
+
+	tag, err := s.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("%s: failed to retry delivery: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return dto.ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
+
+func enqueueZoneEvent(ctx context.Context, db execer, eventType dto.WebhookEventType, zoneId int) error {
+	return enqueueWebhookEvent(ctx, db, dto.WebhookEventIn{Type: eventType, Data: dto.ZoneEventPayload{ZoneId: zoneId}})
+}
+
+func enqueueWebhookEvent(ctx context.Context, db execer, event dto.WebhookEventIn) error {
+	const op = "storage.enqueueWebhookEvent"
+	const query = `
+		INSERT INTO webhook_event (type, payload, idempotency_key)
+		VALUES ($1, $2, NULLIF($3, ''))
+		ON CONFLICT (idempotency_key) DO NOTHING;`
+
+	if _, err := db.Exec(ctx, query, event.Type, event.Data, event.IdempotencyKey); err != nil {
+		return fmt.Errorf("%s: failed to enqueue event: %w", op, err)
+	}
+	return nil
+}
+
+func scanWebhookDeliveries(rows pgx.Rows, op string) ([]dto.WebhookDelivery, error) {
+	defer rows.Close()
+
+	result := make([]dto.WebhookDelivery, 0)
+	for rows.Next() {
+		var delivery dto.WebhookDelivery
+		var nextAttemptAt time.Time
+		err := rows.Scan(
+			&delivery.Id,
+			&delivery.SubscriptionId,
+			&delivery.Status,
+			&delivery.Attempts,
+			&nextAttemptAt,
+			&delivery.LastStatusCode,
+			&delivery.LastError,
+			&delivery.UpdatedAt,
+			&delivery.Event.Id,
+			&delivery.Event.Type,
+			&delivery.Event.Data,
+			&delivery.Event.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan delivery: %w", op, err)
+		}
+		if delivery.Status == dto.WebhookDeliveryPending {
+			delivery.NextAttemptAt = &nextAttemptAt
+		}
+		result = append(result, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read deliveries: %w", op, err)
+	}
+	return result, nil
+}
+
+func eventTypesToStrings(eventTypes []dto.WebhookEventType) []string {
+	result := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		result = append(result, string(eventType))
+	}
+	return result
+}
@@ -0,0 +1,129 @@
+package changes
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/maxsnegir/zones_service/internal/config"
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+// Store reads the zone change log written by the zone storage.
+type Store interface {
+	GetZoneChanges(ctx context.Context, afterSeq int64, limit int) ([]dto.ZoneChange, error)
+}
+
+type Service struct {
+	log   *logrus.Logger
+	store Store
+	cfg   config.ChangesConfig
+	mu    sync.Mutex
+	// notified is closed and replaced by Notify to wake up waiting requests.
+	notified chan struct{}
+}
+
+func New(log *logrus.Logger, store Store, cfg config.ChangesConfig) *Service {
+	return &Service{
+		log:      log,
+		store:    store,
+		cfg:      cfg,
+		notified: make(chan struct{}),
+	}
+}
+
+// Notify wakes up waiting requests to read the change log, it is called on every
+// database notification about a zone change.
+func (s *Service) Notify() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	close(s.notified)
+	s.notified = make(chan struct{})
+}
+
+func (s *Service) GetChanges(ctx context.Context, afterSeq int64, limit int) (dto.ZoneChangesOut, error) {
+	changes, err := s.store.GetZoneChanges(ctx, afterSeq, limit)
+	if err != nil {
+		return dto.ZoneChangesOut{}, err
+	}
+	return newChangesOut(changes, afterSeq), nil
+}
+
+// WaitChanges blocks until there are changes after afterSeq or timeout expires. The
+// change log is read again on Notify, PollInterval only covers missed notifications.
+// An expired timeout is not an error, the result is just empty. Zero or too long
+// timeout is replaced with MaxWaitTimeout.
+func (s *Service) WaitChanges(ctx context.Context, afterSeq int64, limit int, timeout time.Duration) (dto.ZoneChangesOut, error) {
+	if timeout <= 0 || timeout > s.cfg.MaxWaitTimeout {
+		timeout = s.cfg.MaxWaitTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		s.mu.Lock()
+		notified := s.notified
+		s.mu.Unlock()
+
+		changes, err := s.store.GetZoneChanges(ctx, afterSeq, limit)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+				return newChangesOut(nil, afterSeq), nil
+			}
+			return dto.ZoneChangesOut{}, err
+		}
+		if len(changes) > 0 {
+			return newChangesOut(changes, afterSeq), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return newChangesOut(nil, afterSeq), nil
+			}
+			return dto.ZoneChangesOut{}, ctx.Err()
+		case <-notified:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stream sends changes after afterSeq as they appear until ctx is done or send fails.
+// keepAlive is called when there were no changes for a Heartbeat interval.
+func (s *Service) Stream(ctx context.Context, afterSeq int64, limit int, send func(changes []dto.ZoneChange) error, keepAlive func() error) error {
+	for {
+		out, err := s.WaitChanges(ctx, afterSeq, limit, s.cfg.Heartbeat)
+		if err != nil {
+			return err
+		}
+		if len(out.Changes) == 0 {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			err = keepAlive()
+		} else {
+			err = send(out.Changes)
+		}
+		if err != nil {
+			return err
+		}
+		afterSeq = out.LastSeq
+	}
+}
+
+func newChangesOut(changes []dto.ZoneChange, afterSeq int64) dto.ZoneChangesOut {
+	out := dto.ZoneChangesOut{Changes: changes, LastSeq: afterSeq}
+	if out.Changes == nil {
+		out.Changes = make([]dto.ZoneChange, 0)
+	}
+	if len(changes) > 0 {
+		out.LastSeq = changes[len(changes)-1].Seq
+	}
+	return out
+}
@@ -0,0 +1,197 @@
+package changes
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/config"
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/logger"
+	"github.com/maxsnegir/zones_service/internal/repository/psql"
+)
+
+const polygonGeoJson = `
+	{
+		"type": "FeatureCollection",
+		"features": [
+			{
+				"type": "Feature",
+				"properties": {},
+				"geometry": {
+					"type": "Polygon",
+					"coordinates": [[[0, 0], [0, 1], [1, 1], [1, 0], [0, 0]]]
+				}
+			}
+		]
+	}`
+
+func mustFeatureCollection(t *testing.T, data string) geojson.FeatureCollection {
+	t.Helper()
+
+	var featureCollectionJson dto.FeatureCollectionJSON
+	require.NoError(t, json.Unmarshal([]byte(data), &featureCollectionJson))
+
+	var featureCollection geojson.FeatureCollection
+	require.NoError(t, featureCollection.FromFeatureCollectionJSON(featureCollectionJson))
+	return featureCollection
+}
+
+func newTestPsqlStorage(t *testing.T) *psql.TestStorage {
+	t.Helper()
+
+	storage, err := psql.NewTestStorage(context.Background())
+	if err != nil {
+		t.Skipf("postgres is not available: %v", err)
+	}
+	t.Cleanup(storage.ShutDown)
+	return storage
+}
+
+func newTestService(t *testing.T) (*Service, *psql.TestStorage) {
+	t.Helper()
+
+	log := logger.New(config.EnvTest)
+	storage := newTestPsqlStorage(t)
+	cfg := config.ChangesConfig{
+		PollInterval:   5 * time.Millisecond,
+		Heartbeat:      20 * time.Millisecond,
+		MaxWaitTimeout: time.Second,
+	}
+	return New(log, storage, cfg), storage
+}
+
+func TestService_GetChanges(t *testing.T) {
+	ctx := context.Background()
+	s, storage := newTestService(t)
+
+	zoneId, err := storage.SaveZoneFromFeatureCollection(ctx, mustFeatureCollection(t, polygonGeoJson))
+	require.NoError(t, err)
+	require.NoError(t, storage.UpdateZoneFromFeatureCollection(ctx, zoneId, mustFeatureCollection(t, polygonGeoJson)))
+	require.NoError(t, storage.DeleteZoneById(ctx, zoneId))
+
+	tests := []struct {
+		name     string
+		afterSeq int64
+		limit    int
+		types    []dto.ZoneChangeType
+		lastSeq  int64
+	}{
+		{
+			name:    "from start",
+			limit:   10,
+			types:   []dto.ZoneChangeType{dto.ZoneChangeCreated, dto.ZoneChangeUpdated, dto.ZoneChangeDeleted},
+			lastSeq: 3,
+		},
+		{
+			name:     "resume",
+			afterSeq: 1,
+			limit:    1,
+			types:    []dto.ZoneChangeType{dto.ZoneChangeUpdated},
+			lastSeq:  2,
+		},
+		{
+			name:     "no changes",
+			afterSeq: 3,
+			limit:    10,
+			types:    []dto.ZoneChangeType{},
+			lastSeq:  3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := s.GetChanges(ctx, tt.afterSeq, tt.limit)
+			require.NoError(t, err)
+			require.Equal(t, tt.lastSeq, out.LastSeq)
+
+			types := make([]dto.ZoneChangeType, 0, len(out.Changes))
+			for i, change := range out.Changes {
+				require.Equal(t, tt.afterSeq+int64(i)+1, change.Seq)
+				require.Equal(t, zoneId, change.ZoneId)
+				types = append(types, change.Type)
+			}
+			require.Equal(t, tt.types, types)
+		})
+	}
+}
+
+func TestService_WaitChanges(t *testing.T) {
+	ctx := context.Background()
+	s, storage := newTestService(t)
+
+	t.Run("timeout", func(t *testing.T) {
+		out, err := s.WaitChanges(ctx, 0, 10, 20*time.Millisecond)
+		require.NoError(t, err)
+		require.Empty(t, out.Changes)
+		require.Zero(t, out.LastSeq)
+	})
+
+	t.Run("new change", func(t *testing.T) {
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			_, _ = storage.SaveZoneFromFeatureCollection(ctx, mustFeatureCollection(t, polygonGeoJson))
+		}()
+
+		out, err := s.WaitChanges(ctx, 0, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, out.Changes, 1)
+		require.Equal(t, dto.ZoneChangeCreated, out.Changes[0].Type)
+		require.Equal(t, int64(1), out.LastSeq)
+	})
+}
+
+func TestService_Notify(t *testing.T) {
+	ctx := context.Background()
+	log := logger.New(config.EnvTest)
+	storage := newTestPsqlStorage(t)
+	// Polling alone would not find the change before the timeout.
+	s := New(log, storage, config.ChangesConfig{PollInterval: time.Hour, MaxWaitTimeout: time.Hour})
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = storage.SaveZoneFromFeatureCollection(ctx, mustFeatureCollection(t, polygonGeoJson))
+		s.Notify()
+	}()
+
+	out, err := s.WaitChanges(ctx, 0, 10, time.Second)
+	require.NoError(t, err)
+	require.Len(t, out.Changes, 1)
+}
+
+func TestService_Stream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, storage := newTestService(t)
+
+	zoneId, err := storage.SaveZoneFromFeatureCollection(ctx, mustFeatureCollection(t, polygonGeoJson))
+	require.NoError(t, err)
+
+	var received []dto.ZoneChange
+	keepAlives := 0
+	send := func(changes []dto.ZoneChange) error {
+		received = append(received, changes...)
+		if len(received) == 1 {
+			return storage.DeleteZoneById(ctx, zoneId)
+		}
+		return nil
+	}
+	keepAlive := func() error {
+		keepAlives++
+		if len(received) == 2 {
+			cancel()
+		}
+		return nil
+	}
+
+	err = s.Stream(ctx, 0, 10, send, keepAlive)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, received, 2)
+	require.Equal(t, dto.ZoneChangeCreated, received[0].Type)
+	require.Equal(t, dto.ZoneChangeDeleted, received[1].Type)
+	require.Positive(t, keepAlives)
+}
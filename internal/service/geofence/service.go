@@ -0,0 +1,180 @@
+package geofence
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+// ZoneChecker is the part of zone.Provider used to locate devices.
+type ZoneChecker interface {
+	ContainsPoint(ctx context.Context, ids []int, point dto.Point, opts dto.ContainsOptions) ([]dto.ZoneContainsPointOut, error)
+}
+
+// StateStore keeps the last known state of devices. UpdateDeviceState must apply
+// fn atomically per device and keep the state only if fn succeeds; fn gets a zero
+// state for unknown devices.
+type StateStore interface {
+	GetDeviceState(ctx context.Context, deviceId string) (dto.DeviceState, error)
+	UpdateDeviceState(ctx context.Context, deviceId string, fn func(state *dto.DeviceState) error) error
+}
+
+// Publisher receives events of every tracked position, e.g. to notify webhooks.
+// Events sent again must be skipped by their idempotency key.
+type Publisher interface {
+	PublishGeofenceEvents(ctx context.Context, events []dto.GeofenceEvent) error
+}
+
+type Service struct {
+	log       *logrus.Logger
+	zones     ZoneChecker
+	store     StateStore
+	publisher Publisher
+	dwellTime time.Duration
+}
+
+// New creates the service, publisher may be nil.
+func New(log *logrus.Logger, zones ZoneChecker, store StateStore, publisher Publisher, dwellTime time.Duration) *Service {
+	return &Service{
+		log:       log,
+		zones:     zones,
+		store:     store,
+		publisher: publisher,
+		dwellTime: dwellTime,
+	}
+}
+
+// TrackPosition updates the device state and returns events caused by the position.
+// Only zones listed in the request are re-evaluated, the others keep their state.
+//
+// Events are kept in the state until they are published. A failed publish fails the
+// position, the events are published again with the next position of the device.
+func (s *Service) TrackPosition(ctx context.Context, in dto.DevicePositionIn) (dto.DevicePositionOut, error) {
+	const op = "geofence.TrackPosition"
+
+	contains, err := s.zones.ContainsPoint(ctx, in.ZoneIds, in.Point, in.ContainsOptions)
+	if err != nil {
+		return dto.DevicePositionOut{}, err
+	}
+	inside := make(map[int]bool, len(contains))
+	for _, c := range contains {
+		inside[c.ZoneId] = c.Contains
+	}
+
+	var out dto.DevicePositionOut
+	err = s.store.UpdateDeviceState(ctx, in.DeviceId, func(state *dto.DeviceState) error {
+		if in.Time.Before(state.LastSeen) {
+			return dto.ErrStalePosition
+		}
+		out.Events = s.applyPosition(state, in, inside)
+		if s.publisher != nil {
+			state.Unpublished = append(state.Unpublished, out.Events...)
+		}
+		out.DeviceState = *state
+		return nil
+	})
+	if err != nil {
+		return dto.DevicePositionOut{}, err
+	}
+
+	if err = s.publish(ctx, in.DeviceId, out.Unpublished); err != nil {
+		return dto.DevicePositionOut{}, fmt.Errorf("%s: failed to publish events: %w", op, err)
+	}
+	out.Unpublished = nil
+	return out, nil
+}
+
+// publish sends the events in one call and drops them from the device state.
+func (s *Service) publish(ctx context.Context, deviceId string, events []dto.GeofenceEvent) error {
+	if s.publisher == nil || len(events) == 0 {
+		return nil
+	}
+	if err := s.publisher.PublishGeofenceEvents(ctx, events); err != nil {
+		return err
+	}
+
+	last := events[len(events)-1].Sequence
+	return s.store.UpdateDeviceState(ctx, deviceId, func(state *dto.DeviceState) error {
+		// Events of positions tracked meanwhile stay.
+		i := 0
+		for i < len(state.Unpublished) && state.Unpublished[i].Sequence <= last {
+			i++
+		}
+		state.Unpublished = state.Unpublished[i:]
+		return nil
+	})
+}
+
+func (s *Service) GetDeviceState(ctx context.Context, deviceId string) (dto.DeviceState, error) {
+	return s.store.GetDeviceState(ctx, deviceId)
+}
+
+// applyPosition diffs the zones containing the position against the state. Dwell is
+// reported once per stay, on the first position at least dwellTime after the entry.
+func (s *Service) applyPosition(state *dto.DeviceState, in dto.DevicePositionIn, inside map[int]bool) []dto.GeofenceEvent {
+	events := make([]dto.GeofenceEvent, 0)
+	newEvent := func(eventType dto.GeofenceEventType, zoneId int, enteredAt *time.Time) dto.GeofenceEvent {
+		state.Sequence++
+		return dto.GeofenceEvent{
+			Type:      eventType,
+			DeviceId:  in.DeviceId,
+			Sequence:  state.Sequence,
+			ZoneId:    zoneId,
+			Point:     in.Point,
+			Time:      in.Time,
+			EnteredAt: enteredAt,
+		}
+	}
+
+	stays := make(map[int]dto.ZoneStay, len(state.Zones))
+	for _, stay := range state.Zones {
+		stays[stay.ZoneId] = stay
+	}
+
+	for _, zoneId := range uniqueSorted(in.ZoneIds) {
+		stay, was := stays[zoneId]
+		switch {
+		case inside[zoneId] && !was:
+			stays[zoneId] = dto.ZoneStay{ZoneId: zoneId, EnteredAt: in.Time}
+			events = append(events, newEvent(dto.GeofenceEventEnter, zoneId, nil))
+		case inside[zoneId] && was && !stay.Dwelled && in.Time.Sub(stay.EnteredAt) >= s.dwellTime:
+			stay.Dwelled = true
+			stays[zoneId] = stay
+			enteredAt := stay.EnteredAt
+			events = append(events, newEvent(dto.GeofenceEventDwell, zoneId, &enteredAt))
+		case !inside[zoneId] && was:
+			delete(stays, zoneId)
+			enteredAt := stay.EnteredAt
+			events = append(events, newEvent(dto.GeofenceEventExit, zoneId, &enteredAt))
+		}
+	}
+
+	state.DeviceId = in.DeviceId
+	state.Point = in.Point
+	state.LastSeen = in.Time
+	state.Zones = make([]dto.ZoneStay, 0, len(stays))
+	for _, stay := range stays {
+		state.Zones = append(state.Zones, stay)
+	}
+	sort.Slice(state.Zones, func(i, j int) bool { return state.Zones[i].ZoneId < state.Zones[j].ZoneId })
+	return events
+}
+
+func uniqueSorted(ids []int) []int {
+	result := make([]int, 0, len(ids))
+	seen := make(map[int]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+	sort.Ints(result)
+	return result
+}
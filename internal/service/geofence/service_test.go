@@ -0,0 +1,208 @@
+package geofence
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/config"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/logger"
+	"github.com/maxsnegir/zones_service/internal/repository/memory"
+	storageMock "github.com/maxsnegir/zones_service/internal/repository/mocks"
+)
+
+func TestService_TrackPosition(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	enteredAt := at(0)
+
+	mockProvider := storageMock.NewMockProvider(ctrl)
+	s := New(logger.New(config.EnvTest), mockProvider, memory.NewDeviceStateStore(), nil, 5*time.Minute)
+
+	steps := []struct {
+		name     string
+		time     time.Time
+		inside   map[int]bool
+		expected []dto.GeofenceEvent
+		zones    []dto.ZoneStay
+	}{
+		{
+			name:   "enter first zone",
+			time:   at(0),
+			inside: map[int]bool{1: true, 2: false},
+			expected: []dto.GeofenceEvent{
+				{Type: dto.GeofenceEventEnter, DeviceId: "courier", Sequence: 1, ZoneId: 1, Time: at(0)},
+			},
+			zones: []dto.ZoneStay{{ZoneId: 1, EnteredAt: at(0)}},
+		},
+		{
+			name:     "stay before dwell time",
+			time:     at(3),
+			inside:   map[int]bool{1: true, 2: false},
+			expected: []dto.GeofenceEvent{},
+			zones:    []dto.ZoneStay{{ZoneId: 1, EnteredAt: at(0)}},
+		},
+		{
+			name:   "dwell and enter second zone",
+			time:   at(6),
+			inside: map[int]bool{1: true, 2: true},
+			expected: []dto.GeofenceEvent{
+				{Type: dto.GeofenceEventDwell, DeviceId: "courier", Sequence: 2, ZoneId: 1, Time: at(6), EnteredAt: &enteredAt},
+				{Type: dto.GeofenceEventEnter, DeviceId: "courier", Sequence: 3, ZoneId: 2, Time: at(6)},
+			},
+			zones: []dto.ZoneStay{{ZoneId: 1, EnteredAt: at(0), Dwelled: true}, {ZoneId: 2, EnteredAt: at(6)}},
+		},
+		{
+			name:   "exit first zone",
+			time:   at(8),
+			inside: map[int]bool{1: false, 2: true},
+			expected: []dto.GeofenceEvent{
+				{Type: dto.GeofenceEventExit, DeviceId: "courier", Sequence: 4, ZoneId: 1, Time: at(8), EnteredAt: &enteredAt},
+			},
+			zones: []dto.ZoneStay{{ZoneId: 2, EnteredAt: at(6)}},
+		},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			contains := []dto.ZoneContainsPointOut{
+				{ZoneId: 1, Contains: step.inside[1]},
+				{ZoneId: 2, Contains: step.inside[2]},
+			}
+			mockProvider.EXPECT().
+				ContainsPoint(gomock.Any(), []int{1, 2}, gomock.Any(), gomock.Any()).
+				Return(contains, nil).
+				Times(1)
+
+			out, err := s.TrackPosition(ctx, dto.DevicePositionIn{DeviceId: "courier", ZoneIds: []int{1, 2}, Time: step.time})
+			require.NoError(t, err)
+			require.Equal(t, step.expected, out.Events)
+			require.Equal(t, step.zones, out.Zones)
+			require.Equal(t, step.time, out.LastSeen)
+
+			state, err := s.GetDeviceState(ctx, "courier")
+			require.NoError(t, err)
+			require.Equal(t, out.DeviceState, state)
+		})
+	}
+
+	t.Run("stale position", func(t *testing.T) {
+		mockProvider.EXPECT().
+			ContainsPoint(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return([]dto.ZoneContainsPointOut{{ZoneId: 1, Contains: true}}, nil).
+			Times(1)
+
+		_, err := s.TrackPosition(ctx, dto.DevicePositionIn{DeviceId: "courier", ZoneIds: []int{1}, Time: at(1)})
+		require.ErrorIs(t, err, dto.ErrStalePosition)
+
+		state, err := s.GetDeviceState(ctx, "courier")
+		require.NoError(t, err)
+		require.Equal(t, at(8), state.LastSeen)
+	})
+
+	t.Run("unknown device", func(t *testing.T) {
+		_, err := s.GetDeviceState(ctx, "unknown")
+		require.ErrorIs(t, err, dto.ErrDeviceNotFound)
+	})
+}
+
+type publisherFunc func(ctx context.Context, events []dto.GeofenceEvent) error
+
+func (f publisherFunc) PublishGeofenceEvents(ctx context.Context, events []dto.GeofenceEvent) error {
+	return f(ctx, events)
+}
+
+func TestService_TrackPositionPublish(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var published [][]dto.GeofenceEvent
+	publishErr := errors.New("queue is down")
+	publisher := publisherFunc(func(ctx context.Context, events []dto.GeofenceEvent) error {
+		if publishErr != nil {
+			return publishErr
+		}
+		published = append(published, events)
+		return nil
+	})
+
+	mockProvider := storageMock.NewMockProvider(ctrl)
+	mockProvider.EXPECT().
+		ContainsPoint(gomock.Any(), []int{1}, gomock.Any(), gomock.Any()).
+		Return([]dto.ZoneContainsPointOut{{ZoneId: 1, Contains: true}}, nil).
+		Times(3)
+	mockProvider.EXPECT().
+		ContainsPoint(gomock.Any(), []int{1}, gomock.Any(), gomock.Any()).
+		Return([]dto.ZoneContainsPointOut{{ZoneId: 1, Contains: false}}, nil).
+		Times(1)
+	s := New(logger.New(config.EnvTest), mockProvider, memory.NewDeviceStateStore(), publisher, 5*time.Minute)
+
+	// A failed publish fails the position, the state keeps the events to publish.
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	position := dto.DevicePositionIn{DeviceId: "courier", ZoneIds: []int{1}, Time: start}
+	_, err := s.TrackPosition(ctx, position)
+	require.ErrorIs(t, err, publishErr)
+	state, err := s.GetDeviceState(ctx, "courier")
+	require.NoError(t, err)
+	require.Equal(t, []dto.ZoneStay{{ZoneId: 1, EnteredAt: start}}, state.Zones)
+	require.Len(t, state.Unpublished, 1)
+	entered := state.Unpublished[0]
+
+	// The resent position causes no events, the kept ones are published.
+	publishErr = nil
+	out, err := s.TrackPosition(ctx, position)
+	require.NoError(t, err)
+	require.Empty(t, out.Events)
+	require.Equal(t, [][]dto.GeofenceEvent{{entered}}, published)
+	state, err = s.GetDeviceState(ctx, "courier")
+	require.NoError(t, err)
+	require.Empty(t, state.Unpublished)
+
+	// Positions without events are not published.
+	_, err = s.TrackPosition(ctx, dto.DevicePositionIn{DeviceId: "courier", ZoneIds: []int{1}, Time: start.Add(time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, published, 1)
+
+	// The next event of the device gets the next sequence.
+	out, err = s.TrackPosition(ctx, dto.DevicePositionIn{DeviceId: "courier", ZoneIds: []int{1}, Time: start.Add(2 * time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, out.Events, 1)
+	require.Equal(t, entered.Sequence+1, out.Events[0].Sequence)
+	require.Equal(t, [][]dto.GeofenceEvent{{entered}, out.Events}, published)
+}
+
+func TestDeviceStateStore_UpdatePerDevice(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewDeviceStateStore()
+
+	// An update of one device does not wait for an update of another one.
+	blocked := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- store.UpdateDeviceState(ctx, "first", func(state *dto.DeviceState) error {
+			close(blocked)
+			<-release
+			return nil
+		})
+	}()
+	<-blocked
+	require.NoError(t, store.UpdateDeviceState(ctx, "second", func(state *dto.DeviceState) error { return nil }))
+	close(release)
+	require.NoError(t, <-done)
+
+	_, err := store.GetDeviceState(ctx, "first")
+	require.NoError(t, err)
+	_, err = store.GetDeviceState(ctx, "second")
+	require.NoError(t, err)
+}
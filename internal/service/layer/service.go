@@ -0,0 +1,118 @@
+package layer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+// Store keeps layers and their datasets. Publishing a version replaces the zones
+// of the layer in the zone storage at once.
+type Store interface {
+	CreateLayer(ctx context.Context, name string) (dto.Layer, error)
+	GetLayers(ctx context.Context) ([]dto.Layer, error)
+	GetLayer(ctx context.Context, name string) (dto.Layer, error)
+	CreateLayerDraft(ctx context.Context, name string, zones []dto.FeatureCollectionJSON) (dto.LayerVersion, error)
+	GetLayerVersions(ctx context.Context, name string) ([]dto.LayerVersion, error)
+	ValidateLayerVersion(ctx context.Context, name string, version int) (dto.LayerVersion, error)
+	PublishLayerVersion(ctx context.Context, name string, version int) (dto.LayerVersion, error)
+	RollbackLayer(ctx context.Context, name string) (dto.LayerVersion, error)
+}
+
+// DraftError points at the zone of an uploaded dataset the draft is rejected for.
+type DraftError struct {
+	Zone int
+	Err  error
+}
+
+func (e DraftError) Error() string {
+	return fmt.Sprintf("zone %d: %v", e.Zone, e.Err)
+}
+
+func (e DraftError) Unwrap() error {
+	return e.Err
+}
+
+type Service struct {
+	log   *logrus.Logger
+	store Store
+}
+
+func New(log *logrus.Logger, store Store) *Service {
+	return &Service{
+		log:   log,
+		store: store,
+	}
+}
+
+func (s *Service) CreateLayer(ctx context.Context, in dto.LayerIn) (dto.Layer, error) {
+	return s.store.CreateLayer(ctx, in.Name)
+}
+
+func (s *Service) GetLayers(ctx context.Context) ([]dto.Layer, error) {
+	return s.store.GetLayers(ctx)
+}
+
+func (s *Service) GetLayer(ctx context.Context, name string) (dto.Layer, error) {
+	return s.store.GetLayer(ctx, name)
+}
+
+// CreateDraft checks that every zone is a valid FeatureCollection of this layer with
+// an external key unique within the dataset, and stores the dataset as a draft.
+// Geometries are checked by the storage on Validate.
+func (s *Service) CreateDraft(ctx context.Context, name string, in dto.LayerDraftIn) (dto.LayerVersion, error) {
+	keys := make(map[string]struct{}, len(in.Zones))
+	for i, zone := range in.Zones {
+		var featureCollection geojson.FeatureCollection
+		if err := featureCollection.FromFeatureCollectionJSON(zone); err != nil {
+			return dto.LayerVersion{}, DraftError{Zone: i, Err: err}
+		}
+		metadata := featureCollection.Metadata
+		if metadata == nil {
+			continue
+		}
+		if metadata.Layer != "" && metadata.Layer != name {
+			return dto.LayerVersion{}, DraftError{Zone: i, Err: dto.ErrLayerZoneMismatch}
+		}
+		if metadata.ExternalKey != nil {
+			if _, ok := keys[*metadata.ExternalKey]; ok {
+				return dto.LayerVersion{}, DraftError{Zone: i, Err: dto.ErrDuplicateLayerExternalKey}
+			}
+			keys[*metadata.ExternalKey] = struct{}{}
+		}
+	}
+	if in.Zones == nil {
+		in.Zones = []dto.FeatureCollectionJSON{}
+	}
+	return s.store.CreateLayerDraft(ctx, name, in.Zones)
+}
+
+func (s *Service) GetVersions(ctx context.Context, name string) ([]dto.LayerVersion, error) {
+	return s.store.GetLayerVersions(ctx, name)
+}
+
+func (s *Service) Validate(ctx context.Context, name string, version int) (dto.LayerVersion, error) {
+	return s.store.ValidateLayerVersion(ctx, name, version)
+}
+
+func (s *Service) Publish(ctx context.Context, name string, version int) (dto.LayerVersion, error) {
+	published, err := s.store.PublishLayerVersion(ctx, name, version)
+	if err != nil {
+		return dto.LayerVersion{}, err
+	}
+	s.log.Infof("layer %s: version %d published", name, version)
+	return published, nil
+}
+
+func (s *Service) Rollback(ctx context.Context, name string) (dto.LayerVersion, error) {
+	published, err := s.store.RollbackLayer(ctx, name)
+	if err != nil {
+		return dto.LayerVersion{}, err
+	}
+	s.log.Infof("layer %s: rolled back to version %d", name, published.Version)
+	return published, nil
+}
@@ -0,0 +1,40 @@
+package webhook
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+// NewClient returns the client sending webhooks. It connects only to the addresses
+// dto.PublicAddr allows, so a host name resolving to a private address, e.g. one changed
+// after the subscription was created, does not reach the network of the service either.
+// Redirects are checked the same way. Proxies are not used, they would hide the address.
+func NewClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   checkPublicAddr,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport}
+}
+
+// checkPublicAddr is called with the resolved address before connecting.
+func checkPublicAddr(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", dto.ErrPrivateWebhookURL, address)
+	}
+	if !dto.PublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", dto.ErrPrivateWebhookURL, address)
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/maxsnegir/zones_service/internal/config"
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+const (
+	DeliveryHeader  = "X-Webhook-Delivery"
+	EventHeader     = "X-Webhook-Event"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+
+	maxErrorLength = 1024
+)
+
+// Store keeps subscriptions, the event outbox and deliveries. Zone events are
+// written to the outbox by the zone storage in the same transaction as the zone.
+type Store interface {
+	CreateWebhookSubscription(ctx context.Context, in dto.WebhookSubscriptionIn) (dto.WebhookSubscription, error)
+	GetWebhookSubscriptions(ctx context.Context) ([]dto.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id int) error
+	// EnqueueWebhookEvents enqueues all events or none, skipping already enqueued idempotency keys.
+	EnqueueWebhookEvents(ctx context.Context, events []dto.WebhookEventIn) error
+	// FanOutWebhookEvents creates deliveries of outbox events for matching subscriptions.
+	FanOutWebhookEvents(ctx context.Context, limit int) (int, error)
+	// ClaimWebhookDeliveries returns due deliveries and postpones them by lease,
+	// so deliveries of a crashed worker are retried.
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]dto.WebhookDeliveryJob, error)
+	// CompleteWebhookDelivery returns dto.ErrWebhookClaimExpired when the delivery
+	// was claimed again since the claim of the result.
+	CompleteWebhookDelivery(ctx context.Context, result dto.WebhookDeliveryResult) error
+	GetWebhookDeliveries(ctx context.Context, subscriptionId int, limit int) ([]dto.WebhookDelivery, error)
+	GetDeadWebhookDeliveries(ctx context.Context, limit int) ([]dto.WebhookDelivery, error)
+	RetryWebhookDelivery(ctx context.Context, id int64) error
+}
+
+type Service struct {
+	log    *logrus.Logger
+	store  Store
+	client *http.Client
+	cfg    config.WebhookConfig
+}
+
+func New(log *logrus.Logger, store Store, client *http.Client, cfg config.WebhookConfig) *Service {
+	return &Service{
+		log:    log,
+		store:  store,
+		client: client,
+		cfg:    cfg,
+	}
+}
+
+// CreateSubscription generates a secret when none is given. The secret is returned only here.
+func (s *Service) CreateSubscription(ctx context.Context, in dto.WebhookSubscriptionIn) (dto.WebhookSubscription, error) {
+	if in.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return dto.WebhookSubscription{}, fmt.Errorf("failed to generate secret: %w", err)
+		}
+		in.Secret = hex.EncodeToString(secret)
+	}
+	return s.store.CreateWebhookSubscription(ctx, in)
+}
+
+func (s *Service) GetSubscriptions(ctx context.Context) ([]dto.WebhookSubscription, error) {
+	subscriptions, err := s.store.GetWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	return subscriptions, nil
+}
+
+func (s *Service) DeleteSubscription(ctx context.Context, id int) error {
+	return s.store.DeleteWebhookSubscription(ctx, id)
+}
+
+func (s *Service) GetDeliveries(ctx context.Context, subscriptionId int, limit int) ([]dto.WebhookDelivery, error) {
+	return s.store.GetWebhookDeliveries(ctx, subscriptionId, limit)
+}
+
+func (s *Service) GetDeadLetters(ctx context.Context, limit int) ([]dto.WebhookDelivery, error) {
+	return s.store.GetDeadWebhookDeliveries(ctx, limit)
+}
+
+func (s *Service) RetryDeadLetter(ctx context.Context, id int64) error {
+	return s.store.RetryWebhookDelivery(ctx, id)
+}
+
+// PublishGeofenceEvents enqueues device events; it implements geofence.Publisher.
+// Events already published are skipped by their idempotency key.
+func (s *Service) PublishGeofenceEvents(ctx context.Context, events []dto.GeofenceEvent) error {
+	in := make([]dto.WebhookEventIn, 0, len(events))
+	for _, event := range events {
+		var eventType dto.WebhookEventType
+		switch event.Type {
+		case dto.GeofenceEventEnter:
+			eventType = dto.WebhookEventDeviceEntered
+		case dto.GeofenceEventExit:
+			eventType = dto.WebhookEventDeviceExited
+		case dto.GeofenceEventDwell:
+			eventType = dto.WebhookEventDeviceDwelled
+		default:
+			continue
+		}
+		in = append(in, dto.WebhookEventIn{Type: eventType, Data: event, IdempotencyKey: event.IdempotencyKey()})
+	}
+	if len(in) == 0 {
+		return nil
+	}
+	return s.store.EnqueueWebhookEvents(ctx, in)
+}
+
+// Run delivers webhooks until ctx is done.
+func (s *Service) Run(ctx context.Context) {
+	const op = "webhook.Run"
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			processed, err := s.ProcessOnce(ctx)
+			if err != nil {
+				s.log.Error(fmt.Sprintf("%s: %v", op, err))
+				break
+			}
+			if processed < s.cfg.BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessOnce fans out pending events and sends one batch of due deliveries.
+// It returns the number of sent deliveries.
+//
+// The batch is sent concurrently, every request is bounded by the timeout, so the
+// whole batch is done well within the lease. Deliveries are not ordered, retries
+// reorder them anyway. A result whose lease ran out anyway is
+// dropped, the delivery belongs to the worker that claimed it again.
+func (s *Service) ProcessOnce(ctx context.Context) (int, error) {
+	const op = "webhook.ProcessOnce"
+
+	if _, err := s.store.FanOutWebhookEvents(ctx, s.cfg.BatchSize); err != nil {
+		return 0, err
+	}
+	jobs, err := s.store.ClaimWebhookDeliveries(ctx, s.cfg.BatchSize, 2*s.cfg.Timeout)
+	if err != nil {
+		return 0, err
+	}
+
+	results := make([]dto.WebhookDeliveryResult, len(jobs))
+	var wg sync.WaitGroup
+	for i := range jobs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = s.deliver(ctx, jobs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		err = s.store.CompleteWebhookDelivery(ctx, result)
+		if errors.Is(err, dto.ErrWebhookClaimExpired) {
+			s.log.Error(fmt.Sprintf("%s: delivery %d: %v", op, result.DeliveryId, err))
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(jobs), nil
+}
+
+func (s *Service) deliver(ctx context.Context, job dto.WebhookDeliveryJob) dto.WebhookDeliveryResult {
+	result := dto.WebhookDeliveryResult{DeliveryId: job.Delivery.Id, Claim: job.Claim, Status: dto.WebhookDeliveryDelivered}
+
+	statusCode, err := s.send(ctx, job)
+	result.StatusCode = statusCode
+	if err == nil {
+		return result
+	}
+
+	result.Error = err.Error()
+	if len(result.Error) > maxErrorLength {
+		result.Error = result.Error[:maxErrorLength]
+	}
+	attempts := job.Delivery.Attempts + 1
+	if attempts >= s.cfg.MaxAttempts {
+		result.Status = dto.WebhookDeliveryDead
+		return result
+	}
+	result.Status = dto.WebhookDeliveryPending
+	result.NextAttemptAt = time.Now().Add(s.backoff(attempts))
+	return result
+}
+
+func (s *Service) send(ctx context.Context, job dto.WebhookDeliveryJob) (int, error) {
+	body, err := json.Marshal(job.Delivery.Event)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(job.Delivery.Id, 10))
+	req.Header.Set(EventHeader, string(job.Delivery.Event.Type))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(job.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff doubles the delay after every failed attempt up to BackoffMax.
+func (s *Service) backoff(attempts int) time.Duration {
+	delay := s.cfg.BackoffBase
+	for i := 1; i < attempts && delay < s.cfg.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > s.cfg.BackoffMax {
+		delay = s.cfg.BackoffMax
+	}
+	return delay
+}
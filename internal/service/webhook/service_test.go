@@ -0,0 +1,392 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxsnegir/zones_service/internal/config"
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+	"github.com/maxsnegir/zones_service/internal/logger"
+	"github.com/maxsnegir/zones_service/internal/repository/psql"
+)
+
+const polygonGeoJson = `
+	{
+		"type": "FeatureCollection",
+		"features": [
+			{
+				"type": "Feature",
+				"properties": {},
+				"geometry": {
+					"type": "Polygon",
+					"coordinates": [[[0, 0], [0, 1], [1, 1], [1, 0], [0, 0]]]
+				}
+			}
+		]
+	}`
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver is a webhook endpoint answering with the queued status codes, then 200.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, receivedRequest{header: req.Header.Clone(), body: body})
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (rc *receiver) received() []receivedRequest {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]receivedRequest(nil), rc.requests...)
+}
+
+func newTestPsqlStorage(t *testing.T) *psql.TestStorage {
+	t.Helper()
+
+	storage, err := psql.NewTestStorage(context.Background())
+	if err != nil {
+		t.Skipf("postgres is not available: %v", err)
+	}
+	t.Cleanup(storage.ShutDown)
+	return storage
+}
+
+func newTestService(t *testing.T, statuses ...int) (*Service, *psql.TestStorage, *receiver, *httptest.Server) {
+	t.Helper()
+
+	storage := newTestPsqlStorage(t)
+	rc := &receiver{statuses: statuses}
+	server := httptest.NewServer(rc)
+	t.Cleanup(server.Close)
+
+	log := logger.New(config.EnvTest)
+	cfg := config.WebhookConfig{
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+		MaxAttempts:  3,
+		BackoffBase:  time.Millisecond,
+		BackoffMax:   5 * time.Millisecond,
+		BatchSize:    10,
+	}
+	return New(log, storage, server.Client(), cfg), storage, rc, server
+}
+
+func mustFeatureCollection(t *testing.T, data string) geojson.FeatureCollection {
+	t.Helper()
+
+	var featureCollectionJson dto.FeatureCollectionJSON
+	require.NoError(t, json.Unmarshal([]byte(data), &featureCollectionJson))
+
+	var featureCollection geojson.FeatureCollection
+	require.NoError(t, featureCollection.FromFeatureCollectionJSON(featureCollectionJson))
+	return featureCollection
+}
+
+// processUntil runs ProcessOnce until cond holds, waiting for the backoff between runs.
+func processUntil(t *testing.T, s *Service, cond func() bool) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		_, err := s.ProcessOnce(context.Background())
+		require.NoError(t, err)
+		return cond()
+	}, 2*time.Second, 5*time.Millisecond)
+}
+
+func TestService_DeliverZoneEvents(t *testing.T) {
+	ctx := context.Background()
+	s, storage, rc, server := newTestService(t)
+
+	subscription, err := s.CreateSubscription(ctx, dto.WebhookSubscriptionIn{
+		URL:        server.URL,
+		EventTypes: []dto.WebhookEventType{dto.WebhookEventZoneCreated, dto.WebhookEventZoneDeleted},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, subscription.Secret)
+
+	zoneId, err := storage.SaveZoneFromFeatureCollection(ctx, mustFeatureCollection(t, polygonGeoJson))
+	require.NoError(t, err)
+	require.NoError(t, storage.UpdateZoneProperties(ctx, zoneId, []map[string]interface{}{{"name": "a"}}))
+	require.NoError(t, storage.DeleteZoneById(ctx, zoneId))
+	// Deleting a missing zone is not an event.
+	require.NoError(t, storage.DeleteZoneById(ctx, zoneId))
+
+	processUntil(t, s, func() bool { return len(rc.received()) == 2 })
+
+	// Deliveries of a batch are sent concurrently, in no particular order.
+	receivedTypes := make([]dto.WebhookEventType, 0, 2)
+	for _, request := range rc.received() {
+		require.Equal(t, "application/json", request.header.Get("Content-Type"))
+
+		timestamp, err := strconv.ParseInt(request.header.Get(TimestampHeader), 10, 64)
+		require.NoError(t, err)
+		require.True(t, Verify(subscription.Secret, timestamp, request.body, request.header.Get(SignatureHeader)))
+		require.False(t, Verify("wrong secret", timestamp, request.body, request.header.Get(SignatureHeader)))
+
+		var event dto.WebhookEvent
+		require.NoError(t, json.Unmarshal(request.body, &event))
+		require.Equal(t, string(event.Type), request.header.Get(EventHeader))
+		receivedTypes = append(receivedTypes, event.Type)
+
+		var payload dto.ZoneEventPayload
+		require.NoError(t, json.Unmarshal(event.Data, &payload))
+		require.Equal(t, zoneId, payload.ZoneId)
+	}
+	require.ElementsMatch(t, []dto.WebhookEventType{dto.WebhookEventZoneCreated, dto.WebhookEventZoneDeleted}, receivedTypes)
+
+	deliveries, err := s.GetDeliveries(ctx, subscription.Id, dto.DefaultWebhookDeliveriesLimit)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	for _, delivery := range deliveries {
+		require.Equal(t, dto.WebhookDeliveryDelivered, delivery.Status)
+		require.Equal(t, 1, delivery.Attempts)
+		require.Equal(t, http.StatusOK, delivery.LastStatusCode)
+	}
+
+	subscriptions, err := s.GetSubscriptions(ctx)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	require.Empty(t, subscriptions[0].Secret)
+}
+
+func TestService_RetryWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	s, _, rc, server := newTestService(t, http.StatusInternalServerError, http.StatusBadGateway)
+
+	subscription, err := s.CreateSubscription(ctx, dto.WebhookSubscriptionIn{
+		URL:        server.URL,
+		EventTypes: []dto.WebhookEventType{dto.WebhookEventDeviceEntered},
+		Secret:     "secret",
+	})
+	require.NoError(t, err)
+
+	events := []dto.GeofenceEvent{
+		{Type: dto.GeofenceEventEnter, DeviceId: "courier", Sequence: 1, ZoneId: 1, Time: time.Now()},
+		// Not subscribed.
+		{Type: dto.GeofenceEventExit, DeviceId: "courier", Sequence: 2, ZoneId: 2, Time: time.Now()},
+	}
+	require.NoError(t, s.PublishGeofenceEvents(ctx, events))
+	// Published events are skipped when sent again.
+	require.NoError(t, s.PublishGeofenceEvents(ctx, events))
+
+	processUntil(t, s, func() bool { return len(rc.received()) == 3 })
+
+	deliveries, err := s.GetDeliveries(ctx, subscription.Id, dto.DefaultWebhookDeliveriesLimit)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, dto.WebhookDeliveryDelivered, deliveries[0].Status)
+	require.Equal(t, 3, deliveries[0].Attempts)
+	require.Equal(t, dto.WebhookEventDeviceEntered, deliveries[0].Event.Type)
+
+	// Every attempt sends the same event.
+	for _, request := range rc.received() {
+		require.Equal(t, rc.received()[0].body, request.body)
+		require.Equal(t, strconv.FormatInt(deliveries[0].Id, 10), request.header.Get(DeliveryHeader))
+	}
+}
+
+func TestService_DeadLetters(t *testing.T) {
+	ctx := context.Background()
+	s, storage, rc, server := newTestService(t,
+		http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError,
+	)
+
+	subscription, err := s.CreateSubscription(ctx, dto.WebhookSubscriptionIn{
+		URL:        server.URL,
+		EventTypes: []dto.WebhookEventType{dto.WebhookEventZoneCreated},
+	})
+	require.NoError(t, err)
+	_, err = storage.SaveZoneFromFeatureCollection(ctx, mustFeatureCollection(t, polygonGeoJson))
+	require.NoError(t, err)
+
+	processUntil(t, s, func() bool {
+		deadLetters, err := s.GetDeadLetters(ctx, dto.DefaultWebhookDeliveriesLimit)
+		require.NoError(t, err)
+		return len(deadLetters) == 1
+	})
+	require.Len(t, rc.received(), 3)
+
+	deadLetters, err := s.GetDeadLetters(ctx, dto.DefaultWebhookDeliveriesLimit)
+	require.NoError(t, err)
+	require.Equal(t, subscription.Id, deadLetters[0].SubscriptionId)
+	require.Equal(t, 3, deadLetters[0].Attempts)
+	require.Equal(t, http.StatusInternalServerError, deadLetters[0].LastStatusCode)
+	require.NotEmpty(t, deadLetters[0].LastError)
+
+	// Dead deliveries are not sent anymore.
+	processed, err := s.ProcessOnce(ctx)
+	require.NoError(t, err)
+	require.Zero(t, processed)
+
+	require.ErrorIs(t, s.RetryDeadLetter(ctx, deadLetters[0].Id+1), dto.ErrWebhookDeliveryNotFound)
+	require.NoError(t, s.RetryDeadLetter(ctx, deadLetters[0].Id))
+	require.ErrorIs(t, s.RetryDeadLetter(ctx, deadLetters[0].Id), dto.ErrWebhookDeliveryNotFound)
+
+	processUntil(t, s, func() bool { return len(rc.received()) == 4 })
+
+	deadLetters, err = s.GetDeadLetters(ctx, dto.DefaultWebhookDeliveriesLimit)
+	require.NoError(t, err)
+	require.Empty(t, deadLetters)
+
+	require.NoError(t, s.DeleteSubscription(ctx, subscription.Id))
+	require.ErrorIs(t, s.DeleteSubscription(ctx, subscription.Id), dto.ErrSubscriptionNotFound)
+	deliveries, err := s.GetDeliveries(ctx, subscription.Id, dto.DefaultWebhookDeliveriesLimit)
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+}
+
+// Two workers share the outbox. The endpoint is slow enough that a batch sent one
+// request after another outlives its lease, still every delivery is sent once.
+func TestService_TwoWorkersSlowEndpoint(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	storage := newTestPsqlStorage(t)
+
+	var mu sync.Mutex
+	received := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		received[req.Header.Get(DeliveryHeader)]++
+		mu.Unlock()
+		time.Sleep(300 * time.Millisecond)
+	}))
+	t.Cleanup(server.Close)
+
+	log := logger.New(config.EnvTest)
+	cfg := config.WebhookConfig{
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      500 * time.Millisecond,
+		MaxAttempts:  3,
+		BackoffBase:  time.Millisecond,
+		BackoffMax:   5 * time.Millisecond,
+		BatchSize:    5,
+	}
+	workers := []*Service{
+		New(log, storage, server.Client(), cfg),
+		New(log, storage, server.Client(), cfg),
+	}
+
+	subscription, err := workers[0].CreateSubscription(ctx, dto.WebhookSubscriptionIn{
+		URL:        server.URL,
+		EventTypes: []dto.WebhookEventType{dto.WebhookEventDeviceEntered},
+	})
+	require.NoError(t, err)
+	const eventsCount = 12
+	events := make([]dto.GeofenceEvent, 0, eventsCount)
+	for i := 0; i < eventsCount; i++ {
+		events = append(events, dto.GeofenceEvent{Type: dto.GeofenceEventEnter, DeviceId: "courier", Sequence: int64(i + 1), ZoneId: i, Time: time.Now()})
+	}
+	require.NoError(t, workers[0].PublishGeofenceEvents(ctx, events))
+
+	var wg sync.WaitGroup
+	for _, worker := range workers {
+		wg.Add(1)
+		go func(worker *Service) {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				if _, err := worker.ProcessOnce(ctx); err != nil && ctx.Err() == nil {
+					t.Errorf("process: %v", err)
+					return
+				}
+			}
+		}(worker)
+	}
+
+	require.Eventually(t, func() bool {
+		deliveries, err := workers[0].GetDeliveries(ctx, subscription.Id, dto.DefaultWebhookDeliveriesLimit)
+		require.NoError(t, err)
+		for _, delivery := range deliveries {
+			if delivery.Status != dto.WebhookDeliveryDelivered {
+				return false
+			}
+		}
+		return len(deliveries) == eventsCount
+	}, 10*time.Second, 50*time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, eventsCount)
+	for id, count := range received {
+		require.Equal(t, 1, count, "delivery %s", id)
+	}
+}
+
+func TestService_ExpiredClaim(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestPsqlStorage(t)
+
+	_, err := storage.CreateWebhookSubscription(ctx, dto.WebhookSubscriptionIn{
+		URL:        "https://example.com",
+		EventTypes: []dto.WebhookEventType{dto.WebhookEventDeviceEntered},
+		Secret:     "secret",
+	})
+	require.NoError(t, err)
+	require.NoError(t, storage.EnqueueWebhookEvents(ctx, []dto.WebhookEventIn{
+		{Type: dto.WebhookEventDeviceEntered, Data: dto.GeofenceEvent{DeviceId: "courier"}},
+	}))
+	_, err = storage.FanOutWebhookEvents(ctx, 1)
+	require.NoError(t, err)
+
+	// The first lease is over at once, the delivery is claimed again.
+	stale, err := storage.ClaimWebhookDeliveries(ctx, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, stale, 1)
+	current, err := storage.ClaimWebhookDeliveries(ctx, 1, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, current, 1)
+	require.Equal(t, stale[0].Delivery.Id, current[0].Delivery.Id)
+
+	result := dto.WebhookDeliveryResult{DeliveryId: stale[0].Delivery.Id, Claim: stale[0].Claim, Status: dto.WebhookDeliveryDelivered}
+	require.ErrorIs(t, storage.CompleteWebhookDelivery(ctx, result), dto.ErrWebhookClaimExpired)
+	result.Claim = current[0].Claim
+	require.NoError(t, storage.CompleteWebhookDelivery(ctx, result))
+}
+
+func TestService_Backoff(t *testing.T) {
+	s := New(nil, nil, nil, config.WebhookConfig{BackoffBase: 2 * time.Second, BackoffMax: time.Minute})
+
+	require.Equal(t, 2*time.Second, s.backoff(1))
+	require.Equal(t, 4*time.Second, s.backoff(2))
+	require.Equal(t, 32*time.Second, s.backoff(5))
+	require.Equal(t, time.Minute, s.backoff(6))
+	require.Equal(t, time.Minute, s.backoff(20))
+}
+
+func TestNewClient(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	t.Cleanup(server.Close)
+
+	// The test server listens on the loopback address, webhooks must not reach it.
+	_, err := NewClient().Get(server.URL)
+	require.ErrorIs(t, err, dto.ErrPrivateWebhookURL)
+	require.Empty(t, rc.received())
+}
@@ -0,0 +1,146 @@
+package zone
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/maxsnegir/zones_service/internal/domain/geojson"
+	"github.com/maxsnegir/zones_service/internal/dto"
+)
+
+// ImportZones reads NDJSON, one FeatureCollection per line, and creates all zones at
+// once. Blank lines are skipped. Invalid lines are reported in the result and nothing
+// is imported then, at most dto.MaxImportErrors lines are reported. Lines are parsed as the mode requires,
+// a srid other than 0 declares the crs of every line. Zones are passed to the storage as
+// they are read, only their line numbers and external keys are kept.
+func (s *Service) ImportZones(ctx context.Context, r io.Reader, mode dto.GeoJSONMode, srid int) (dto.ZoneImportOut, error) {
+	var result dto.ZoneImportOut
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), dto.MaxImportLineSize)
+	source := &importSource{scanner: scanner, mode: mode, srid: srid, keys: make(map[string]struct{})}
+
+	ids, err := s.zoneSaver.ImportZones(ctx, source)
+	if errors.Is(err, errInvalidImportLines) {
+		result.Errors = source.errors
+		return result, nil
+	}
+	if err != nil {
+		var rejected dto.ImportZonesErr
+		if !errors.As(err, &rejected) {
+			return dto.ZoneImportOut{}, err
+		}
+		for _, zoneErr := range rejected {
+			result.Errors = append(result.Errors, dto.ImportLineError{Line: source.lines[zoneErr.Index], Error: zoneErr.Err.Error()})
+		}
+		sort.Slice(result.Errors, func(i, j int) bool { return result.Errors[i].Line < result.Errors[j].Line })
+		if len(result.Errors) > dto.MaxImportErrors {
+			result.Errors = result.Errors[:dto.MaxImportErrors]
+		}
+		return result, nil
+	}
+	if len(source.lines) == 0 {
+		return dto.ZoneImportOut{}, dto.ErrEmptyData
+	}
+	s.log.Infof("imported %d zones", len(ids))
+	result.Created = ids
+	return result, nil
+}
+
+// errInvalidImportLines fails the import source when lines are invalid, they are in its errors.
+var errInvalidImportLines = errors.New("invalid import lines")
+
+// importSource yields the zones of the NDJSON lines as they are read. After the first invalid
+// line zones are not yielded anymore, the remaining lines are only checked for errors.
+type importSource struct {
+	scanner *bufio.Scanner
+	mode    dto.GeoJSONMode
+	srid    int
+
+	line    int
+	current geojson.FeatureCollection
+	// lines are the line numbers of the yielded zones.
+	lines  []int
+	keys   map[string]struct{}
+	errors []dto.ImportLineError
+	err    error
+	done   bool
+}
+
+func (s *importSource) Next() bool {
+	if s.done {
+		return false
+	}
+	for len(s.errors) < dto.MaxImportErrors && s.scanner.Scan() {
+		s.line++
+		data := bytes.TrimSpace(s.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		featureCollection, err := parseImportLine(data, s.mode, s.srid)
+		if err != nil {
+			s.errors = append(s.errors, dto.ImportLineError{Line: s.line, Error: err.Error()})
+			continue
+		}
+		if metadata := featureCollection.Metadata; metadata != nil && metadata.ExternalKey != nil {
+			if _, ok := s.keys[*metadata.ExternalKey]; ok {
+				s.errors = append(s.errors, dto.ImportLineError{Line: s.line, Error: dto.ErrDuplicateImportExternalKey.Error()})
+				continue
+			}
+			s.keys[*metadata.ExternalKey] = struct{}{}
+		}
+		if len(s.errors) > 0 {
+			continue
+		}
+		s.current = featureCollection
+		s.lines = append(s.lines, s.line)
+		return true
+	}
+	s.done = true
+	s.current = geojson.FeatureCollection{}
+	if err := s.scanner.Err(); err != nil {
+		if !errors.Is(err, bufio.ErrTooLong) {
+			s.err = err
+			return false
+		}
+		s.errors = append(s.errors, dto.ImportLineError{Line: s.line + 1, Error: dto.ErrImportLineTooLong.Error()})
+	}
+	return false
+}
+
+func (s *importSource) FeatureCollection() geojson.FeatureCollection {
+	return s.current
+}
+
+func (s *importSource) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.errors) > 0 {
+		return errInvalidImportLines
+	}
+	return nil
+}
+
+func parseImportLine(data []byte, mode dto.GeoJSONMode, srid int) (geojson.FeatureCollection, error) {
+	var featureCollection geojson.FeatureCollection
+
+	var featureCollectionJSON dto.FeatureCollectionJSON
+	if err := json.Unmarshal(data, &featureCollectionJSON); err != nil {
+		return featureCollection, geojson.SerializationErr
+	}
+	if err := featureCollection.ParseFeatureCollectionJSON(featureCollectionJSON, mode); err != nil {
+		return featureCollection, err
+	}
+	if srid != 0 {
+		if err := featureCollection.DeclareCRS(srid); err != nil {
+			return featureCollection, err
+		}
+	}
+	return featureCollection, nil
+}
@@ -2,6 +2,9 @@ package zone
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -9,8 +12,14 @@ import (
 	"github.com/maxsnegir/zones_service/internal/dto"
 )
 
+var ErrNoResult = errors.New("no result for item")
+
 type Saver interface {
 	SaveZoneFromFeatureCollection(ctx context.Context, featureCollection geojson.FeatureCollection) (int, error)
+	UpdateZoneFromFeatureCollection(ctx context.Context, zoneId int, featureCollection geojson.FeatureCollection) error
+	UpdateZoneProperties(ctx context.Context, zoneId int, properties []map[string]interface{}) error
+	ImportZones(ctx context.Context, zones geojson.Source) ([]int, error)
+	RepairGeometries(ctx context.Context, featureCollection geojson.FeatureCollection) (geojson.FeatureCollection, []dto.GeometryRepair, error)
 }
 
 type Deleter interface {
@@ -18,10 +27,19 @@ type Deleter interface {
 }
 
 type Provider interface {
-	GetZonesByIds(ctx context.Context, ids []int) ([]dto.ZoneGeoJSON, error)
-	ContainsPoint(ctx context.Context, ids []int, point dto.Point) ([]dto.ZoneContainsPointOut, error)
-	AnyContainsPoint(ctx context.Context, ids []int, point dto.Point) (bool, error)
+	GetZonesByIds(ctx context.Context, ids []int, at *time.Time, srid int) ([]dto.ZoneGeoJSON, error)
+	GetZoneVersions(ctx context.Context, zoneId int) ([]dto.ZoneVersion, error)
+	GetZoneByExternalKey(ctx context.Context, key string) (dto.ZoneGeoJSON, error)
+	GetLayerZoneIds(ctx context.Context, layer string) ([]int, error)
+	ListZones(ctx context.Context, in dto.ZonesListIn) ([]dto.ZoneGeoJSON, error)
+	FindZonesContainingPoint(ctx context.Context, point dto.Point) ([]dto.ZoneGeoJSON, error)
+	NearestZones(ctx context.Context, ids []int, point dto.Point, limit int) ([]dto.NearestZoneOut, error)
+	RouteCrossings(ctx context.Context, ids []int, track []dto.Point) ([]dto.ZoneCrossingOut, error)
+	ContainsPoint(ctx context.Context, ids []int, point dto.Point, opts dto.ContainsOptions) ([]dto.ZoneContainsPointOut, error)
+	AnyContainsPoint(ctx context.Context, ids []int, point dto.Point, opts dto.ContainsOptions) (dto.AnyContainsPointOut, error)
+	GetZonesTile(ctx context.Context, tile dto.TileIn) ([]byte, error)
 	GetZonesCount(ctx context.Context) (int, error)
+	ExportZones(ctx context.Context, filter dto.ZonesFilter, fn func(dto.ZoneGeoJSON) error) error
 	ButchAnyZoneContainsPoint(ctx context.Context, in dto.BatchZoneContainsPointInCollection) ([]dto.BatchZoneContainsPointOut, error)
 }
 
@@ -48,27 +66,231 @@ func (s *Service) SaveZoneFromFeatureCollection(
 	return s.zoneSaver.SaveZoneFromFeatureCollection(ctx, featureCollection)
 }
 
-func (s *Service) GetZonesByIds(ctx context.Context, ids []int) ([]dto.ZoneGeoJSON, error) {
-	return s.zoneProvider.GetZonesByIds(ctx, ids)
+func (s *Service) UpdateZoneFromFeatureCollection(
+	ctx context.Context,
+	zoneId int,
+	featureCollection geojson.FeatureCollection,
+) error {
+	return s.zoneSaver.UpdateZoneFromFeatureCollection(ctx, zoneId, featureCollection)
+}
+
+// RepairGeometries repairs the invalid geometries of the zone to be saved, the repairs describe what changed.
+func (s *Service) RepairGeometries(
+	ctx context.Context,
+	featureCollection geojson.FeatureCollection,
+) (geojson.FeatureCollection, []dto.GeometryRepair, error) {
+	return s.zoneSaver.RepairGeometries(ctx, featureCollection)
+}
+
+func (s *Service) UpdateZoneProperties(ctx context.Context, zoneId int, data dto.ZonePropertiesPatchIn) error {
+	return s.zoneSaver.UpdateZoneProperties(ctx, zoneId, data.Properties)
+}
+
+// GetZonesByIds returns the current zones, or the versions valid at at when it is set,
+// with geometries in srid.
+func (s *Service) GetZonesByIds(ctx context.Context, ids []int, at *time.Time, srid int) ([]dto.ZoneGeoJSON, error) {
+	return s.zoneProvider.GetZonesByIds(ctx, ids, at, srid)
+}
+
+func (s *Service) GetZoneById(ctx context.Context, id int, at *time.Time) (dto.ZoneGeoJSON, error) {
+	zones, err := s.zoneProvider.GetZonesByIds(ctx, []int{id}, at, dto.WGS84)
+	if err != nil {
+		return dto.ZoneGeoJSON{}, err
+	}
+	if len(zones) == 0 {
+		return dto.ZoneGeoJSON{}, dto.ErrZoneNotFound
+	}
+	return zones[0], nil
+}
+
+func (s *Service) GetZoneVersions(ctx context.Context, id int) ([]dto.ZoneVersion, error) {
+	return s.zoneProvider.GetZoneVersions(ctx, id)
+}
+
+func (s *Service) GetZoneByExternalKey(ctx context.Context, key string) (dto.ZoneGeoJSON, error) {
+	return s.zoneProvider.GetZoneByExternalKey(ctx, key)
+}
+
+// ListZones returns a page of zones, NextCursor is set when the page is full and more zones follow.
+func (s *Service) ListZones(ctx context.Context, in dto.ZonesListIn) (dto.ZonesPageOut, error) {
+	page := dto.ZonesPageOut{Zones: make([]dto.ZoneListItemJSON, 0)}
+
+	// One more zone is read to tell whether the page is the last one.
+	query := in
+	query.Limit++
+	zones, err := s.zoneProvider.ListZones(ctx, query)
+	if err != nil {
+		return page, err
+	}
+	if len(zones) > in.Limit {
+		zones = zones[:in.Limit]
+		page.NextCursor = dto.NewZonesCursor(in.Sort, zones[len(zones)-1]).String()
+	}
+	for _, zone := range zones {
+		item := dto.ZoneListItemJSON{
+			ZoneId:       zone.ZoneId,
+			ZoneMetadata: zone.ZoneMetadata,
+			CreatedAt:    zone.CreatedAt,
+			UpdatedAt:    zone.UpdatedAt,
+		}
+		if !in.WithoutGeometry {
+			featureCollection := zone.GeoJSON
+			item.GeoJSON = &featureCollection
+		}
+		page.Zones = append(page.Zones, item)
+	}
+	return page, nil
+}
+
+func (s *Service) GetZonesCount(ctx context.Context) (int, error) {
+	return s.zoneProvider.GetZonesCount(ctx)
+}
+
+// ExportZones calls fn for every zone of the export in id order, it stops at the first error of fn.
+func (s *Service) ExportZones(ctx context.Context, in dto.ExportIn, fn func(dto.ZoneGeoJSON) error) error {
+	return s.zoneProvider.ExportZones(ctx, in.ZonesFilter, fn)
+}
+
+func (s *Service) FindZonesContainingPoint(ctx context.Context, data dto.PointIn) ([]dto.ZoneGeoJSON, error) {
+	return s.zoneProvider.FindZonesContainingPoint(ctx, data.Point)
+}
+
+func (s *Service) NearestZones(ctx context.Context, data dto.NearestZonesIn) ([]dto.NearestZoneOut, error) {
+	return s.zoneProvider.NearestZones(ctx, data.ZoneIds, data.Point, data.Limit)
+}
+
+// RouteCrossings returns zones crossed by the route with entry and exit
+// times interpolated from the route points when they carry time.
+func (s *Service) RouteCrossings(ctx context.Context, data dto.RouteCrossingIn) ([]dto.ZoneCrossingOut, error) {
+	crossings, err := s.zoneProvider.RouteCrossings(ctx, data.ZoneIds, data.Track())
+	if err != nil {
+		return nil, err
+	}
+	times := data.Times()
+	for i := range crossings {
+		for j := range crossings[i].Segments {
+			segment := &crossings[i].Segments[j]
+			segment.EntryTime = times.At(segment.EntryFraction)
+			segment.ExitTime = times.At(segment.ExitFraction)
+		}
+	}
+	return crossings, nil
+}
+
+func (s *Service) GetZonesTile(ctx context.Context, tile dto.TileIn) ([]byte, error) {
+	return s.zoneProvider.GetZonesTile(ctx, tile)
 }
 
 func (s *Service) ContainsPoint(ctx context.Context, data dto.ZoneContainsPointIn) ([]dto.ZoneContainsPointOut, error) {
-	return s.zoneProvider.ContainsPoint(ctx, data.ZoneIds, data.Point)
+	ids, err := s.targetIds(ctx, data.ZoneIds, data.Layer)
+	if err != nil {
+		return nil, err
+	}
+	return s.zoneProvider.ContainsPoint(ctx, ids, data.Point, data.ContainsOptions)
 }
 
-func (s *Service) AnyZoneContainsPoint(ctx context.Context, data dto.ZoneContainsPointIn) (bool, error) {
-	return s.zoneProvider.AnyContainsPoint(ctx, data.ZoneIds, data.Point)
+func (s *Service) AnyZoneContainsPoint(ctx context.Context, data dto.ZoneContainsPointIn) (dto.AnyContainsPointOut, error) {
+	ids, err := s.targetIds(ctx, data.ZoneIds, data.Layer)
+	if err != nil {
+		return dto.AnyContainsPointOut{}, err
+	}
+	return s.zoneProvider.AnyContainsPoint(ctx, ids, data.Point, data.ContainsOptions)
+}
+
+// targetIds returns the ids of the layer zones when the layer is set.
+func (s *Service) targetIds(ctx context.Context, ids []int, layer string) ([]int, error) {
+	if layer == "" {
+		return ids, nil
+	}
+	return s.zoneProvider.GetLayerZoneIds(ctx, layer)
 }
 
 func (s *Service) DeleteZone(ctx context.Context, id int) error {
 	return s.zoneDeleter.DeleteZoneById(ctx, id)
 }
 
-type BatchZoneContainsPointOutWithError struct {
-	dto.BatchZoneContainsPointOut
-	Error error
+// ButchAnyZoneContainsPoint validates items one by one: invalid items get their
+// error inline and only valid ones are sent to the provider. Layers are resolved
+// to zone ids once per batch, items with an unknown layer get the error inline.
+// Items the provider rejects get dto.ErrBatchItemRejected inline, see checkBatch,
+// other provider errors fail the batch.
+func (s *Service) ButchAnyZoneContainsPoint(ctx context.Context, in dto.BatchZoneContainsPointInCollection) ([]dto.BatchZoneContainsPointOut, error) {
+	results := make([]dto.BatchZoneContainsPointOut, len(in))
+	valid := make(dto.BatchZoneContainsPointInCollection, 0, len(in))
+	layerIds := make(map[string][]int)
+	for i, item := range in {
+		results[i].Key = item.Key
+		if err := item.Validate(); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if item.Layer != "" {
+			ids, ok := layerIds[item.Layer]
+			if !ok {
+				var err error
+				ids, err = s.zoneProvider.GetLayerZoneIds(ctx, item.Layer)
+				if errors.Is(err, dto.ErrLayerNotFound) {
+					results[i].Error = err.Error()
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+				layerIds[item.Layer] = ids
+			}
+			item.ZoneIds, item.Layer = ids, ""
+		}
+		valid = append(valid, item)
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	out, err := s.checkBatch(ctx, valid)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]dto.BatchZoneContainsPointOut, len(out))
+	for _, o := range out {
+		byKey[o.Key] = o
+	}
+	for i := range results {
+		if results[i].Error != "" {
+			continue
+		}
+		if o, ok := byKey[results[i].Key]; ok {
+			results[i] = o
+		} else {
+			results[i].Error = ErrNoResult.Error()
+		}
+	}
+	return results, nil
 }
 
-func (s *Service) ButchAnyZoneContainsPoint(ctx context.Context, in dto.BatchZoneContainsPointInCollection) ([]dto.BatchZoneContainsPointOut, error) {
-	return s.zoneProvider.ButchAnyZoneContainsPoint(ctx, in)
+// checkBatch sends the items to the provider. A batch the provider rejects because of
+// the input of an item is split in halves until the rejected items are found, they get
+// dto.ErrBatchItemRejected inline and their errors are logged. Any other error, e.g. of
+// the connection or ctx, fails the batch at once.
+func (s *Service) checkBatch(ctx context.Context, items dto.BatchZoneContainsPointInCollection) ([]dto.BatchZoneContainsPointOut, error) {
+	const op = "zone.checkBatch"
+
+	out, err := s.zoneProvider.ButchAnyZoneContainsPoint(ctx, items)
+	if err == nil || !errors.Is(err, dto.ErrBatchItemRejected) {
+		return out, err
+	}
+	if len(items) == 1 {
+		s.log.Error(fmt.Sprintf("%s: item %q: %v", op, items[0].Key, err))
+		return []dto.BatchZoneContainsPointOut{{Key: items[0].Key, Error: dto.ErrBatchItemRejected.Error()}}, nil
+	}
+
+	middle := len(items) / 2
+	first, err := s.checkBatch(ctx, items[:middle])
+	if err != nil {
+		return nil, err
+	}
+	second, err := s.checkBatch(ctx, items[middle:])
+	if err != nil {
+		return nil, err
+	}
+	return append(first, second...), nil
 }
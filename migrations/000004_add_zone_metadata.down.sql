@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS zone_tags_idx;
+ALTER TABLE zone
+    DROP COLUMN IF EXISTS name,
+    DROP COLUMN IF EXISTS external_key,
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS updated_at;
\ No newline at end of file
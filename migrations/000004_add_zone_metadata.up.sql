@@ -0,0 +1,7 @@
+ALTER TABLE zone
+    ADD COLUMN IF NOT EXISTS name         VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS external_key VARCHAR(255) UNIQUE,
+    ADD COLUMN IF NOT EXISTS tags         TEXT[]       NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS created_at   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS updated_at   TIMESTAMPTZ  NOT NULL DEFAULT now();
+CREATE INDEX IF NOT EXISTS zone_tags_idx ON zone USING GIN (tags);
\ No newline at end of file
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS webhook_subscription
+(
+    id          SERIAL PRIMARY KEY,
+    url         TEXT        NOT NULL,
+    event_types TEXT[]      NOT NULL,
+    secret      TEXT        NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS webhook_event
+(
+    id            BIGSERIAL PRIMARY KEY,
+    type          TEXT        NOT NULL,
+    payload       JSONB       NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    dispatched_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS webhook_event_pending_idx ON webhook_event (id) WHERE dispatched_at IS NULL;
+CREATE TABLE IF NOT EXISTS webhook_delivery
+(
+    id               BIGSERIAL PRIMARY KEY,
+    event_id         BIGINT      NOT NULL REFERENCES webhook_event (id) ON DELETE CASCADE,
+    subscription_id  INT         NOT NULL REFERENCES webhook_subscription (id) ON DELETE CASCADE,
+    status           TEXT        NOT NULL DEFAULT 'pending',
+    attempts         INT         NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_status_code INT,
+    last_error       TEXT,
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS webhook_delivery_due_idx ON webhook_delivery (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_delivery_subscription_idx ON webhook_delivery (subscription_id, id);
+CREATE INDEX IF NOT EXISTS webhook_delivery_dead_idx ON webhook_delivery (id) WHERE status = 'dead';
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS zone_change;
\ No newline at end of file
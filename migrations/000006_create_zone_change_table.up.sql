@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS zone_change
+(
+    seq        BIGSERIAL PRIMARY KEY,
+    zone_id    INT         NOT NULL,
+    type       TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS zone_geometry_version;
+DROP TABLE IF EXISTS zone_version;
\ No newline at end of file
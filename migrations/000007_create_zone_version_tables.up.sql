@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS zone_version
+(
+    zone_id    INT         NOT NULL,
+    version    INT         NOT NULL,
+    valid_from TIMESTAMPTZ NOT NULL,
+    valid_to   TIMESTAMPTZ,
+    PRIMARY KEY (zone_id, version)
+);
+CREATE TABLE IF NOT EXISTS zone_geometry_version
+(
+    id         SERIAL PRIMARY KEY,
+    zone_id    INT NOT NULL,
+    version    INT NOT NULL,
+    geom       GEOMETRY,
+    properties json,
+    FOREIGN KEY (zone_id, version) REFERENCES zone_version (zone_id, version) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS zone_geometry_version_geom_idx ON zone_geometry_version USING GIST (geom);
+CREATE INDEX IF NOT EXISTS zone_geometry_version_idx ON zone_geometry_version (zone_id, version);
+INSERT INTO zone_version (zone_id, version, valid_from)
+SELECT id, 1, created_at FROM zone;
+INSERT INTO zone_geometry_version (zone_id, version, geom, properties)
+SELECT zone_id, 1, geom, properties FROM zone_geometry ORDER BY id;
\ No newline at end of file
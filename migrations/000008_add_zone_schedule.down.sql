@@ -0,0 +1,2 @@
+ALTER TABLE zone
+    DROP COLUMN IF EXISTS schedule;
\ No newline at end of file
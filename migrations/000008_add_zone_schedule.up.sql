@@ -0,0 +1,2 @@
+ALTER TABLE zone
+    ADD COLUMN IF NOT EXISTS schedule JSONB;
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS layer_version;
+DROP INDEX IF EXISTS zone_layer_idx;
+ALTER TABLE zone
+    DROP COLUMN IF EXISTS layer;
+DROP TABLE IF EXISTS layer;
\ No newline at end of file
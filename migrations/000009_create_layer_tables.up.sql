@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS layer
+(
+    name              VARCHAR(64) PRIMARY KEY,
+    published_version INT,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+INSERT INTO layer (name) VALUES ('default') ON CONFLICT DO NOTHING;
+ALTER TABLE zone
+    ADD COLUMN IF NOT EXISTS layer VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES layer (name);
+CREATE INDEX IF NOT EXISTS zone_layer_idx ON zone (layer);
+CREATE TABLE IF NOT EXISTS layer_version
+(
+    layer        VARCHAR(64) NOT NULL REFERENCES layer (name) ON DELETE CASCADE,
+    version      INT         NOT NULL,
+    status       TEXT        NOT NULL DEFAULT 'draft',
+    dataset      JSONB       NOT NULL,
+    errors       JSONB,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    published_at TIMESTAMPTZ,
+    PRIMARY KEY (layer, version)
+);
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS zone_geometry_properties_idx;
+DROP INDEX IF EXISTS zone_created_at_idx;
\ No newline at end of file
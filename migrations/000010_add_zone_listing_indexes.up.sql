@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS zone_created_at_idx ON zone (created_at, id);
+CREATE INDEX IF NOT EXISTS zone_geometry_properties_idx ON zone_geometry USING GIN ((properties::jsonb));
\ No newline at end of file
@@ -0,0 +1,8 @@
+ALTER TABLE zone_geometry_version
+    DROP COLUMN IF EXISTS feature_id,
+    DROP COLUMN IF EXISTS foreign_members;
+ALTER TABLE zone_geometry
+    DROP COLUMN IF EXISTS feature_id,
+    DROP COLUMN IF EXISTS foreign_members;
+ALTER TABLE zone
+    DROP COLUMN IF EXISTS foreign_members;
\ No newline at end of file
@@ -0,0 +1,8 @@
+ALTER TABLE zone
+    ADD COLUMN IF NOT EXISTS foreign_members JSONB;
+ALTER TABLE zone_geometry
+    ADD COLUMN IF NOT EXISTS feature_id      JSONB,
+    ADD COLUMN IF NOT EXISTS foreign_members JSONB;
+ALTER TABLE zone_geometry_version
+    ADD COLUMN IF NOT EXISTS feature_id      JSONB,
+    ADD COLUMN IF NOT EXISTS foreign_members JSONB;
\ No newline at end of file
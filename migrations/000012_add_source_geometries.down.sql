@@ -0,0 +1,7 @@
+DROP FUNCTION IF EXISTS zone_areal_geometry(GEOMETRY, DOUBLE PRECISION);
+ALTER TABLE zone_geometry_version
+    DROP COLUMN IF EXISTS source_geom,
+    DROP COLUMN IF EXISTS radius;
+ALTER TABLE zone_geometry
+    DROP COLUMN IF EXISTS source_geom,
+    DROP COLUMN IF EXISTS radius;
\ No newline at end of file
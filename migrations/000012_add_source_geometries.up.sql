@@ -0,0 +1,24 @@
+ALTER TABLE zone_geometry
+    ADD COLUMN IF NOT EXISTS source_geom GEOMETRY,
+    ADD COLUMN IF NOT EXISTS radius      DOUBLE PRECISION;
+ALTER TABLE zone_geometry_version
+    ADD COLUMN IF NOT EXISTS source_geom GEOMETRY,
+    ADD COLUMN IF NOT EXISTS radius      DOUBLE PRECISION;
+
+-- zone_areal_geometry buffers the points and lines of g by radius meters and merges them
+-- with its polygons into a MultiPolygon. Polygons and MultiPolygons are returned as they are.
+CREATE OR REPLACE FUNCTION zone_areal_geometry(g GEOMETRY, radius DOUBLE PRECISION) RETURNS GEOMETRY
+    LANGUAGE sql
+    IMMUTABLE
+AS
+$$
+SELECT CASE
+           WHEN GeometryType(g) IN ('POLYGON', 'MULTIPOLYGON') THEN g
+           ELSE (SELECT ST_Multi(ST_CollectionExtract(ST_UnaryUnion(ST_Collect(
+                   CASE
+                       WHEN ST_Dimension(d.geom) = 2 THEN d.geom
+                       ELSE ST_SetSRID(ST_Buffer(d.geom::geography, radius)::geometry, ST_SRID(g))
+                       END)), 3))
+                 FROM ST_Dump(g) d)
+           END
+$$;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE zone_geometry_version
+    ALTER COLUMN geom TYPE GEOMETRY USING ST_SetSRID(geom, 0),
+    ALTER COLUMN source_geom TYPE GEOMETRY USING ST_SetSRID(source_geom, 0);
+ALTER TABLE zone_geometry
+    ALTER COLUMN geom TYPE GEOMETRY USING ST_SetSRID(geom, 0),
+    ALTER COLUMN source_geom TYPE GEOMETRY USING ST_SetSRID(source_geom, 0);
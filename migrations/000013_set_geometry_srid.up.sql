@@ -0,0 +1,7 @@
+-- Geometries were stored without an SRID in lon/lat, they are EPSG:4326 from now on.
+ALTER TABLE zone_geometry
+    ALTER COLUMN geom TYPE GEOMETRY(Geometry, 4326) USING ST_SetSRID(geom, 4326),
+    ALTER COLUMN source_geom TYPE GEOMETRY(Geometry, 4326) USING ST_SetSRID(source_geom, 4326);
+ALTER TABLE zone_geometry_version
+    ALTER COLUMN geom TYPE GEOMETRY(Geometry, 4326) USING ST_SetSRID(geom, 4326),
+    ALTER COLUMN source_geom TYPE GEOMETRY(Geometry, 4326) USING ST_SetSRID(source_geom, 4326);
@@ -0,0 +1,2 @@
+-- Every claim increments the counter, a worker completes a delivery only while its claim is the last one.
+ALTER TABLE webhook_delivery ADD COLUMN IF NOT EXISTS claim INT NOT NULL DEFAULT 0;
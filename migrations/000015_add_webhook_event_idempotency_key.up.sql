@@ -0,0 +1,2 @@
+ALTER TABLE webhook_event ADD COLUMN IF NOT EXISTS idempotency_key TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_event_idempotency_key_idx ON webhook_event (idempotency_key);
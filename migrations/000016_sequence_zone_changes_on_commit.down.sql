@@ -0,0 +1,11 @@
+DELETE FROM zone_change WHERE seq IS NULL;
+DROP INDEX IF EXISTS zone_change_unsequenced_idx;
+DROP INDEX IF EXISTS zone_change_seq_idx;
+ALTER TABLE zone_change DROP CONSTRAINT IF EXISTS zone_change_pkey;
+ALTER TABLE zone_change DROP COLUMN IF EXISTS id;
+CREATE SEQUENCE IF NOT EXISTS zone_change_seq_seq OWNED BY zone_change.seq;
+SELECT setval('zone_change_seq_seq', COALESCE(max(seq), 0) + 1, false) FROM zone_change;
+ALTER TABLE zone_change
+    ALTER COLUMN seq SET DEFAULT nextval('zone_change_seq_seq'),
+    ALTER COLUMN seq SET NOT NULL;
+ALTER TABLE zone_change ADD PRIMARY KEY (seq);
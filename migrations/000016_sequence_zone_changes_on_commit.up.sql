@@ -0,0 +1,11 @@
+-- Changes are written without a sequence number, it is assigned after the writing
+-- transaction commits, see recordZoneChange.
+ALTER TABLE zone_change ADD COLUMN IF NOT EXISTS id BIGSERIAL;
+ALTER TABLE zone_change DROP CONSTRAINT IF EXISTS zone_change_pkey;
+ALTER TABLE zone_change ADD PRIMARY KEY (id);
+ALTER TABLE zone_change
+    ALTER COLUMN seq DROP DEFAULT,
+    ALTER COLUMN seq DROP NOT NULL;
+DROP SEQUENCE IF EXISTS zone_change_seq_seq;
+CREATE UNIQUE INDEX IF NOT EXISTS zone_change_seq_idx ON zone_change (seq);
+CREATE INDEX IF NOT EXISTS zone_change_unsequenced_idx ON zone_change (id) WHERE seq IS NULL;